@@ -0,0 +1,104 @@
+package api
+
+import (
+	"sort"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+/**
+How the pairs of a request's market connect to the rebase asset, see market.Connectivity.
+Pairs and assets are named as in the request's market. With an asset registry, assets that are one are analyzed as one,
+and are all listed wherever it is.
+*/
+type ConnectivityOutput struct {
+	RebaseAssetId string `json:"rebaseAssetId"`
+	// longest rebase paths taken into account
+	MaxPathLength uint8      `json:"maxPathLength"`
+	Components    [][]string `json:"components"`
+	// fewest hops to price every reachable asset
+	Distances         map[string]int `json:"distances"`
+	UnreachableAssets []string       `json:"unreachableAssets"`
+	// symbols
+	UnreachablePairs []string        `json:"unreachablePairs"`
+	Suggestions      []SuggestedPair `json:"suggestions"`
+}
+
+/**
+Missing pair that would make unreachable pairs reachable, see market.PairSuggestion. As the pair isn't in the market,
+its symbol is in canonical asset ids with an asset registry. Volume is the combined base volume of the connected pairs
+in the suggested pair's quote asset.
+*/
+type SuggestedPair struct {
+	Symbol string `json:"symbol"`
+	// symbols
+	ConnectedPairs []string `json:"connectedPairs"`
+	Volume         float32  `json:"volume"`
+}
+
+/**
+Analyzes which assets and pairs of a request's market its rebase can reach, and which missing pairs would reach more.
+The market is filtered and includes the request's reference rates and pegs like a rebase's, and like a rebase's, paths
+only go through the pairs within the request's liquidity limits. Paths are at most the request's maxPathLength long, or
+the maximum automatic path length without one. A request with a basket is analyzed from the basket's first asset.
+*/
+func Connectivity(i Input) (ConnectivityOutput, error) {
+	prepared, err := prepare(i)
+	if err != nil {
+		return ConnectivityOutput{}, err
+	}
+	rebaseAssetId, err := i.pathRebaseAssetId()
+	if err != nil {
+		return ConnectivityOutput{}, err
+	}
+
+	graph, hopMarket, _ := prepared.engine.Graph(prepared.assetId(rebaseAssetId), &prepared.market)
+	maxPathLength := prepared.engine.MaxPathDepth()
+	connectivity := graph.ConnectivityThrough(prepared.assetId(rebaseAssetId), maxPathLength, hopMarket)
+	output := ConnectivityOutput{
+		RebaseAssetId:     rebaseAssetId,
+		MaxPathLength:     maxPathLength,
+		Components:        [][]string{},
+		Distances:         map[string]int{},
+		UnreachableAssets: prepared.assetIds(connectivity.UnreachableAssetIds),
+		UnreachablePairs:  prepared.symbols(graph, connectivity.UnreachablePairIds),
+		Suggestions:       []SuggestedPair{},
+	}
+	for _, component := range connectivity.Components {
+		output.Components = append(output.Components, prepared.assetIds(component))
+	}
+	for assetId, distance := range connectivity.AssetDistances {
+		for _, originalId := range prepared.originalAssetIds(assetId) {
+			output.Distances[originalId] = distance
+		}
+	}
+	for _, suggestion := range connectivity.Suggestions {
+		suggestedPair := m.Pair{BaseAssetId: suggestion.BaseAssetId, QuoteAssetId: suggestion.QuoteAssetId}
+		output.Suggestions = append(output.Suggestions, SuggestedPair{
+			Symbol:         suggestedPair.Symbol(),
+			ConnectedPairs: prepared.symbols(graph, suggestion.PairIds),
+			Volume:         suggestion.Volume,
+		})
+	}
+	return output, nil
+}
+
+// sorted ids that the request's market knows the assets by
+func (p *preparedInput) assetIds(assetIds []string) []string {
+	result := []string{}
+	for _, assetId := range assetIds {
+		result = append(result, p.originalAssetIds(assetId)...)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// sorted symbols that the request's market knows the market's pairs by
+func (p *preparedInput) symbols(market *m.Market, pairIds []string) []string {
+	result := []string{}
+	for _, pairId := range pairIds {
+		result = append(result, p.pairSymbols(market.PairsById[pairId])...)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func mockConnectivityInput() Input {
+	input := mockInput()
+	input.Market = append(input.Market, m.Pair{
+		BaseAssetId:     "BTC",
+		QuoteAssetId:    "ETH",
+		ExchangeMarkets: []m.ExchangeMarket{{CurrentBid: 15, CurrentAsk: 16, BaseVolume: 10}},
+	})
+	return input
+}
+
+func TestConnectivity(t *testing.T) {
+	Convey("reports unreachable assets and pairs and the pairs that would reach them", t, func() {
+		actual, err := Connectivity(mockConnectivityInput())
+
+		So(err, ShouldBeNil)
+		So(actual.RebaseAssetId, ShouldEqual, "USD")
+		So(actual.MaxPathLength, ShouldEqual, 3)
+		So(actual.Components, ShouldResemble, [][]string{{"EUR", "GBP", "USD"}, {"BTC", "ETH"}})
+		So(actual.Distances, ShouldResemble, map[string]int{"USD": 0, "EUR": 1, "GBP": 2})
+		So(actual.UnreachableAssets, ShouldResemble, []string{"BTC", "ETH"})
+		So(actual.UnreachablePairs, ShouldResemble, []string{"BTC/ETH"})
+		So(actual.Suggestions, ShouldResemble, []SuggestedPair{{Symbol: "USD/BTC", ConnectedPairs: []string{"BTC/ETH"}, Volume: 10}})
+	})
+	Convey("takes pegs into account", t, func() {
+		input := mockConnectivityInput()
+		input.Pegs = []PegInput{{AssetId: "BTC", PeggedTo: "EUR"}}
+
+		actual, err := Connectivity(input)
+
+		So(err, ShouldBeNil)
+		So(actual.UnreachablePairs, ShouldBeEmpty)
+		So(actual.Distances["ETH"], ShouldEqual, 3)
+	})
+	Convey("uses the maximum automatic path length without a maxPathLength", t, func() {
+		input := mockConnectivityInput()
+		input.MaxPathLength = 0
+		input.AutoPathLength = &AutoPathLengthInput{MaxPathLength: 1}
+
+		actual, err := Connectivity(input)
+
+		So(err, ShouldBeNil)
+		So(actual.MaxPathLength, ShouldEqual, 1)
+		So(actual.UnreachablePairs, ShouldResemble, []string{"BTC/ETH", "EUR/GBP"})
+	})
+	Convey("only follows paths through the pairs within the liquidity limits", t, func() {
+		input := mockConnectivityInput()
+		input.Market[0].ExchangeMarkets[0].BaseVolume = 1000
+		input.Market = append(input.Market, m.Pair{
+			BaseAssetId:     "GBP",
+			QuoteAssetId:    "JPY",
+			ExchangeMarkets: []m.ExchangeMarket{{CurrentBid: 150, CurrentAsk: 151, BaseVolume: 1000}},
+		})
+		input.MinCombinedVolume = 500
+
+		actual, err := Connectivity(input)
+
+		So(err, ShouldBeNil)
+		So(actual.Distances, ShouldResemble, map[string]int{"USD": 0, "EUR": 1, "GBP": 2})
+		So(actual.UnreachablePairs, ShouldResemble, []string{"BTC/ETH", "GBP/JPY"})
+	})
+	Convey("keeps the original symbols with an asset registry", t, func() {
+		input := mockConnectivityInput()
+		input.Market[2].BaseAssetId = "xbt"
+		input.Market = append(input.Market, m.Pair{
+			BaseAssetId:     "BTC",
+			QuoteAssetId:    "ETH",
+			ExchangeMarkets: []m.ExchangeMarket{{ExchangeId: "b", CurrentBid: 15, CurrentAsk: 16, BaseVolume: 10}},
+		})
+		input.Assets = &AssetsInput{Aliases: map[string][]string{"BTC": {"XBT"}}}
+
+		actual, err := Connectivity(input)
+
+		So(err, ShouldBeNil)
+		So(actual.Components, ShouldResemble, [][]string{{"EUR", "GBP", "USD"}, {"BTC", "ETH", "xbt"}})
+		So(actual.UnreachableAssets, ShouldResemble, []string{"BTC", "ETH", "xbt"})
+		So(actual.UnreachablePairs, ShouldResemble, []string{"BTC/ETH", "xbt/ETH"})
+		So(actual.Suggestions, ShouldResemble, []SuggestedPair{
+			{Symbol: "USD/BTC", ConnectedPairs: []string{"BTC/ETH", "xbt/ETH"}, Volume: 20},
+		})
+	})
+}
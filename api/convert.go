@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+/**
+Request to convert an amount of one asset into another, at the prices of the request's rebased market.
+*/
+type ConvertInput struct {
+	Input
+	FromAssetId string `json:"fromAssetId"`
+	// defaults to the rebase asset
+	ToAssetId string  `json:"toAssetId"`
+	Amount    float64 `json:"amount"`
+}
+
+type ConvertOutput struct {
+	FromAssetId string  `json:"fromAssetId"`
+	ToAssetId   string  `json:"toAssetId"`
+	Amount      float64 `json:"amount"`
+	// amount of ToAssetId that Amount of FromAssetId is worth
+	ConvertedAmount float64 `json:"convertedAmount"`
+	PathLength      uint8   `json:"pathLength"`
+	// set when a budget ran out and the prices may be based on a partially rebased market
+	Partial       bool   `json:"partial,omitempty"`
+	PartialReason string `json:"partialReason,omitempty"`
+	// set when either price relies on a reference rate
+	Referenced bool `json:"referenced,omitempty"`
+	// set when either price relies on a peg
+	Pegged bool `json:"pegged,omitempty"`
+}
+
+/**
+Converts an amount by rebasing the request's market and pricing both assets in the rebase asset.
+Fails if either asset can't be priced.
+*/
+func Convert(ctx context.Context, i ConvertInput) (ConvertOutput, error) {
+	toAssetId := i.ToAssetId
+	if toAssetId == "" {
+		toAssetId = i.RebaseAssetId
+	}
+
+	rebased, err := rebase(ctx, i.Input)
+	if err != nil {
+		return ConvertOutput{}, err
+	}
+	prices := rebased.assetPrices(i.RebaseAssetId)
+	fromPrice, ok := prices[rebased.assetId(i.FromAssetId)]
+	if !ok {
+		return ConvertOutput{}, fmt.Errorf(`no price for asset "%s" in rebaseId "%s"`, i.FromAssetId, i.RebaseAssetId)
+	}
+	toPrice, ok := prices[rebased.assetId(toAssetId)]
+	if !ok {
+		return ConvertOutput{}, fmt.Errorf(`no price for asset "%s" in rebaseId "%s"`, toAssetId, i.RebaseAssetId)
+	}
+
+	return ConvertOutput{
+		FromAssetId:     i.FromAssetId,
+		ToAssetId:       toAssetId,
+		Amount:          i.Amount,
+		ConvertedAmount: i.Amount * float64(fromPrice) / float64(toPrice),
+		PathLength:      rebased.PathDepth,
+		Partial:         rebased.partialReason != "",
+		PartialReason:   rebased.partialReason,
+		Referenced:      rebased.referenced(i.FromAssetId) || rebased.referenced(toAssetId),
+		Pegged:          rebased.pegged(i.FromAssetId) || rebased.pegged(toAssetId),
+	}, nil
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	Convey("converts into the rebase asset by default", t, func() {
+		actual, err := Convert(context.Background(), ConvertInput{Input: mockInput(), FromAssetId: "EUR", Amount: 2})
+
+		So(err, ShouldBeNil)
+		So(actual.ToAssetId, ShouldEqual, "USD")
+		So(actual.ConvertedAmount, ShouldAlmostEqual, 2.25, 0.0001)
+		So(actual.PathLength, ShouldEqual, 3)
+	})
+	Convey("converts between any priced assets", t, func() {
+		actual, err := Convert(context.Background(), ConvertInput{
+			Input:       mockInput(),
+			FromAssetId: "GBP",
+			ToAssetId:   "EUR",
+			Amount:      1,
+		})
+
+		So(err, ShouldBeNil)
+		So(actual.ConvertedAmount, ShouldAlmostEqual, 1.155, 0.0001)
+	})
+	Convey("converts aliased assets", t, func() {
+		input := mockInput()
+		input.Assets = &AssetsInput{Equivalent: [][]string{{"GBP", "XGBP"}}}
+
+		actual, err := Convert(context.Background(), ConvertInput{Input: input, FromAssetId: "xgbp", ToAssetId: "eur", Amount: 1})
+
+		So(err, ShouldBeNil)
+		So(actual.FromAssetId, ShouldEqual, "xgbp")
+		So(actual.ConvertedAmount, ShouldAlmostEqual, 1.155, 0.0001)
+	})
+	Convey("flags prices that rely on a peg", t, func() {
+		input := mockInput()
+		input.Market[1].BaseAssetId = "EURT"
+		input.Pegs = []PegInput{{AssetId: "EURT", PeggedTo: "EUR"}}
+
+		pegged, err := Convert(context.Background(), ConvertInput{Input: input, FromAssetId: "GBP", Amount: 1})
+		So(err, ShouldBeNil)
+		notPegged, err := Convert(context.Background(), ConvertInput{Input: input, FromAssetId: "EUR", Amount: 1})
+		So(err, ShouldBeNil)
+
+		So(pegged.Pegged, ShouldBeTrue)
+		So(notPegged.Pegged, ShouldBeFalse)
+	})
+	Convey("flags prices that rely on a reference rate", t, func() {
+		input := mockInput()
+		input.Market[1].BaseAssetId = "XEUR"
+		input.ReferenceRates = &ReferenceRatesInput{
+			Rates: []ReferenceRateInput{{BaseAssetId: "USD", QuoteAssetId: "XEUR", Rate: 1.125}},
+		}
+
+		referenced, err := Convert(context.Background(), ConvertInput{Input: input, FromAssetId: "GBP", Amount: 1})
+		So(err, ShouldBeNil)
+		notReferenced, err := Convert(context.Background(), ConvertInput{Input: input, FromAssetId: "EUR", Amount: 1})
+		So(err, ShouldBeNil)
+
+		So(referenced.Referenced, ShouldBeTrue)
+		So(referenced.ConvertedAmount, ShouldAlmostEqual, 1.299375, 0.0001)
+		So(notReferenced.Referenced, ShouldBeFalse)
+	})
+	Convey("converts into a basket", t, func() {
+		input := mockInput()
+		input.RebaseAssetId = "BASKET"
+		input.Basket = &BasketInput{Assets: []BasketAssetInput{{AssetId: "USD", Weight: 0.6}, {AssetId: "EUR", Weight: 0.4}}}
+
+		actual, err := Convert(context.Background(), ConvertInput{Input: input, FromAssetId: "GBP", Amount: 1})
+
+		So(err, ShouldBeNil)
+		So(actual.ToAssetId, ShouldEqual, "BASKET")
+		So(actual.ConvertedAmount, ShouldAlmostEqual, 1.299375/1.05, 0.0001)
+
+		actual, err = Convert(context.Background(), ConvertInput{Input: input, FromAssetId: "USD", Amount: 1.05})
+
+		So(err, ShouldBeNil)
+		So(actual.ConvertedAmount, ShouldAlmostEqual, 1, 0.0001)
+	})
+	Convey("converts at the prices fitted by least squares", t, func() {
+		input := mockInput()
+		input.Engine = "leastSquares"
+
+		actual, err := Convert(context.Background(), ConvertInput{Input: input, FromAssetId: "GBP", ToAssetId: "EUR", Amount: 1})
+
+		So(err, ShouldBeNil)
+		So(actual.ConvertedAmount, ShouldAlmostEqual, 1.155, 0.0001)
+	})
+	Convey("assets without a price", t, func() {
+		_, err := Convert(context.Background(), ConvertInput{Input: mockInput(), FromAssetId: "JPY", Amount: 1})
+
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+/**
+Request to draw the market of a request as a graph, see market.Graph.
+*/
+type GraphInput struct {
+	Input
+	// "assets" for a graph of assets with an edge for every pair, the default, or "pairs" for a graph of pairs with an
+	// edge for every rebase neighbor
+	Nodes string `json:"nodes,omitempty"`
+	// edge labels, any of "mid", "spread" and "volume"
+	Labels []string `json:"labels,omitempty"`
+	// symbol of a pair whose rebase paths are highlighted
+	Highlight string `json:"highlight,omitempty"`
+}
+
+/**
+Draws the market that a rebase of a request goes through: filtered, including its reference rates and pegs. Nodes are
+colored by connected component. A highlighted pair's rebase paths are those found within the request's budget, from the
+basket's first asset if the request has a basket.
+With an asset registry, the graph is that of the canonical market the rebase goes through, unlike the output of a
+rebase: assets that are one are a single node named by their canonical id, pairs merged into one are a single edge, or
+a single node named by its canonical symbol in a graph of pairs, and edges' pair ids are those of the canonical pairs.
+The highlighted symbol may use any of the original asset ids.
+*/
+func Graph(ctx context.Context, i GraphInput) (m.Graph, error) {
+	ctx, cancel := withResponseMargin(ctx)
+	defer cancel()
+
+	if i.Nodes != "" && i.Nodes != "assets" && i.Nodes != "pairs" {
+		return m.Graph{}, fmt.Errorf(`unknown nodes "%s"`, i.Nodes)
+	}
+	for _, label := range i.Labels {
+		if label != m.GraphMid && label != m.GraphSpread && label != m.GraphVolume {
+			return m.Graph{}, fmt.Errorf(`unknown label "%s"`, label)
+		}
+	}
+	prepared, err := prepare(i.Input)
+	if err != nil {
+		return m.Graph{}, err
+	}
+	rebaseAssetId, err := i.pathRebaseAssetId()
+	if err != nil {
+		return m.Graph{}, err
+	}
+	rebaseAssetId = prepared.assetId(rebaseAssetId)
+	graph, _, rebaseNeighbors := prepared.engine.Graph(rebaseAssetId, &prepared.market)
+
+	options := m.GraphOptions{Labels: i.Labels, Highlight: map[string]bool{}}
+	if i.Highlight != "" {
+		separator := strings.Index(i.Highlight, "/")
+		if separator < 0 {
+			return m.Graph{}, fmt.Errorf(`invalid symbol "%s"`, i.Highlight)
+		}
+		highlighted := m.Pair{
+			BaseAssetId:  prepared.assetId(i.Highlight[:separator]),
+			QuoteAssetId: prepared.assetId(i.Highlight[separator+1:]),
+		}
+		if _, ok := graph.PairsById[highlighted.Id()]; !ok {
+			return m.Graph{}, fmt.Errorf(`no pair "%s" in the market`, i.Highlight)
+		}
+		paths, err := prepared.engine.Paths(ctx, rebaseAssetId, highlighted.Id(), &prepared.market)
+		if err != nil && !partial(err) {
+			return m.Graph{}, err
+		}
+		for _, path := range paths {
+			for _, pairId := range path {
+				options.Highlight[pairId] = true
+			}
+		}
+	}
+
+	if i.Nodes == "pairs" {
+		return graph.NeighborGraph(rebaseNeighbors, options), nil
+	}
+	return graph.Graph(options), nil
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	Convey("draws the assets of the market", t, func() {
+		actual, err := Graph(context.Background(), GraphInput{Input: mockInput(), Labels: []string{"mid"}})
+
+		So(err, ShouldBeNil)
+		So(actual.Nodes, ShouldHaveLength, 3)
+		So(actual.Edges, ShouldHaveLength, 2)
+		So(actual.Edges[0].Source, ShouldEqual, "EUR")
+		So(actual.Edges[0].Label, ShouldEqual, "mid 1.155")
+	})
+	Convey("highlights the rebase paths of a pair", t, func() {
+		actual, err := Graph(context.Background(), GraphInput{Input: mockInput(), Highlight: "EUR/GBP"})
+
+		So(err, ShouldBeNil)
+		for _, edge := range actual.Edges {
+			So(edge.Highlighted, ShouldBeTrue)
+		}
+	})
+	Convey("draws the pairs of the market and their neighbors", t, func() {
+		actual, err := Graph(context.Background(), GraphInput{Input: mockInput(), Nodes: "pairs"})
+
+		So(err, ShouldBeNil)
+		So(actual.Nodes, ShouldHaveLength, 2)
+		So(actual.Edges, ShouldResemble, []m.GraphEdge{{
+			Source: "USD/EUR",
+			Target: "EUR/GBP",
+			PairId: (&m.Pair{BaseAssetId: "EUR", QuoteAssetId: "GBP"}).Id(),
+		}})
+	})
+	Convey("fails with an unknown label, kind of nodes or highlighted pair", t, func() {
+		_, labelErr := Graph(context.Background(), GraphInput{Input: mockInput(), Labels: []string{"color"}})
+		_, nodesErr := Graph(context.Background(), GraphInput{Input: mockInput(), Nodes: "exchanges"})
+		_, highlightErr := Graph(context.Background(), GraphInput{Input: mockInput(), Highlight: "GBP/JPY"})
+
+		So(labelErr, ShouldNotBeNil)
+		So(nodesErr, ShouldNotBeNil)
+		So(highlightErr, ShouldNotBeNil)
+	})
+}
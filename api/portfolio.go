@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jochenboesmans/go-rebase/rebasing"
+)
+
+/**
+Request to value holdings of assets at the prices of the request's rebased market.
+*/
+type PortfolioInput struct {
+	Input
+	// quantity held of every asset, negative for short positions
+	Holdings map[string]float64 `json:"holdings"`
+}
+
+/**
+Value of holdings in the rebase asset. Values are at the rebased mids, conservative values at the rebased bids and
+optimistic values at the rebased asks, the other way around for short positions. Totals leave out unpriced positions.
+*/
+type PortfolioOutput struct {
+	RebaseAssetId     string     `json:"rebaseAssetId"`
+	Positions         []Position `json:"positions"`
+	Total             float64    `json:"total"`
+	ConservativeTotal float64    `json:"conservativeTotal"`
+	OptimisticTotal   float64    `json:"optimisticTotal"`
+	PathLength        uint8      `json:"pathLength"`
+	// assets of the positions that can't be priced, sorted
+	Unpriced []string `json:"unpriced,omitempty"`
+	// set when a budget ran out and the prices may be based on a partially rebased market
+	Partial       bool   `json:"partial,omitempty"`
+	PartialReason string `json:"partialReason,omitempty"`
+}
+
+type Position struct {
+	AssetId           string  `json:"assetId"`
+	Quantity          float64 `json:"quantity"`
+	Value             float64 `json:"value"`
+	ConservativeValue float64 `json:"conservativeValue"`
+	OptimisticValue   float64 `json:"optimisticValue"`
+	// set when the asset can't be priced, its values are then 0
+	Unpriced bool `json:"unpriced,omitempty"`
+	// set when the price relies on a reference rate
+	Referenced bool `json:"referenced,omitempty"`
+	// set when the price relies on a peg
+	Pegged bool `json:"pegged,omitempty"`
+}
+
+/**
+Values holdings by rebasing the request's market and pricing every asset in the rebase asset, see Convert.
+Positions are sorted by value, largest first. Assets that can't be priced are flagged rather than failing the request,
+and running out of budget or time flags the valuation as partial.
+*/
+func Portfolio(ctx context.Context, i PortfolioInput) (PortfolioOutput, error) {
+	rebased, err := rebase(ctx, i.Input)
+	if err != nil {
+		return PortfolioOutput{}, err
+	}
+	prices := rebased.assetPrices(i.RebaseAssetId)
+	bidPrices := rebased.marketPrices(i.RebaseAssetId, rebasing.AssetBidPrices)
+	askPrices := rebased.marketPrices(i.RebaseAssetId, rebasing.AssetAskPrices)
+
+	output := PortfolioOutput{
+		RebaseAssetId: i.RebaseAssetId,
+		Positions:     []Position{},
+		PathLength:    rebased.PathDepth,
+		Partial:       rebased.partialReason != "",
+		PartialReason: rebased.partialReason,
+	}
+	for assetId, quantity := range i.Holdings {
+		position := Position{AssetId: assetId, Quantity: quantity}
+		price, ok := prices[rebased.assetId(assetId)]
+		if !ok {
+			position.Unpriced = true
+			output.Unpriced = append(output.Unpriced, assetId)
+			output.Positions = append(output.Positions, position)
+			continue
+		}
+		// without rebased bids or asks, e.g. for the assets of a basket, the mid is all there is
+		lowPrice, highPrice := price, price
+		if bidPrice, ok := bidPrices[rebased.assetId(assetId)]; ok {
+			lowPrice = bidPrice
+		}
+		if askPrice, ok := askPrices[rebased.assetId(assetId)]; ok {
+			highPrice = askPrice
+		}
+		// fitted mids of least squares needn't lie between the rebased bids and asks
+		if lowPrice > price {
+			lowPrice = price
+		}
+		if highPrice < price {
+			highPrice = price
+		}
+		if quantity < 0 {
+			lowPrice, highPrice = highPrice, lowPrice
+		}
+		position.Value = quantity * float64(price)
+		position.ConservativeValue = quantity * float64(lowPrice)
+		position.OptimisticValue = quantity * float64(highPrice)
+		position.Referenced = rebased.referenced(assetId)
+		position.Pegged = rebased.pegged(assetId)
+		output.Total += position.Value
+		output.ConservativeTotal += position.ConservativeValue
+		output.OptimisticTotal += position.OptimisticValue
+		output.Positions = append(output.Positions, position)
+	}
+	sort.Strings(output.Unpriced)
+	sort.Slice(output.Positions, func(i, j int) bool {
+		if output.Positions[i].Value != output.Positions[j].Value {
+			return output.Positions[i].Value > output.Positions[j].Value
+		}
+		return output.Positions[i].AssetId < output.Positions[j].AssetId
+	})
+	return output, nil
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestPortfolio(t *testing.T) {
+	Convey("values positions at the rebased mids, bids and asks", t, func() {
+		actual, err := Portfolio(context.Background(), PortfolioInput{
+			Input:    mockInput(),
+			Holdings: map[string]float64{"EUR": 2, "USD": 1},
+		})
+
+		So(err, ShouldBeNil)
+		So(actual.RebaseAssetId, ShouldEqual, "USD")
+		So(actual.PathLength, ShouldEqual, 3)
+		So(actual.Unpriced, ShouldBeEmpty)
+		So(actual.Positions, ShouldHaveLength, 2)
+		So(actual.Positions[0].AssetId, ShouldEqual, "EUR")
+		So(actual.Positions[0].Value, ShouldAlmostEqual, 2.25, 0.0001)
+		So(actual.Positions[0].ConservativeValue, ShouldAlmostEqual, 2.24, 0.0001)
+		So(actual.Positions[0].OptimisticValue, ShouldAlmostEqual, 2.26, 0.0001)
+		So(actual.Positions[1].AssetId, ShouldEqual, "USD")
+		So(actual.Positions[1].Value, ShouldAlmostEqual, 1, 0.0001)
+		So(actual.Total, ShouldAlmostEqual, 3.25, 0.0001)
+		So(actual.ConservativeTotal, ShouldAlmostEqual, 3.24, 0.0001)
+		So(actual.OptimisticTotal, ShouldAlmostEqual, 3.26, 0.0001)
+	})
+	Convey("values short positions conservatively at the asks", t, func() {
+		actual, err := Portfolio(context.Background(), PortfolioInput{
+			Input:    mockInput(),
+			Holdings: map[string]float64{"EUR": -1},
+		})
+
+		So(err, ShouldBeNil)
+		So(actual.Positions[0].Value, ShouldAlmostEqual, -1.125, 0.0001)
+		So(actual.Positions[0].ConservativeValue, ShouldAlmostEqual, -1.13, 0.0001)
+		So(actual.Positions[0].OptimisticValue, ShouldAlmostEqual, -1.12, 0.0001)
+	})
+	Convey("flags positions that can't be priced and leaves them out of the totals", t, func() {
+		actual, err := Portfolio(context.Background(), PortfolioInput{
+			Input:    mockInput(),
+			Holdings: map[string]float64{"EUR": 1, "BTC": 3},
+		})
+
+		So(err, ShouldBeNil)
+		So(actual.Unpriced, ShouldResemble, []string{"BTC"})
+		So(actual.Positions, ShouldHaveLength, 2)
+		So(actual.Positions[1].AssetId, ShouldEqual, "BTC")
+		So(actual.Positions[1].Unpriced, ShouldBeTrue)
+		So(actual.Positions[1].Value, ShouldEqual, 0)
+		So(actual.Total, ShouldAlmostEqual, 1.125, 0.0001)
+	})
+	Convey("values aliased assets", t, func() {
+		input := mockInput()
+		input.Assets = &AssetsInput{Equivalent: [][]string{{"EUR", "XEUR"}}}
+
+		actual, err := Portfolio(context.Background(), PortfolioInput{Input: input, Holdings: map[string]float64{"xeur": 1}})
+
+		So(err, ShouldBeNil)
+		So(actual.Positions[0].AssetId, ShouldEqual, "xeur")
+		So(actual.Positions[0].Value, ShouldAlmostEqual, 1.125, 0.0001)
+	})
+	Convey("doesn't value assets at pairs of equivalent assets", t, func() {
+		input := mockInput()
+		input.Market = []m.Pair{
+			{
+				BaseAssetId:     "USD",
+				QuoteAssetId:    "ETH",
+				ExchangeMarkets: []m.ExchangeMarket{{CurrentBid: 2000, CurrentAsk: 2000, BaseVolume: 100}},
+			},
+			{
+				BaseAssetId:     "ETH",
+				QuoteAssetId:    "WETH",
+				ExchangeMarkets: []m.ExchangeMarket{{CurrentBid: 0.999, CurrentAsk: 1.001, BaseVolume: 100}},
+			},
+		}
+		input.Assets = &AssetsInput{Equivalent: [][]string{{"ETH", "WETH"}}}
+
+		actual, err := Portfolio(context.Background(), PortfolioInput{Input: input, Holdings: map[string]float64{"ETH": 1}})
+
+		So(err, ShouldBeNil)
+		So(actual.Positions[0].Value, ShouldAlmostEqual, 2000, 0.01)
+		So(actual.Positions[0].ConservativeValue, ShouldAlmostEqual, 2000, 0.01)
+		So(actual.Positions[0].OptimisticValue, ShouldAlmostEqual, 2000, 0.01)
+	})
+	Convey("values holdings in a basket", t, func() {
+		input := mockInput()
+		input.RebaseAssetId = "BASKET"
+		input.Basket = &BasketInput{Assets: []BasketAssetInput{{AssetId: "USD"}, {AssetId: "EUR"}}}
+
+		actual, err := Portfolio(context.Background(), PortfolioInput{Input: input, Holdings: map[string]float64{"GBP": 1}})
+
+		So(err, ShouldBeNil)
+		So(actual.Unpriced, ShouldBeEmpty)
+		So(actual.Total, ShouldBeGreaterThan, 0)
+	})
+	Convey("fails with an unknown engine", t, func() {
+		input := mockInput()
+		input.Engine = "magic"
+
+		_, err := Portfolio(context.Background(), PortfolioInput{Input: input, Holdings: map[string]float64{"EUR": 1}})
+
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,541 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"time"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	"github.com/jochenboesmans/go-rebase/rebasing"
+)
+
+/**
+Rebase request, as received by the Lambda and the other entry points.
+*/
+type Input struct {
+	// with a basket, the id of the basket
+	RebaseAssetId string `json:"rebaseAssetId"`
+	// chosen automatically when left out
+	MaxPathLength     uint8                `json:"maxPathLength"`
+	AutoPathLength    *AutoPathLengthInput `json:"autoPathLength"`
+	PathWeighting     string               `json:"pathWeighting"`
+	MinCombinedVolume float32              `json:"minCombinedVolume"`
+	MinExchanges      int                  `json:"minExchanges"`
+	MaxRelativeSpread float32              `json:"maxRelativeSpread"`
+	Filter            m.Filter             `json:"filter"`
+	Budget            BudgetInput          `json:"budget"`
+	// asset ids are taken as they are when left out
+	Assets         *AssetsInput         `json:"assets,omitempty"`
+	ReferenceRates *ReferenceRatesInput `json:"referenceRates,omitempty"`
+	Pegs           []PegInput           `json:"pegs,omitempty"`
+	// rebases in a basket of assets named rebaseAssetId instead of a single asset
+	Basket *BasketInput `json:"basket,omitempty"`
+	// "paths", the default, or "leastSquares" to fit one consistent price per asset to all pairs at once
+	Engine string   `json:"engine,omitempty"`
+	Market []m.Pair `json:"market"`
+}
+
+type AutoPathLengthInput struct {
+	Tolerance     float32 `json:"tolerance"`
+	MaxPathLength uint8   `json:"maxPathLength"`
+	TimeBudgetMs  int     `json:"timeBudgetMs"`
+}
+
+// zero values fall back to DefaultBudget
+type BudgetInput struct {
+	MaxPathsPerPair int `json:"maxPathsPerPair"`
+	MaxPaths        int `json:"maxPaths"`
+	TimeBudgetMs    int `json:"timeBudgetMs"`
+}
+
+/**
+Asset ids that name the same asset. When given, asset ids are also compared regardless of case and surrounding
+whitespace, and the output keeps the original symbols.
+*/
+type AssetsInput struct {
+	// aliases by canonical asset id, e.g. {"BTC": ["XBT"]}
+	Aliases map[string][]string `json:"aliases,omitempty"`
+	// groups of asset ids treated as one, named after the first
+	Equivalent [][]string `json:"equivalent,omitempty"`
+}
+
+/**
+Table of rates from outside the market, like a central bank's daily fix, see rebasing.ReferenceRates.
+*/
+type ReferenceRatesInput struct {
+	Rates []ReferenceRateInput `json:"rates"`
+	// only use rates of assets that no market path connects to the rebase asset
+	Fallback bool    `json:"fallback,omitempty"`
+	Volume   float32 `json:"volume,omitempty"`
+}
+
+// rate is the price of one unit of quoteAssetId in baseAssetId, like the mid of a pair
+type ReferenceRateInput struct {
+	BaseAssetId  string  `json:"baseAssetId"`
+	QuoteAssetId string  `json:"quoteAssetId"`
+	Rate         float32 `json:"rate"`
+}
+
+/**
+Basket of assets to rebase in, see rebasing.Basket. Without weights, all assets weigh the same.
+*/
+type BasketInput struct {
+	Assets []BasketAssetInput `json:"assets"`
+	// prices the basket as the weighted geometric mean of its assets, instead of holding amounts of them
+	Geometric bool `json:"geometric,omitempty"`
+}
+
+type BasketAssetInput struct {
+	AssetId string  `json:"assetId"`
+	Weight  float32 `json:"weight,omitempty"`
+}
+
+/**
+Assumption that an asset trades at a fixed ratio to another, see rebasing.Peg. Zero values use the defaults.
+*/
+type PegInput struct {
+	AssetId   string  `json:"assetId"`
+	PeggedTo  string  `json:"peggedTo"`
+	Ratio     float32 `json:"ratio,omitempty"`
+	Tolerance float32 `json:"tolerance,omitempty"`
+	Fallback  bool    `json:"fallback,omitempty"`
+	Volume    float32 `json:"volume,omitempty"`
+}
+
+// time left to respond after the rebase was cut short by the invocation's deadline
+const responseMargin = 500 * time.Millisecond
+
+// keeps a single request from exhausting the Lambda's memory or time
+var DefaultBudget = rebasing.Budget{
+	MaxPathsPerPair: 10000,
+	MaxPaths:        1000000,
+	MaxDuration:     10 * time.Second,
+}
+
+/**
+Rebased market in response to an Input.
+*/
+type Output struct {
+	RebaseAssetId string `json:"rebaseAssetId"`
+	PathLength    uint8  `json:"pathLength"`
+	// set when a budget ran out and the market is only partially rebased
+	Partial       bool   `json:"partial,omitempty"`
+	PartialReason string `json:"partialReason,omitempty"`
+	// symbols of the pairs whose rebased rates rely on a reference rate
+	Referenced []string `json:"referenced,omitempty"`
+	// symbols of the pairs whose rebased rates rely on a peg
+	Pegged []string `json:"pegged,omitempty"`
+	// with a basket, the price of one unit of each of its assets in the basket
+	BasketPrices map[string]float32 `json:"basketPrices,omitempty"`
+	// with least squares, the log of every fitted pair's mid minus the log of the mid the fitted prices imply, by symbol
+	Residuals map[string]float32 `json:"residuals,omitempty"`
+	Market    []m.Pair           `json:"market"`
+}
+
+func (input Input) extractMarket() m.Market {
+	market := m.Market{
+		PairsById: map[string]m.Pair{},
+	}
+	for _, pair := range input.Market {
+		market.PairsById[pair.Id()] = pair
+	}
+	return market
+}
+
+func (input Input) extractAutoDepth() rebasing.AutoDepth {
+	if input.AutoPathLength == nil {
+		return rebasing.AutoDepth{}
+	}
+	return rebasing.AutoDepth{
+		Tolerance:  input.AutoPathLength.Tolerance,
+		MaxDepth:   input.AutoPathLength.MaxPathLength,
+		TimeBudget: time.Duration(input.AutoPathLength.TimeBudgetMs) * time.Millisecond,
+	}
+}
+
+// nil without assets in the input
+func (input Input) extractAssetRegistry() *m.AssetRegistry {
+	if input.Assets == nil {
+		return nil
+	}
+	registry := m.NewAssetRegistry()
+	canonicalIds := []string{}
+	for canonicalId := range input.Assets.Aliases {
+		canonicalIds = append(canonicalIds, canonicalId)
+	}
+	sort.Strings(canonicalIds)
+	for _, canonicalId := range canonicalIds {
+		registry.Alias(canonicalId, input.Assets.Aliases[canonicalId]...)
+	}
+	for _, assetIds := range input.Assets.Equivalent {
+		registry.Equate(assetIds...)
+	}
+	return registry
+}
+
+// reference rates between canonical asset ids if there's an asset registry
+func (input Input) extractReferenceRates(assets *m.AssetRegistry) rebasing.ReferenceRates {
+	if input.ReferenceRates == nil {
+		return rebasing.ReferenceRates{}
+	}
+	referenceRates := rebasing.ReferenceRates{
+		Fallback: input.ReferenceRates.Fallback,
+		Volume:   input.ReferenceRates.Volume,
+	}
+	for _, rate := range input.ReferenceRates.Rates {
+		baseAssetId, quoteAssetId := rate.BaseAssetId, rate.QuoteAssetId
+		if assets != nil {
+			baseAssetId, quoteAssetId = assets.Canonical(baseAssetId), assets.Canonical(quoteAssetId)
+		}
+		referenceRates.Rates = append(referenceRates.Rates, rebasing.ReferenceRate{
+			BaseAssetId:  baseAssetId,
+			QuoteAssetId: quoteAssetId,
+			Rate:         rate.Rate,
+		})
+	}
+	return referenceRates
+}
+
+// basket of canonical asset ids if there's an asset registry, nil without a basket in the input
+func (input Input) extractBasket(basketId string, assets *m.AssetRegistry) *rebasing.Basket {
+	if input.Basket == nil {
+		return nil
+	}
+	basket := rebasing.Basket{Id: basketId, Geometric: input.Basket.Geometric}
+	for _, asset := range input.Basket.Assets {
+		assetId := asset.AssetId
+		if assets != nil {
+			assetId = assets.Canonical(assetId)
+		}
+		basket.Components = append(basket.Components, rebasing.BasketComponent{AssetId: assetId, Weight: asset.Weight})
+	}
+	return &basket
+}
+
+// pegs between canonical asset ids if there's an asset registry, failing for the first invalid one
+func (input Input) extractPegs(assets *m.AssetRegistry) ([]rebasing.Peg, error) {
+	var pegs []rebasing.Peg
+	for _, peg := range input.Pegs {
+		assetId, peggedTo := peg.AssetId, peg.PeggedTo
+		if assets != nil {
+			assetId, peggedTo = assets.Canonical(assetId), assets.Canonical(peggedTo)
+		}
+		extracted := rebasing.Peg{
+			AssetId:   assetId,
+			PeggedTo:  peggedTo,
+			Ratio:     peg.Ratio,
+			Tolerance: peg.Tolerance,
+			Fallback:  peg.Fallback,
+			Volume:    peg.Volume,
+		}
+		if err := extracted.Validate(); err != nil {
+			return nil, err
+		}
+		pegs = append(pegs, extracted)
+	}
+	return pegs, nil
+}
+
+func (input Input) extractBudget() rebasing.Budget {
+	budget := DefaultBudget
+	if input.Budget.MaxPathsPerPair > 0 {
+		budget.MaxPathsPerPair = input.Budget.MaxPathsPerPair
+	}
+	if input.Budget.MaxPaths > 0 {
+		budget.MaxPaths = input.Budget.MaxPaths
+	}
+	if input.Budget.TimeBudgetMs > 0 {
+		budget.MaxDuration = time.Duration(input.Budget.TimeBudgetMs) * time.Millisecond
+	}
+	return budget
+}
+
+func toOutput(rebasedMarket m.Market, rebaseAssetId string, pathLength uint8) Output {
+	output := Output{
+		RebaseAssetId: rebaseAssetId,
+		PathLength:    pathLength,
+		Market:        []m.Pair{},
+	}
+	for _, pair := range rebasedMarket.PairsById {
+		output.Market = append(output.Market, pair)
+	}
+	return output
+}
+
+/**
+Rebases the market of a request.
+Running out of budget or time isn't an error: the partially rebased market is returned, flagged as partial.
+*/
+func Rebase(ctx context.Context, i Input) (Output, error) {
+	rebased, err := rebase(ctx, i)
+	if err != nil {
+		return Output{}, err
+	}
+	market := *rebased.Market
+	if rebased.assets != nil {
+		market = rebased.originalSymbols.Restore(rebased.Market)
+	}
+	output := toOutput(market, i.RebaseAssetId, rebased.PathDepth)
+	for _, pair := range output.Market {
+		if _, ok := rebased.ReferencedPairs[rebased.pairId(pair)]; ok {
+			output.Referenced = append(output.Referenced, pair.Symbol())
+		}
+		if _, ok := rebased.PeggedPairs[rebased.pairId(pair)]; ok {
+			output.Pegged = append(output.Pegged, pair.Symbol())
+		}
+	}
+	sort.Strings(output.Referenced)
+	sort.Strings(output.Pegged)
+	if rebased.Residuals != nil {
+		output.Residuals = map[string]float32{}
+		for _, pair := range output.Market {
+			if residual, ok := rebased.Residuals[rebased.pairId(pair)]; ok {
+				output.Residuals[pair.Symbol()] = residual
+			}
+		}
+	}
+	if i.Basket != nil {
+		output.BasketPrices = map[string]float32{}
+		for _, asset := range i.Basket.Assets {
+			output.BasketPrices[asset.AssetId] = rebased.BasketPrices[rebased.assetId(asset.AssetId)]
+		}
+	}
+	if rebased.partialReason != "" {
+		output.Partial = true
+		output.PartialReason = rebased.partialReason
+	}
+	return output, nil
+}
+
+// result of rebasing the market of a request, in canonical asset ids if the request has an asset registry
+type rebasedInput struct {
+	*rebasing.Result
+	// set when the result is partial
+	partialReason string
+	// nil without an asset registry
+	assets          *m.AssetRegistry
+	originalSymbols m.OriginalSymbols
+}
+
+// asset id as it's known in the rebased market
+func (r *rebasedInput) assetId(assetId string) string {
+	if r.assets == nil {
+		return assetId
+	}
+	return r.assets.Canonical(assetId)
+}
+
+// id of a pair of the request as it's known in the rebased market
+func (r *rebasedInput) pairId(pair m.Pair) string {
+	rebasedPair := m.Pair{
+		BaseAssetId:  r.assetId(pair.BaseAssetId),
+		QuoteAssetId: r.assetId(pair.QuoteAssetId),
+	}
+	return rebasedPair.Id()
+}
+
+// price of one unit of every asset in the rebase asset or basket, the fitted prices with least squares
+func (r *rebasedInput) assetPrices(rebaseAssetId string) map[string]float32 {
+	if r.Prices != nil {
+		return r.Prices
+	}
+	return r.marketPrices(rebaseAssetId, rebasing.AssetPrices)
+}
+
+// prices read from the rebased market, along with the prices of a basket's assets that it doesn't price
+func (r *rebasedInput) marketPrices(rebaseAssetId string, assetPrices func(*m.Market, string) map[string]float32) map[string]float32 {
+	prices := assetPrices(r.Market, r.assetId(rebaseAssetId))
+	for assetId, price := range r.BasketPrices {
+		if _, ok := prices[assetId]; !ok {
+			prices[assetId] = price
+		}
+	}
+	return prices
+}
+
+// whether the price of the asset relies on a reference rate
+func (r *rebasedInput) referenced(assetId string) bool {
+	return r.quotedIn(r.ReferencedPairs, assetId)
+}
+
+// whether the price of the asset relies on a peg
+func (r *rebasedInput) pegged(assetId string) bool {
+	return r.quotedIn(r.PeggedPairs, assetId)
+}
+
+// whether the asset is the quote asset of any of the rebased pairs
+func (r *rebasedInput) quotedIn(pairIds map[string][]string, assetId string) bool {
+	for pairId := range pairIds {
+		if r.Market.PairsById[pairId].QuoteAssetId == r.assetId(assetId) {
+			return true
+		}
+	}
+	return false
+}
+
+func rebase(ctx context.Context, i Input) (*rebasedInput, error) {
+	prepared, err := prepare(i)
+	if err != nil {
+		return nil, err
+	}
+	return prepared.rebase(ctx, i)
+}
+
+// rebases the prepared market of the request
+func (p *preparedInput) rebase(ctx context.Context, i Input) (*rebasedInput, error) {
+	ctx, cancel := withResponseMargin(ctx)
+	defer cancel()
+
+	var err error
+	rebased := rebasedInput{assets: p.assets, originalSymbols: p.originalSymbols}
+	if basket := i.extractBasket(p.rebaseAssetId, p.assets); basket != nil {
+		rebased.Result, err = p.engine.RebaseInBasket(ctx, *basket, &p.market)
+	} else {
+		rebased.Result, err = p.engine.Rebase(ctx, p.rebaseAssetId, &p.market)
+	}
+	if partial(err) {
+		rebased.partialReason = err.Error()
+		return &rebased, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rebased, nil
+}
+
+// leaves time to respond before the deadline of the context, if it has one
+func withResponseMargin(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(ctx, deadline.Add(-responseMargin))
+	}
+	return ctx, func() {}
+}
+
+// whether the error only cut the work short, running out of budget or time still leaves a useful, partial result
+func partial(err error) bool {
+	return errors.Is(err, rebasing.ErrBudgetExceeded) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// request ready to be rebased, in canonical asset ids if the request has an asset registry
+type preparedInput struct {
+	engine        *rebasing.Engine
+	market        m.Market
+	rebaseAssetId string
+	// nil without an asset registry
+	assets          *m.AssetRegistry
+	originalSymbols m.OriginalSymbols
+	// sorted ids of the assets of the request's market by canonical id, nil without an asset registry
+	assetIdsByCanonicalId map[string][]string
+}
+
+// asset id as it's known in the prepared market
+func (p *preparedInput) assetId(assetId string) string {
+	if p.assets == nil {
+		return assetId
+	}
+	return p.assets.Canonical(assetId)
+}
+
+// ids that the request's market knows an asset of the prepared market by, or its own id if the market doesn't list it
+func (p *preparedInput) originalAssetIds(assetId string) []string {
+	if assetIds, ok := p.assetIdsByCanonicalId[assetId]; ok {
+		return assetIds
+	}
+	return []string{assetId}
+}
+
+// symbols of the request's pairs that were merged into a pair of the prepared market, or its own symbol if there are none
+func (p *preparedInput) pairSymbols(pair m.Pair) []string {
+	if symbols := p.originalSymbols.Symbols(pair.Id()); symbols != nil {
+		return symbols
+	}
+	return []string{pair.Symbol()}
+}
+
+// sorted ids of the assets of the market by canonical id
+func originalAssetIds(market *m.Market, assets *m.AssetRegistry) map[string][]string {
+	assetIds := map[string]map[string]bool{}
+	for _, pair := range market.PairsById {
+		for _, assetId := range []string{pair.BaseAssetId, pair.QuoteAssetId} {
+			canonicalId := assets.Canonical(assetId)
+			if assetIds[canonicalId] == nil {
+				assetIds[canonicalId] = map[string]bool{}
+			}
+			assetIds[canonicalId][assetId] = true
+		}
+	}
+	result := map[string][]string{}
+	for canonicalId, ids := range assetIds {
+		for assetId := range ids {
+			result[canonicalId] = append(result[canonicalId], assetId)
+		}
+		sort.Strings(result[canonicalId])
+	}
+	return result
+}
+
+// asset to follow rebase paths from, the first asset of the basket if the request has one
+func (input Input) pathRebaseAssetId() (string, error) {
+	if input.Basket == nil {
+		return input.RebaseAssetId, nil
+	}
+	if len(input.Basket.Assets) == 0 {
+		return "", fmt.Errorf(`basket "%s" has no assets`, input.RebaseAssetId)
+	}
+	return input.Basket.Assets[0].AssetId, nil
+}
+
+func prepare(i Input) (*preparedInput, error) {
+	pathWeighter, err := rebasing.PathWeighterByName(i.PathWeighting)
+	if err != nil {
+		return nil, err
+	}
+
+	prepared := preparedInput{
+		market:        i.extractMarket(),
+		rebaseAssetId: i.RebaseAssetId,
+		assets:        i.extractAssetRegistry(),
+	}
+	filter := i.Filter
+	pegs, err := i.extractPegs(prepared.assets)
+	if err != nil {
+		return nil, err
+	}
+	if prepared.assets != nil {
+		prepared.assetIdsByCanonicalId = originalAssetIds(&prepared.market, prepared.assets)
+		prepared.market, prepared.originalSymbols = prepared.assets.Canonicalize(&prepared.market)
+		prepared.rebaseAssetId = prepared.assets.Canonical(prepared.rebaseAssetId)
+		filter = prepared.assets.CanonicalFilter(filter)
+	}
+
+	engineOptions := []rebasing.Option{
+		rebasing.WithPathWeighter(pathWeighter),
+		rebasing.WithLiquidityLimits(rebasing.LiquidityLimits{
+			MinCombinedVolume: i.MinCombinedVolume,
+			MinExchanges:      i.MinExchanges,
+			MaxRelativeSpread: i.MaxRelativeSpread,
+		}),
+		rebasing.WithFilter(filter),
+		rebasing.WithBudget(i.extractBudget()),
+		rebasing.WithReferenceRates(i.extractReferenceRates(prepared.assets)),
+		rebasing.WithPegs(pegs...),
+		rebasing.WithConcurrency(runtime.NumCPU()),
+	}
+	switch i.Engine {
+	case "", "paths":
+	case "leastSquares":
+		engineOptions = append(engineOptions, rebasing.WithLeastSquares())
+	default:
+		return nil, fmt.Errorf(`unknown engine "%s"`, i.Engine)
+	}
+	if i.MaxPathLength > 0 {
+		engineOptions = append(engineOptions, rebasing.WithMaxPathDepth(i.MaxPathLength))
+	} else {
+		engineOptions = append(engineOptions, rebasing.WithAutoDepth(i.extractAutoDepth()))
+	}
+	prepared.engine = rebasing.NewEngine(engineOptions...)
+	return &prepared, nil
+}
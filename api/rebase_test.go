@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"errors"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	"github.com/jochenboesmans/go-rebase/rebasing"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func mockInput() Input {
+	return Input{
+		RebaseAssetId: "USD",
+		MaxPathLength: 3,
+		Market: []m.Pair{
+			{
+				BaseAssetId:  "USD",
+				QuoteAssetId: "EUR",
+				ExchangeMarkets: []m.ExchangeMarket{
+					{
+						CurrentBid: 1.12,
+						CurrentAsk: 1.13,
+						BaseVolume: 100,
+					},
+				},
+			},
+			{
+				BaseAssetId:  "EUR",
+				QuoteAssetId: "GBP",
+				ExchangeMarkets: []m.ExchangeMarket{
+					{
+						CurrentBid: 1.15,
+						CurrentAsk: 1.16,
+						BaseVolume: 100,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRebase(t *testing.T) {
+	Convey("rebases the market of the request", t, func() {
+		input := mockInput()
+		market := input.extractMarket()
+
+		actual, err := Rebase(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.RebaseAssetId, ShouldEqual, "USD")
+		So(actual.PathLength, ShouldEqual, 3)
+		So(actual.Partial, ShouldBeFalse)
+		So(actual.Market, ShouldHaveLength, 2)
+		for _, pair := range actual.Market {
+			So(pair, ShouldResemble, rebasing.RebaseMarket("USD", 3, &market).PairsById[pair.Id()])
+		}
+	})
+	Convey("running out of budget flags the output as partial", t, func() {
+		input := mockInput()
+		input.Budget.MaxPaths = 1
+
+		actual, err := Rebase(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.Partial, ShouldBeTrue)
+		So(actual.PartialReason, ShouldNotBeEmpty)
+	})
+	Convey("connects aliased assets and keeps their original symbols", t, func() {
+		input := mockInput()
+		input.RebaseAssetId = "usd"
+		input.Market[1].BaseAssetId = "eur "
+		input.Market = append(input.Market, m.Pair{
+			BaseAssetId:     "XEUR",
+			QuoteAssetId:    "GBP",
+			ExchangeMarkets: []m.ExchangeMarket{{ExchangeId: "b", CurrentBid: 1.15, CurrentAsk: 1.16, BaseVolume: 100}},
+		})
+		input.Assets = &AssetsInput{Aliases: map[string][]string{"EUR": {"XEUR"}}}
+
+		actual, err := Rebase(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.RebaseAssetId, ShouldEqual, "usd")
+		So(actual.Market, ShouldHaveLength, 3)
+		for _, pair := range actual.Market {
+			if pair.QuoteAssetId == "GBP" {
+				So([]string{"eur ", "XEUR"}, ShouldContain, pair.BaseAssetId)
+				So(pair.ExchangeMarkets, ShouldHaveLength, 1)
+				So(pair.ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 1.29375, 0.0001)
+			}
+		}
+	})
+	Convey("leaves out pairs of equivalent assets instead of rebasing through them", t, func() {
+		input := mockInput()
+		input.Market = []m.Pair{
+			{
+				BaseAssetId:     "USD",
+				QuoteAssetId:    "ETH",
+				ExchangeMarkets: []m.ExchangeMarket{{CurrentBid: 2000, CurrentAsk: 2000, BaseVolume: 100}},
+			},
+			{
+				BaseAssetId:     "ETH",
+				QuoteAssetId:    "WETH",
+				ExchangeMarkets: []m.ExchangeMarket{{CurrentBid: 0.999, CurrentAsk: 1.001, BaseVolume: 100}},
+			},
+		}
+		input.Assets = &AssetsInput{Equivalent: [][]string{{"ETH", "WETH"}}}
+
+		actual, err := Rebase(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.Market, ShouldHaveLength, 1)
+		So(actual.Market[0].Symbol(), ShouldEqual, "USD/ETH")
+		So(actual.Market[0].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 2000, 0.01)
+		So(actual.Market[0].ExchangeMarkets[0].CurrentAsk, ShouldAlmostEqual, 2000, 0.01)
+	})
+	Convey("flags pairs whose rates rely on a peg", t, func() {
+		input := mockInput()
+		input.Market[1].BaseAssetId = "EURT"
+		input.Pegs = []PegInput{{AssetId: "EURT", PeggedTo: "EUR"}}
+
+		actual, err := Rebase(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.Pegged, ShouldResemble, []string{"EURT/GBP"})
+		for _, pair := range actual.Market {
+			if pair.QuoteAssetId == "GBP" {
+				So(pair.ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 1.29375, 0.0001)
+			}
+		}
+	})
+	Convey("fails with a peg whose tolerance isn't in [0, 1) or whose ratio is negative", t, func() {
+		for _, peg := range []PegInput{
+			{AssetId: "EURT", PeggedTo: "EUR", Tolerance: 1},
+			{AssetId: "EURT", PeggedTo: "EUR", Tolerance: 1.5},
+			{AssetId: "EURT", PeggedTo: "EUR", Tolerance: -0.1},
+			{AssetId: "EURT", PeggedTo: "EUR", Ratio: -1},
+		} {
+			input := mockInput()
+			input.Market[1].BaseAssetId = "EURT"
+			input.Pegs = []PegInput{peg}
+
+			_, err := Rebase(context.Background(), input)
+
+			So(err, ShouldNotBeNil)
+		}
+	})
+	Convey("flags pairs whose rates rely on a reference rate", t, func() {
+		input := mockInput()
+		input.Market[1].BaseAssetId = "XEUR"
+		input.ReferenceRates = &ReferenceRatesInput{
+			Rates: []ReferenceRateInput{{BaseAssetId: "USD", QuoteAssetId: "XEUR", Rate: 1.125}},
+		}
+
+		actual, err := Rebase(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.Referenced, ShouldResemble, []string{"XEUR/GBP"})
+		So(actual.Pegged, ShouldBeEmpty)
+		for _, pair := range actual.Market {
+			if pair.QuoteAssetId == "GBP" {
+				So(pair.ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 1.29375, 0.0001)
+			}
+		}
+	})
+	Convey("reference rates use canonical asset ids", t, func() {
+		input := mockInput()
+		input.Market[1].BaseAssetId = "XEUR"
+		input.Assets = &AssetsInput{Aliases: map[string][]string{"EUR": {"XEUR"}}}
+		input.ReferenceRates = &ReferenceRatesInput{
+			Rates: []ReferenceRateInput{{BaseAssetId: "usd", QuoteAssetId: "xeur", Rate: 2}},
+		}
+
+		actual, err := Rebase(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.Referenced, ShouldBeEmpty)
+	})
+	Convey("rebases in a basket named by rebaseAssetId", t, func() {
+		input := mockInput()
+		input.RebaseAssetId = "BASKET"
+		input.Basket = &BasketInput{Assets: []BasketAssetInput{{AssetId: "USD", Weight: 0.6}, {AssetId: "EUR", Weight: 0.4}}}
+
+		actual, err := Rebase(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.RebaseAssetId, ShouldEqual, "BASKET")
+		So(actual.BasketPrices["USD"], ShouldAlmostEqual, 1/1.05, 0.0001)
+		So(actual.BasketPrices["EUR"], ShouldAlmostEqual, 1.125/1.05, 0.0001)
+		for _, pair := range actual.Market {
+			if pair.QuoteAssetId == "GBP" {
+				So(pair.ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 1.29375/1.05, 0.0001)
+			}
+		}
+	})
+	Convey("baskets of assets without a price", t, func() {
+		input := mockInput()
+		input.RebaseAssetId = "BASKET"
+		input.Basket = &BasketInput{Assets: []BasketAssetInput{{AssetId: "USD"}, {AssetId: "JPY"}}}
+
+		_, err := Rebase(context.Background(), input)
+
+		So(err, ShouldNotBeNil)
+	})
+	Convey("fits prices by least squares and reports residuals", t, func() {
+		input := mockInput()
+		input.Engine = "leastSquares"
+
+		actual, err := Rebase(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.Residuals, ShouldHaveLength, 2)
+		So(actual.Residuals["EUR/GBP"], ShouldAlmostEqual, 0, 0.0001)
+		for _, pair := range actual.Market {
+			if pair.QuoteAssetId == "GBP" {
+				So(pair.ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 1.29375, 0.0001)
+			}
+		}
+	})
+	Convey("unknown engine", t, func() {
+		input := mockInput()
+		input.Engine = "foo"
+
+		_, err := Rebase(context.Background(), input)
+
+		So(err, ShouldNotBeNil)
+	})
+	Convey("unknown path weighting", t, func() {
+		input := mockInput()
+		input.PathWeighting = "foo"
+
+		_, err := Rebase(context.Background(), input)
+
+		So(err, ShouldNotBeNil)
+		So(errors.Is(err, rebasing.ErrBudgetExceeded), ShouldBeFalse)
+	})
+}
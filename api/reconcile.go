@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+type ReconcileOutput struct {
+	RebaseAssetId string `json:"rebaseAssetId"`
+	// largest absolute deviation first
+	Pairs []ReconciledPair `json:"pairs"`
+	// set when a budget ran out and only some of the pairs were compared
+	Partial       bool   `json:"partial,omitempty"`
+	PartialReason string `json:"partialReason,omitempty"`
+}
+
+/**
+Pair whose direct mid is compared with the mid implied by paths through the rebase asset that avoid it, see
+rebasing.Reconciliation. With an asset registry, the symbol is the original one, and every pair merged into the same
+canonical pair is listed with the same comparison.
+*/
+type ReconciledPair struct {
+	Symbol       string  `json:"symbol"`
+	DirectMid    float32 `json:"directMid"`
+	ImpliedMid   float32 `json:"impliedMid"`
+	DeviationBps float32 `json:"deviationBps"`
+}
+
+/**
+Compares the mid of every pair of a request's market with the mid that the other pairs imply, to spot stale or
+manipulated quotes. A request with a basket is reconciled in the basket's first asset.
+Running out of budget or time isn't an error: the pairs compared so far are returned, flagged as partial.
+*/
+func Reconcile(ctx context.Context, i Input) (ReconcileOutput, error) {
+	ctx, cancel := withResponseMargin(ctx)
+	defer cancel()
+
+	prepared, err := prepare(i)
+	if err != nil {
+		return ReconcileOutput{}, err
+	}
+	rebaseAssetId, err := i.pathRebaseAssetId()
+	if err != nil {
+		return ReconcileOutput{}, err
+	}
+
+	reconciliations, err := prepared.engine.Reconcile(ctx, prepared.assetId(rebaseAssetId), &prepared.market)
+	output := ReconcileOutput{RebaseAssetId: rebaseAssetId, Pairs: []ReconciledPair{}}
+	if partial(err) {
+		output.Partial = true
+		output.PartialReason = err.Error()
+	} else if err != nil {
+		return ReconcileOutput{}, err
+	}
+	for _, reconciliation := range reconciliations {
+		pair := m.Pair{BaseAssetId: reconciliation.BaseAssetId, QuoteAssetId: reconciliation.QuoteAssetId}
+		for _, symbol := range prepared.pairSymbols(pair) {
+			output.Pairs = append(output.Pairs, ReconciledPair{
+				Symbol:       symbol,
+				DirectMid:    reconciliation.DirectMid,
+				ImpliedMid:   reconciliation.ImpliedMid,
+				DeviationBps: reconciliation.DeviationBps,
+			})
+		}
+	}
+	return output, nil
+}
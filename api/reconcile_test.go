@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func mockReconcileInput() Input {
+	input := mockInput()
+	input.Market = append(input.Market, m.Pair{
+		BaseAssetId:  "USD",
+		QuoteAssetId: "GBP",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 1.34,
+				CurrentAsk: 1.36,
+				BaseVolume: 100,
+			},
+		},
+	})
+	return input
+}
+
+func TestReconcile(t *testing.T) {
+	Convey("compares direct mids with implied ones, largest deviation first", t, func() {
+		actual, err := Reconcile(context.Background(), mockReconcileInput())
+
+		So(err, ShouldBeNil)
+		So(actual.RebaseAssetId, ShouldEqual, "USD")
+		So(actual.Partial, ShouldBeFalse)
+		So(actual.Pairs, ShouldHaveLength, 2)
+		So(actual.Pairs[0].Symbol, ShouldEqual, "USD/GBP")
+		So(actual.Pairs[0].DirectMid, ShouldAlmostEqual, 1.35, 0.0001)
+		So(actual.Pairs[0].ImpliedMid, ShouldAlmostEqual, 1.125*1.155, 0.0001)
+		So(actual.Pairs[1].Symbol, ShouldEqual, "EUR/GBP")
+	})
+	Convey("reconciles in the first asset of a basket", t, func() {
+		input := mockReconcileInput()
+		input.RebaseAssetId = "BASKET"
+		input.Basket = &BasketInput{Assets: []BasketAssetInput{{AssetId: "USD"}, {AssetId: "EUR"}}}
+
+		actual, err := Reconcile(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.RebaseAssetId, ShouldEqual, "USD")
+		So(actual.Pairs, ShouldHaveLength, 2)
+	})
+	Convey("keeps the original symbols with an asset registry", t, func() {
+		input := mockReconcileInput()
+		input.Market[2].QuoteAssetId = "XGBP"
+		input.Assets = &AssetsInput{Equivalent: [][]string{{"GBP", "XGBP"}}}
+
+		actual, err := Reconcile(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.Pairs, ShouldHaveLength, 2)
+		So(actual.Pairs[0].Symbol, ShouldEqual, "USD/XGBP")
+	})
+	Convey("lists every pair merged into the same canonical pair", t, func() {
+		input := mockReconcileInput()
+		input.Market = append(input.Market, m.Pair{
+			BaseAssetId:     "usd",
+			QuoteAssetId:    "XGBP",
+			ExchangeMarkets: []m.ExchangeMarket{{ExchangeId: "b", CurrentBid: 1.34, CurrentAsk: 1.36, BaseVolume: 100}},
+		})
+		input.Assets = &AssetsInput{Equivalent: [][]string{{"GBP", "XGBP"}}}
+
+		actual, err := Reconcile(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.Pairs, ShouldHaveLength, 3)
+		So(actual.Pairs[0].Symbol, ShouldEqual, "USD/GBP")
+		So(actual.Pairs[1].Symbol, ShouldEqual, "usd/XGBP")
+		So(actual.Pairs[1].DeviationBps, ShouldEqual, actual.Pairs[0].DeviationBps)
+	})
+	Convey("flags a reconciliation cut short by the budget as partial", t, func() {
+		input := mockReconcileInput()
+		input.Budget.MaxPaths = 1
+
+		actual, err := Reconcile(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.Partial, ShouldBeTrue)
+		So(actual.PartialReason, ShouldNotBeEmpty)
+	})
+	Convey("fails with an unknown engine", t, func() {
+		input := mockReconcileInput()
+		input.Engine = "magic"
+
+		_, err := Reconcile(context.Background(), input)
+
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	"github.com/jochenboesmans/go-rebase/report"
+)
+
+/**
+Rebases the market of a request and analyzes it for a report: its connectivity, how far pairs deviate from the mids
+that the other pairs imply, and its graph. With an asset registry, asset ids are canonical and pairs are shown by their
+original symbols, and with a basket, the analyses start from the basket's first asset. The rebase and the comparison of mids share the request's budget.
+Running out of budget or time isn't an error: the report is flagged as partial.
+*/
+func Report(ctx context.Context, i Input) (report.Report, error) {
+	prepared, err := prepare(i)
+	if err != nil {
+		return report.Report{}, err
+	}
+	prepared.engine = prepared.engine.SharingBudget()
+	rebased, err := prepared.rebase(ctx, i)
+	if err != nil {
+		return report.Report{}, err
+	}
+
+	ctx, cancel := withResponseMargin(ctx)
+	defer cancel()
+	pathRebaseAssetId, err := i.pathRebaseAssetId()
+	if err != nil {
+		return report.Report{}, err
+	}
+	pathRebaseAssetId = prepared.assetId(pathRebaseAssetId)
+	reconciliations, err := prepared.engine.Reconcile(ctx, pathRebaseAssetId, &prepared.market)
+	if err != nil && !partial(err) {
+		return report.Report{}, err
+	}
+	partialReason := rebased.partialReason
+	if err != nil && partialReason == "" {
+		partialReason = err.Error()
+	}
+	graph, hopMarket, _ := prepared.engine.Graph(pathRebaseAssetId, &prepared.market)
+	symbols := map[string][]string{}
+	for pairId := range prepared.originalSymbols {
+		symbols[pairId] = prepared.originalSymbols.Symbols(pairId)
+	}
+
+	return report.Report{
+		GeneratedAt:     time.Now(),
+		RebaseAssetId:   prepared.rebaseAssetId,
+		Result:          rebased.Result,
+		PartialReason:   partialReason,
+		Connectivity:    graph.ConnectivityThrough(pathRebaseAssetId, prepared.engine.MaxPathDepth(), hopMarket),
+		Reconciliations: reconciliations,
+		Graph:           graph.Graph(m.GraphOptions{}),
+		Symbols:         symbols,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	Convey("rebases the market and analyzes it", t, func() {
+		actual, err := Report(context.Background(), mockReconcileInput())
+
+		So(err, ShouldBeNil)
+		So(actual.RebaseAssetId, ShouldEqual, "USD")
+		So(actual.Result.Market.PairsById, ShouldHaveLength, 3)
+		So(actual.Reconciliations, ShouldHaveLength, 2)
+		So(actual.Connectivity.UnreachableAssetIds, ShouldBeEmpty)
+		So(actual.Graph.Edges, ShouldHaveLength, 3)
+		So(actual.PartialReason, ShouldBeEmpty)
+	})
+	Convey("shows pairs by their original symbols with an asset registry", t, func() {
+		input := mockReconcileInput()
+		input.Market[2].QuoteAssetId = "XGBP"
+		input.Assets = &AssetsInput{Equivalent: [][]string{{"GBP", "XGBP"}}}
+
+		actual, err := Report(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		usdGbp := m.Pair{BaseAssetId: "USD", QuoteAssetId: "GBP"}
+		So(actual.Symbols[usdGbp.Id()], ShouldResemble, []string{"USD/XGBP"})
+	})
+	Convey("flags a report cut short by the budget as partial", t, func() {
+		input := mockReconcileInput()
+		input.Budget.MaxPaths = 1
+
+		actual, err := Report(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.PartialReason, ShouldNotBeEmpty)
+	})
+	Convey("shares the budget between the rebase and the comparison of mids", t, func() {
+		input := mockReconcileInput()
+		// enough for either, but not for both
+		input.Budget.MaxPaths = 7
+
+		actual, err := Report(context.Background(), input)
+
+		So(err, ShouldBeNil)
+		So(actual.Result.Market.PairsById, ShouldHaveLength, 3)
+		So(actual.Reconciliations, ShouldBeEmpty)
+		So(actual.PartialReason, ShouldNotBeEmpty)
+	})
+	Convey("fails with an unknown engine", t, func() {
+		input := mockReconcileInput()
+		input.Engine = "magic"
+
+		_, err := Report(context.Background(), input)
+
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,124 @@
+package ccxt
+
+import (
+	"sort"
+	"time"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+/**
+Settings of the conversion of tickers into a market.
+*/
+type Options struct {
+	// tickers older than this are skipped, zero keeps tickers of any age
+	MaxAge time.Duration
+	// the time MaxAge is counted back from, defaults to the current time
+	Now time.Time
+}
+
+/**
+Ticker that didn't make it into the market, and why.
+*/
+type Skipped struct {
+	ExchangeId string
+	Symbol     string
+	Reason     string
+}
+
+/**
+Converts tickers into a market, attributing every ticker to its exchange. A CCXT symbol prices its base asset in its
+quote asset, so "ETH/USDT" becomes the pair with base asset USDT and quote asset ETH, with the ticker's bid and ask as
+they are. The pair's base volume is the ticker's quoteVolume, or else its baseVolume at the ticker's vwap or mid, and
+its quote volume is the ticker's baseVolume.
+Tickers of derivatives, with an unparsable symbol, without a positive bid, ask or volume, or older than the maximum
+age are skipped and returned in order of exchange and symbol.
+*/
+func (t Tickers) Market(options Options) (m.Market, []Skipped) {
+	now := options.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	market := m.Market{PairsById: map[string]m.Pair{}}
+	skipped := []Skipped{}
+	for _, exchangeId := range sortedKeys(t) {
+		exchangeTickers := t[exchangeId]
+		symbols := []string{}
+		for symbol := range exchangeTickers {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+
+		for _, symbol := range symbols {
+			ticker := exchangeTickers[symbol]
+			pair, exchangeMarket, reason := ticker.convert(exchangeId)
+			if reason == "" && options.MaxAge > 0 && ticker.Timestamp != nil &&
+				now.Sub(time.Unix(0, *ticker.Timestamp*int64(time.Millisecond))) > options.MaxAge {
+				reason = "stale"
+			}
+			if reason != "" {
+				skipped = append(skipped, Skipped{ExchangeId: exchangeId, Symbol: symbol, Reason: reason})
+				continue
+			}
+
+			pairId := pair.Id()
+			if existingPair, ok := market.PairsById[pairId]; ok {
+				pair = existingPair
+			}
+			pair.ExchangeMarkets = append(pair.ExchangeMarkets, exchangeMarket)
+			market.PairsById[pairId] = pair
+		}
+	}
+	return market, skipped
+}
+
+// returns the reason the ticker can't be converted instead when it can't
+func (t Ticker) convert(exchangeId string) (m.Pair, m.ExchangeMarket, string) {
+	base, quote, err := ParseSymbol(t.Symbol)
+	if err != nil {
+		return m.Pair{}, m.ExchangeMarket{}, err.Error()
+	}
+	bid, ask := value(t.Bid), value(t.Ask)
+	if bid <= 0 || ask <= 0 {
+		return m.Pair{}, m.ExchangeMarket{}, "missing bid or ask"
+	}
+
+	quoteVolume := value(t.QuoteVolume)
+	if quoteVolume <= 0 {
+		price := value(t.Vwap)
+		if price <= 0 {
+			price = (bid + ask) / 2
+		}
+		quoteVolume = value(t.BaseVolume) * price
+	}
+	if quoteVolume <= 0 {
+		return m.Pair{}, m.ExchangeMarket{}, "missing volume"
+	}
+
+	pair := m.Pair{BaseAssetId: quote, QuoteAssetId: base}
+	exchangeMarket := m.ExchangeMarket{
+		ExchangeId:  exchangeId,
+		CurrentBid:  float32(bid),
+		CurrentAsk:  float32(ask),
+		BaseVolume:  float32(quoteVolume),
+		QuoteVolume: float32(value(t.BaseVolume)),
+	}
+	return pair, exchangeMarket, ""
+}
+
+func value(number *float64) float64 {
+	if number == nil {
+		return 0
+	}
+	return *number
+}
+
+func sortedKeys(tickers Tickers) []string {
+	keys := []string{}
+	for key := range tickers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
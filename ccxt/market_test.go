@@ -0,0 +1,59 @@
+package ccxt
+
+import (
+	"testing"
+	"time"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTickers_Market(t *testing.T) {
+	usdtEth := m.Pair{BaseAssetId: "USDT", QuoteAssetId: "ETH"}
+	usdtBtc := m.Pair{BaseAssetId: "USDT", QuoteAssetId: "BTC"}
+	btcEth := m.Pair{BaseAssetId: "BTC", QuoteAssetId: "ETH"}
+	eurBtc := m.Pair{BaseAssetId: "EUR", QuoteAssetId: "BTC"}
+
+	Convey("converts recorded tickers into pairs of every exchange", t, func() {
+		tickers, err := ReadTickerFiles("testdata/fetch_tickers.json", "testdata/ticker_arrays.json")
+		So(err, ShouldBeNil)
+
+		market, skipped := tickers.Market(Options{})
+
+		So(market.PairsById, ShouldHaveLength, 4)
+		So(market.PairsById[usdtEth.Id()].ExchangeMarkets, ShouldResemble, []m.ExchangeMarket{
+			{ExchangeId: "binance", CurrentBid: 2050.1, CurrentAsk: 2050.2, BaseVolume: 511332500.25, QuoteVolume: 250000.5},
+			{ExchangeId: "kraken", CurrentBid: 2049.9, CurrentAsk: 2050.4, BaseVolume: 5000 * 2044, QuoteVolume: 5000},
+		})
+		So(market.PairsById[usdtBtc.Id()].ExchangeMarkets, ShouldResemble, []m.ExchangeMarket{
+			{ExchangeId: "binance", CurrentBid: 37100, CurrentAsk: 37100.5, BaseVolume: 1112000000, QuoteVolume: 30100},
+		})
+		So(market.PairsById[btcEth.Id()].ExchangeMarkets[0].BaseVolume, ShouldEqual, float32(1000*(0.05541+0.05542)/2))
+		So(market.PairsById[eurBtc.Id()].ExchangeMarkets[0].ExchangeId, ShouldEqual, "coinbase")
+		So(skipped, ShouldResemble, []Skipped{
+			{ExchangeId: "binance", Symbol: "BTC/USDT:USDT", Reason: `"BTC/USDT:USDT" is a derivative symbol`},
+			{ExchangeId: "kraken", Symbol: "DOT/EUR", Reason: "missing bid or ask"},
+		})
+	})
+	Convey("skips tickers older than the maximum age", t, func() {
+		tickers, err := ReadTickerFiles("testdata/fetch_tickers.json")
+		So(err, ShouldBeNil)
+
+		market, skipped := tickers.Market(Options{
+			MaxAge: 5 * time.Second,
+			Now:    time.Unix(1700000001, 0),
+		})
+
+		So(market.PairsById[usdtEth.Id()].ExchangeMarkets, ShouldHaveLength, 1)
+		So(skipped, ShouldContain, Skipped{ExchangeId: "kraken", Symbol: "ETH/USDT", Reason: "stale"})
+	})
+	Convey("skips tickers without volume", t, func() {
+		bid, ask := 1.0, 1.1
+		tickers := Tickers{"a": {"ETH/USDT": {Symbol: "ETH/USDT", Bid: &bid, Ask: &ask}}}
+
+		market, skipped := tickers.Market(Options{})
+
+		So(market.PairsById, ShouldBeEmpty)
+		So(skipped[0].Reason, ShouldEqual, "missing volume")
+	})
+}
@@ -0,0 +1,134 @@
+package ccxt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+/**
+Unified ticker as fetched by CCXT's fetchTicker and fetchTickers. Fields CCXT reports as null are nil.
+Prices are in the symbol's quote asset, baseVolume in its base asset and quoteVolume in its quote asset.
+*/
+type Ticker struct {
+	Symbol string `json:"symbol"`
+	// milliseconds since the epoch
+	Timestamp   *int64   `json:"timestamp"`
+	Bid         *float64 `json:"bid"`
+	Ask         *float64 `json:"ask"`
+	Vwap        *float64 `json:"vwap"`
+	BaseVolume  *float64 `json:"baseVolume"`
+	QuoteVolume *float64 `json:"quoteVolume"`
+}
+
+/**
+Tickers by exchange id and symbol.
+*/
+type Tickers map[string]map[string]Ticker
+
+/**
+Reads a ticker dump keyed by exchange id. Every exchange holds either the object fetchTickers returns, keyed by symbol,
+or an array of tickers.
+*/
+func ReadTickers(r io.Reader) (Tickers, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var exchanges map[string]json.RawMessage
+	if err := json.Unmarshal(data, &exchanges); err != nil {
+		return nil, fmt.Errorf("reading tickers: %w", err)
+	}
+
+	tickers := Tickers{}
+	for exchangeId, exchangeData := range exchanges {
+		var exchangeTickers []Ticker
+		if bytes.HasPrefix(bytes.TrimSpace(exchangeData), []byte("[")) {
+			err = json.Unmarshal(exchangeData, &exchangeTickers)
+		} else {
+			var tickersBySymbol map[string]Ticker
+			err = json.Unmarshal(exchangeData, &tickersBySymbol)
+			for symbol, ticker := range tickersBySymbol {
+				// recorded dumps sometimes leave the symbol out of the ticker itself
+				if ticker.Symbol == "" {
+					ticker.Symbol = symbol
+				}
+				exchangeTickers = append(exchangeTickers, ticker)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf(`reading tickers of exchange "%s": %w`, exchangeId, err)
+		}
+		for _, ticker := range exchangeTickers {
+			tickers.add(exchangeId, ticker)
+		}
+	}
+	return tickers, nil
+}
+
+/**
+Adds the tickers of another dump. Of tickers for the same exchange and symbol, the latest one is kept.
+*/
+func (t Tickers) Add(other Tickers) {
+	for exchangeId, exchangeTickers := range other {
+		for _, ticker := range exchangeTickers {
+			t.add(exchangeId, ticker)
+		}
+	}
+}
+
+func (t Tickers) add(exchangeId string, ticker Ticker) {
+	if _, ok := t[exchangeId]; !ok {
+		t[exchangeId] = map[string]Ticker{}
+	}
+	if existing, ok := t[exchangeId][ticker.Symbol]; ok && existing.timestamp() > ticker.timestamp() {
+		return
+	}
+	t[exchangeId][ticker.Symbol] = ticker
+}
+
+func (t Ticker) timestamp() int64 {
+	if t.Timestamp == nil {
+		return 0
+	}
+	return *t.Timestamp
+}
+
+/**
+Splits a CCXT unified spot symbol like "ETH/USDT" into its base and quote asset.
+Derivative symbols, which carry a settlement asset as in "BTC/USDT:USDT", aren't spot markets and are rejected.
+*/
+func ParseSymbol(symbol string) (base string, quote string, err error) {
+	if strings.Contains(symbol, ":") {
+		return "", "", fmt.Errorf(`"%s" is a derivative symbol`, symbol)
+	}
+	parts := strings.Split(symbol, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf(`invalid symbol "%s"`, symbol)
+	}
+	return parts[0], parts[1], nil
+}
+
+/**
+Reads and adds up the ticker dumps of several files, e.g. one per collection run or exchange.
+*/
+func ReadTickerFiles(paths ...string) (Tickers, error) {
+	tickers := Tickers{}
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		fileTickers, err := ReadTickers(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		tickers.Add(fileTickers)
+	}
+	return tickers, nil
+}
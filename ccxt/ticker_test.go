@@ -0,0 +1,77 @@
+package ccxt
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadTickers(t *testing.T) {
+	Convey("reads fetchTickers objects keyed by exchange", t, func() {
+		file, err := os.Open("testdata/fetch_tickers.json")
+		So(err, ShouldBeNil)
+		defer file.Close()
+
+		tickers, err := ReadTickers(file)
+
+		So(err, ShouldBeNil)
+		So(tickers, ShouldHaveLength, 2)
+		So(tickers["binance"], ShouldHaveLength, 4)
+		So(tickers["kraken"], ShouldHaveLength, 2)
+		ticker := tickers["binance"]["ETH/BTC"]
+		So(*ticker.Bid, ShouldEqual, 0.05541)
+		So(*ticker.Timestamp, ShouldEqual, 1700000000789)
+		So(ticker.QuoteVolume, ShouldBeNil)
+		So(ticker.Vwap, ShouldBeNil)
+	})
+	Convey("reads arrays of tickers and symbols missing from tickers", t, func() {
+		tickers, err := ReadTickers(strings.NewReader(`{"a": [{"symbol": "ETH/USDT", "bid": 1}], "b": {"BTC/USDT": {"bid": 2}}}`))
+
+		So(err, ShouldBeNil)
+		So(*tickers["a"]["ETH/USDT"].Bid, ShouldEqual, 1)
+		So(tickers["b"]["BTC/USDT"].Symbol, ShouldEqual, "BTC/USDT")
+	})
+	Convey("invalid dumps", t, func() {
+		_, err := ReadTickers(strings.NewReader(`[]`))
+		So(err, ShouldNotBeNil)
+
+		_, err = ReadTickers(strings.NewReader(`{"a": {"ETH/USDT": {"bid": "x"}}}`))
+		So(err.Error(), ShouldContainSubstring, `"a"`)
+	})
+}
+
+func TestReadTickerFiles(t *testing.T) {
+	Convey("keeps the latest ticker of every exchange and symbol", t, func() {
+		tickers, err := ReadTickerFiles("testdata/fetch_tickers.json", "testdata/ticker_arrays.json")
+
+		So(err, ShouldBeNil)
+		So(tickers, ShouldHaveLength, 3)
+		So(*tickers["binance"]["BTC/USDT"].Bid, ShouldEqual, 37100)
+		So(*tickers["binance"]["ETH/USDT"].Bid, ShouldEqual, 2050.1)
+		So(tickers["coinbase"], ShouldHaveLength, 1)
+	})
+	Convey("missing files", t, func() {
+		_, err := ReadTickerFiles("testdata/missing.json")
+
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestParseSymbol(t *testing.T) {
+	Convey("spot symbols", t, func() {
+		base, quote, err := ParseSymbol("ETH/USDT")
+
+		So(err, ShouldBeNil)
+		So(base, ShouldEqual, "ETH")
+		So(quote, ShouldEqual, "USDT")
+	})
+	Convey("derivative and invalid symbols", t, func() {
+		for _, symbol := range []string{"BTC/USDT:USDT", "BTC/USD:BTC-211225-60000-C", "BTCUSDT", "BTC/", "A/B/C"} {
+			_, _, err := ParseSymbol(symbol)
+
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
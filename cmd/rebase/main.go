@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jochenboesmans/go-rebase/api"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	rpt "github.com/jochenboesmans/go-rebase/report"
+	"github.com/jochenboesmans/go-rebase/wire"
+)
+
+/**
+Rebases a market file once, reading JSON rebase inputs or CSV markets and writing the rebased market in either format.
+Settings given as flags take precedence over those in a JSON input.
+With -report reconcile, it writes a table of every pair's direct mid next to the mid the other pairs imply instead, and
+with -report connectivity, the assets and pairs that rebase paths don't reach along with pairs that would reach them.
+With -report graph, it draws the market in Graphviz DOT or as JSON nodes and edges, and with -report html, it writes a
+single page report of the rebase.
+*/
+func main() {
+	inFile := flag.String("in", "", "file to read, defaults to stdin")
+	outFile := flag.String("out", "", "file to write, defaults to stdout")
+	inFormat := flag.String("format", "json", "format of the input, json or csv")
+	outFormat := flag.String("outputFormat", "", "format of the output, json or csv, defaults to the input's format")
+	delimiter := flag.String("delimiter", ",", `CSV delimiter, a single character or "tab"`)
+	noHeader := flag.Bool("noHeader", false, "whether CSV files have no header row")
+	rebaseAssetId := flag.String("rebaseAssetId", "", "asset to rebase the market in")
+	maxPathLength := flag.Uint("maxPathLength", 0, "maximum length of rebase paths, chosen automatically when left out")
+	pathWeighting := flag.String("pathWeighting", "", "how rebase paths are weighted")
+	engine := flag.String("engine", "", "paths or leastSquares, defaults to paths")
+	basketAssets := flag.String("basket", "", `assets of a basket named -rebaseAssetId to rebase in, e.g. "USD:0.6,EUR:0.4"`)
+	geometric := flag.Bool("geometric", false, "whether the basket is priced as the weighted geometric mean of its assets")
+	report := flag.String("report", "", `"reconcile", "connectivity", "graph", "html" or "portfolio" to write a report instead of the rebased market`)
+	graphFormat := flag.String("graphFormat", "dot", "format of the graph report, dot or json")
+	graphNodes := flag.String("graphNodes", "assets", "nodes of the graph report, assets or pairs")
+	graphLabels := flag.String("graphLabels", "", `comma separated edge labels of the graph report, e.g. "mid,spread,volume"`)
+	highlight := flag.String("highlight", "", "symbol of a pair whose rebase paths the graph report highlights")
+	holdings := flag.String("holdings", "", `holdings that the portfolio report values, e.g. "BTC:1.5,ETH:10"`)
+	flag.Parse()
+
+	if *outFormat == "" {
+		*outFormat = *inFormat
+	}
+	inCodec, err := codec(*inFormat, *delimiter, *noHeader)
+	if err != nil {
+		log.Fatal(err)
+	}
+	outCodec, err := codec(*outFormat, *delimiter, *noHeader)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	data, err := read(*inFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	input, err := inCodec.UnmarshalInput(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *rebaseAssetId != "" {
+		input.RebaseAssetId = *rebaseAssetId
+	}
+	if *maxPathLength > math.MaxUint8 {
+		log.Fatalf("-maxPathLength %d is over %d", *maxPathLength, math.MaxUint8)
+	}
+	if *maxPathLength != 0 {
+		input.MaxPathLength = uint8(*maxPathLength)
+	}
+	if *pathWeighting != "" {
+		input.PathWeighting = *pathWeighting
+	}
+	if *engine != "" {
+		input.Engine = *engine
+	}
+	if *basketAssets != "" {
+		basket, err := parseBasket(*basketAssets, *geometric)
+		if err != nil {
+			log.Fatal(err)
+		}
+		input.Basket = basket
+	}
+	if input.RebaseAssetId == "" {
+		log.Fatal("-rebaseAssetId is required")
+	}
+
+	switch *report {
+	case "":
+		output, err := api.Rebase(context.Background(), input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if output.Partial {
+			log.Printf("partially rebased: %s", output.PartialReason)
+		}
+		data, err = outCodec.MarshalOutput(output)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "reconcile":
+		output, err := api.Reconcile(context.Background(), input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if output.Partial {
+			log.Printf("partially reconciled: %s", output.PartialReason)
+		}
+		data, err = reconciliationTable(output)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "connectivity":
+		output, err := api.Connectivity(input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		data, err = connectivityReport(output)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "graph":
+		graphInput := api.GraphInput{Input: input, Nodes: *graphNodes, Highlight: *highlight}
+		if *graphLabels != "" {
+			graphInput.Labels = strings.Split(*graphLabels, ",")
+		}
+		graph, err := api.Graph(context.Background(), graphInput)
+		if err != nil {
+			log.Fatal(err)
+		}
+		data, err = graphReport(graph, *graphFormat)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "html":
+		htmlReport, err := api.Report(context.Background(), input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var html bytes.Buffer
+		if err := rpt.WriteHTML(&html, htmlReport); err != nil {
+			log.Fatal(err)
+		}
+		data = html.Bytes()
+	case "portfolio":
+		portfolioInput := api.PortfolioInput{Input: input}
+		if portfolioInput.Holdings, err = parseHoldings(*holdings); err != nil {
+			log.Fatal(err)
+		}
+		output, err := api.Portfolio(context.Background(), portfolioInput)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if output.Partial {
+			log.Printf("partially rebased: %s", output.PartialReason)
+		}
+		data, err = portfolioTable(output)
+		if err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf(`unsupported report "%s"`, *report)
+	}
+	if err := write(*outFile, data); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func codec(format string, delimiter string, noHeader bool) (wire.Codec, error) {
+	switch format {
+	case "json":
+		return wire.JSON, nil
+	case "csv":
+		params := map[string]string{"delimiter": delimiter}
+		if noHeader {
+			params["header"] = "absent"
+		}
+		return wire.ForContentType(mime.FormatMediaType("text/csv", params))
+	}
+	return nil, fmt.Errorf(`unsupported format "%s"`, format)
+}
+
+// comma separated assets with optional weights, e.g. "USD:0.6,EUR:0.4" or "BTC,ETH,USD"
+func parseBasket(assets string, geometric bool) (*api.BasketInput, error) {
+	basket := api.BasketInput{Geometric: geometric}
+	for _, asset := range strings.Split(assets, ",") {
+		assetId, weight := strings.TrimSpace(asset), ""
+		if i := strings.Index(assetId, ":"); i >= 0 {
+			assetId, weight = assetId[:i], assetId[i+1:]
+		}
+		basketAsset := api.BasketAssetInput{AssetId: assetId}
+		if weight != "" {
+			parsed, err := strconv.ParseFloat(weight, 32)
+			if err != nil {
+				return nil, fmt.Errorf(`invalid weight "%s" of basket asset "%s"`, weight, assetId)
+			}
+			basketAsset.Weight = float32(parsed)
+		}
+		basket.Assets = append(basket.Assets, basketAsset)
+	}
+	return &basket, nil
+}
+
+// comma separated assets with quantities, e.g. "BTC:1.5,ETH:10"
+func parseHoldings(assets string) (map[string]float64, error) {
+	holdings := map[string]float64{}
+	if assets == "" {
+		return nil, fmt.Errorf("-holdings is required")
+	}
+	for _, asset := range strings.Split(assets, ",") {
+		holding := strings.TrimSpace(asset)
+		i := strings.Index(holding, ":")
+		if i < 0 {
+			return nil, fmt.Errorf(`no quantity of holding "%s"`, holding)
+		}
+		quantity, err := strconv.ParseFloat(holding[i+1:], 64)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid quantity "%s" of holding "%s"`, holding[i+1:], holding[:i])
+		}
+		holdings[holding[:i]] += quantity
+	}
+	return holdings, nil
+}
+
+// tab aligned table of the positions, largest value first, and their totals, to the precision of the prices
+func portfolioTable(output api.PortfolioOutput) ([]byte, error) {
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(writer, "asset\tquantity\tvalue (%s)\tconservative\toptimistic\t\n", output.RebaseAssetId)
+	for _, position := range output.Positions {
+		if position.Unpriced {
+			fmt.Fprintf(writer, "%s\t%g\tunpriced\t\t\t\n", position.AssetId, position.Quantity)
+			continue
+		}
+		fmt.Fprintf(writer, "%s\t%g\t%.8g\t%.8g\t%.8g\t\n", position.AssetId, position.Quantity, position.Value,
+			position.ConservativeValue, position.OptimisticValue)
+	}
+	fmt.Fprintf(writer, "total\t\t%.8g\t%.8g\t%.8g\t\n", output.Total, output.ConservativeTotal, output.OptimisticTotal)
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	return []byte(table.String()), nil
+}
+
+// tab aligned table of the reconciled pairs, largest deviation first
+func reconciliationTable(output api.ReconcileOutput) ([]byte, error) {
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(writer, "pair\tdirect mid\timplied mid\tdeviation (bps)\t\n")
+	for _, pair := range output.Pairs {
+		fmt.Fprintf(writer, "%s\t%g\t%g\t%.1f\t\n", pair.Symbol, pair.DirectMid, pair.ImpliedMid, pair.DeviationBps)
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	return []byte(table.String()), nil
+}
+
+// connected components, unreachable assets and pairs, and a tab aligned table of suggested pairs
+func connectivityReport(output api.ConnectivityOutput) ([]byte, error) {
+	var report strings.Builder
+	fmt.Fprintf(&report, "rebase paths of at most %d pairs from %s\n", output.MaxPathLength, output.RebaseAssetId)
+	fmt.Fprint(&report, "\ncomponents:\n")
+	for _, component := range output.Components {
+		fmt.Fprintf(&report, "  %s\n", strings.Join(component, " "))
+	}
+	fmt.Fprintf(&report, "\nunreachable assets: %s\n", strings.Join(output.UnreachableAssets, " "))
+	fmt.Fprintf(&report, "unreachable pairs: %s\n", strings.Join(output.UnreachablePairs, " "))
+	if len(output.Suggestions) == 0 {
+		return []byte(report.String()), nil
+	}
+
+	fmt.Fprint(&report, "\n")
+	writer := tabwriter.NewWriter(&report, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(writer, "suggested pair\tconnected pairs\tvolume\t\n")
+	for _, suggestion := range output.Suggestions {
+		fmt.Fprintf(writer, "%s\t%d\t%g\t\n", suggestion.Symbol, len(suggestion.ConnectedPairs), suggestion.Volume)
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	return []byte(report.String()), nil
+}
+
+func graphReport(graph m.Graph, format string) ([]byte, error) {
+	switch format {
+	case "dot":
+		var dot bytes.Buffer
+		if err := m.WriteDOT(&dot, graph); err != nil {
+			return nil, err
+		}
+		return dot.Bytes(), nil
+	case "json":
+		return json.MarshalIndent(graph, "", "  ")
+	}
+	return nil, fmt.Errorf(`unsupported graph format "%s"`, format)
+}
+
+func read(path string) ([]byte, error) {
+	if path == "" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+func write(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
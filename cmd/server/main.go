@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	"github.com/jochenboesmans/go-rebase/rebasing"
+	"github.com/jochenboesmans/go-rebase/rpc"
+	"github.com/jochenboesmans/go-rebase/streaming"
+	"github.com/jochenboesmans/go-rebase/wire"
+)
+
+/**
+Runs the streaming server, which keeps a live market rebased and pushes changes to subscribers, next to the rebase
+endpoint the Lambda offers, and optionally the gRPC rebase service.
+*/
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	grpcAddr := flag.String("grpcAddr", "", "optional address to serve the gRPC rebase service on")
+	rebaseAssetId := flag.String("rebaseAssetId", "", "asset to rebase the market in")
+	maxPathLength := flag.Uint("maxPathLength", 3, "maximum length of rebase paths")
+	pathWeighting := flag.String("pathWeighting", "", "how rebase paths are weighted")
+	marketFile := flag.String("market", "", "optional JSON file with an array of pairs, or .csv file, to start with")
+	flag.Parse()
+
+	if *rebaseAssetId == "" {
+		log.Fatal("-rebaseAssetId is required")
+	}
+	if *maxPathLength > math.MaxUint8 {
+		log.Fatalf("-maxPathLength %d is over %d", *maxPathLength, math.MaxUint8)
+	}
+	pathWeighter, err := rebasing.PathWeighterByName(*pathWeighting)
+	if err != nil {
+		log.Fatal(err)
+	}
+	market, err := readMarket(*marketFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	engine := rebasing.NewEngine(
+		rebasing.WithMaxPathDepth(uint8(*maxPathLength)),
+		rebasing.WithPathWeighter(pathWeighter),
+	)
+	server, err := streaming.NewServer(context.Background(), engine, *rebaseAssetId, &market)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *grpcAddr != "" {
+		listener, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("serving gRPC on %s", *grpcAddr)
+		go func() {
+			log.Fatal(rpc.NewGRPCServer(server).Serve(listener))
+		}()
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/rebase", wire.Handler())
+	mux.Handle("/portfolio", wire.PortfolioHandler())
+	mux.Handle("/", server)
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func readMarket(path string) (m.Market, error) {
+	market := m.Market{PairsById: map[string]m.Pair{}}
+	if path == "" {
+		return market, nil
+	}
+	if strings.HasSuffix(path, ".csv") {
+		file, err := os.Open(path)
+		if err != nil {
+			return market, err
+		}
+		defer file.Close()
+		return m.ReadCSV(file, m.CSVFormat{})
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return market, err
+	}
+	var pairs []m.Pair
+	if err := json.Unmarshal(data, &pairs); err != nil {
+		return market, err
+	}
+	for _, pair := range pairs {
+		market.PairsById[pair.Id()] = pair
+	}
+	return market, nil
+}
@@ -0,0 +1,231 @@
+package market
+
+import (
+	"sort"
+	"strings"
+)
+
+/**
+Canonical ids of assets that markets list under different symbols, e.g. "usd" and "USD", "XBT" and "BTC", or "WETH"
+and "ETH". Asset ids are compared after trimming whitespace and upper-casing them, and aliases map to the canonical id
+of their group. The zero value only normalizes case and whitespace.
+*/
+type AssetRegistry struct {
+	// canonical asset id by normalized asset id
+	canonicalIds map[string]string
+}
+
+func NewAssetRegistry() *AssetRegistry {
+	return &AssetRegistry{canonicalIds: map[string]string{}}
+}
+
+/**
+Asset id with surrounding whitespace trimmed and in upper case.
+*/
+func NormalizeAssetId(assetId string) string {
+	return strings.ToUpper(strings.TrimSpace(assetId))
+}
+
+/**
+Registers aliases of a canonical asset id. Groups the aliases or canonical id are already in join under that id.
+*/
+func (r *AssetRegistry) Alias(canonicalId string, aliases ...string) {
+	canonicalId = NormalizeAssetId(canonicalId)
+	r.join(canonicalId, append([]string{canonicalId}, aliases...))
+}
+
+/**
+Treats assets as one. A group that includes already registered assets joins their groups, under the canonical id of
+the first one listed. Otherwise the first asset id is the group's canonical id.
+*/
+func (r *AssetRegistry) Equate(assetIds ...string) {
+	if len(assetIds) == 0 {
+		return
+	}
+	canonicalId := NormalizeAssetId(assetIds[0])
+	for _, assetId := range assetIds {
+		if existingId, ok := r.canonicalIds[NormalizeAssetId(assetId)]; ok {
+			canonicalId = existingId
+			break
+		}
+	}
+	r.join(canonicalId, assetIds)
+}
+
+// merges the assets and the groups they're in into one group with canonicalId
+func (r *AssetRegistry) join(canonicalId string, assetIds []string) {
+	if r.canonicalIds == nil {
+		r.canonicalIds = map[string]string{}
+	}
+	joinedIds := map[string]bool{}
+	for _, assetId := range assetIds {
+		if existingId, ok := r.canonicalIds[NormalizeAssetId(assetId)]; ok {
+			joinedIds[existingId] = true
+		}
+	}
+	for normalizedId, existingId := range r.canonicalIds {
+		if joinedIds[existingId] {
+			r.canonicalIds[normalizedId] = canonicalId
+		}
+	}
+	for _, assetId := range assetIds {
+		r.canonicalIds[NormalizeAssetId(assetId)] = canonicalId
+	}
+	r.canonicalIds[canonicalId] = canonicalId
+}
+
+/**
+Canonical id of an asset, which is its normalized id unless it's an alias.
+*/
+func (r *AssetRegistry) Canonical(assetId string) string {
+	normalizedId := NormalizeAssetId(assetId)
+	if canonicalId, ok := r.canonicalIds[normalizedId]; ok {
+		return canonicalId
+	}
+	return normalizedId
+}
+
+/**
+Market with the canonical ids of all assets. Pairs that end up with the same assets are merged, and the returned
+OriginalSymbols restore the original pairs from a market rebased from the canonical one. Pairs of two equivalent assets,
+like ETH/WETH with ETH and WETH equated, are left out: they'd only trade an asset against itself. OriginalSymbols still
+records them, and Restore leaves them out like any pair missing from the rebased market.
+*/
+func (r *AssetRegistry) Canonicalize(market *Market) (Market, OriginalSymbols) {
+	// merge pairs in a fixed order, to keep the order of merged exchange markets stable
+	pairs := []Pair{}
+	for _, pair := range market.PairsById {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Symbol() < pairs[j].Symbol()
+	})
+
+	canonicalMarket := Market{PairsById: map[string]Pair{}}
+	originalSymbols := OriginalSymbols{}
+	for _, pair := range pairs {
+		canonicalPair := Pair{
+			BaseAssetId:  r.Canonical(pair.BaseAssetId),
+			QuoteAssetId: r.Canonical(pair.QuoteAssetId),
+		}
+		canonicalPairId := canonicalPair.Id()
+		originalSymbols.add(canonicalPairId, pair)
+		if canonicalPair.BaseAssetId == canonicalPair.QuoteAssetId {
+			continue
+		}
+		if existingPair, ok := canonicalMarket.PairsById[canonicalPairId]; ok {
+			canonicalPair = existingPair
+		}
+		canonicalPair.ExchangeMarkets = append(canonicalPair.ExchangeMarkets, pair.ExchangeMarkets...)
+		canonicalMarket.PairsById[canonicalPairId] = canonicalPair
+	}
+	return canonicalMarket, originalSymbols
+}
+
+/**
+Filter with the canonical ids of the assets and pairs it lists.
+*/
+func (r *AssetRegistry) CanonicalFilter(f Filter) Filter {
+	canonicalAssets := func(assetIds []string) []string {
+		var canonicalIds []string
+		for _, assetId := range assetIds {
+			canonicalIds = append(canonicalIds, r.Canonical(assetId))
+		}
+		return canonicalIds
+	}
+	canonicalPairs := func(symbols []string) []string {
+		var canonicalSymbols []string
+		for _, symbol := range symbols {
+			if assetIds := strings.Split(symbol, "/"); len(assetIds) == 2 {
+				symbol = r.Canonical(assetIds[0]) + "/" + r.Canonical(assetIds[1])
+			}
+			canonicalSymbols = append(canonicalSymbols, symbol)
+		}
+		return canonicalSymbols
+	}
+	f.IncludeAssets = canonicalAssets(f.IncludeAssets)
+	f.ExcludeAssets = canonicalAssets(f.ExcludeAssets)
+	f.IncludePairs = canonicalPairs(f.IncludePairs)
+	f.ExcludePairs = canonicalPairs(f.ExcludePairs)
+	return f
+}
+
+/**
+Original pairs that were merged into the pairs of a canonical market, with the exchanges of their exchange markets in
+the order they were merged.
+*/
+type OriginalSymbols map[string]originalPairs
+
+type originalPairs struct {
+	pairs []Pair
+	// index into pairs and exchange of every exchange market of the canonical pair
+	pairIndexes []int
+	exchangeIds []string
+}
+
+func (s OriginalSymbols) add(canonicalPairId string, pair Pair) {
+	originals := s[canonicalPairId]
+	originals.pairs = append(originals.pairs, Pair{BaseAssetId: pair.BaseAssetId, QuoteAssetId: pair.QuoteAssetId})
+	for _, emd := range pair.ExchangeMarkets {
+		originals.pairIndexes = append(originals.pairIndexes, len(originals.pairs)-1)
+		originals.exchangeIds = append(originals.exchangeIds, emd.ExchangeId)
+	}
+	s[canonicalPairId] = originals
+}
+
+/**
+Sorted symbols of the original pairs that were merged into a canonical pair, or nil for a pair that doesn't come from
+the original market.
+*/
+func (s OriginalSymbols) Symbols(canonicalPairId string) []string {
+	originals, ok := s[canonicalPairId]
+	if !ok {
+		return nil
+	}
+	var symbols []string
+	for _, pair := range originals.pairs {
+		symbols = append(symbols, pair.Symbol())
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+/**
+Market with the original symbols of a market rebased from a canonical one. Every exchange market goes back to the pair
+it came from. Rebasing keeps the order of exchange markets and filters drop them by exchange, so they're matched to
+their origin by exchange in order.
+*/
+func (s OriginalSymbols) Restore(canonicalMarket *Market) Market {
+	market := Market{PairsById: map[string]Pair{}}
+	for canonicalPairId, canonicalPair := range canonicalMarket.PairsById {
+		originals, ok := s[canonicalPairId]
+		if !ok {
+			market.PairsById[canonicalPairId] = canonicalPair
+			continue
+		}
+
+		restoredPairs := make([]Pair, len(originals.pairs))
+		copy(restoredPairs, originals.pairs)
+		next := 0
+		for _, emd := range canonicalPair.ExchangeMarkets {
+			for next < len(originals.exchangeIds) && originals.exchangeIds[next] != emd.ExchangeId {
+				next++
+			}
+			pairIndex := 0
+			if next < len(originals.exchangeIds) {
+				pairIndex = originals.pairIndexes[next]
+				next++
+			}
+			restoredPairs[pairIndex].ExchangeMarkets = append(restoredPairs[pairIndex].ExchangeMarkets, emd)
+		}
+
+		for _, pair := range restoredPairs {
+			// like Filter, drops pairs left without exchange markets
+			if len(pair.ExchangeMarkets) == 0 && len(canonicalPair.ExchangeMarkets) > 0 {
+				continue
+			}
+			market.PairsById[pair.Id()] = pair
+		}
+	}
+	return market
+}
@@ -0,0 +1,116 @@
+package market
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAssetRegistry_Canonical(t *testing.T) {
+	Convey("normalizes case and whitespace", t, func() {
+		var registry AssetRegistry
+
+		So(registry.Canonical(" usd\t"), ShouldEqual, "USD")
+	})
+	Convey("maps aliases to their canonical id", t, func() {
+		registry := NewAssetRegistry()
+		registry.Alias("BTC", "XBT", "xxbt")
+
+		So(registry.Canonical("xbt"), ShouldEqual, "BTC")
+		So(registry.Canonical("XXBT"), ShouldEqual, "BTC")
+		So(registry.Canonical("btc"), ShouldEqual, "BTC")
+		So(registry.Canonical("ETH"), ShouldEqual, "ETH")
+	})
+	Convey("joins groups of equivalent assets", t, func() {
+		registry := NewAssetRegistry()
+		registry.Equate("ETH", "WETH")
+		registry.Equate("STETH", "weth")
+
+		So(registry.Canonical("STETH"), ShouldEqual, "ETH")
+
+		registry.Alias("XETH", "ETH")
+
+		for _, assetId := range []string{"ETH", "WETH", "STETH"} {
+			So(registry.Canonical(assetId), ShouldEqual, "XETH")
+		}
+	})
+}
+
+func TestAssetRegistry_Canonicalize(t *testing.T) {
+	mockPairA := Pair{
+		BaseAssetId:     "usd",
+		QuoteAssetId:    "XBT",
+		ExchangeMarkets: []ExchangeMarket{{ExchangeId: "x", CurrentBid: 1}},
+	}
+	mockPairB := Pair{
+		BaseAssetId:     "USD",
+		QuoteAssetId:    "BTC",
+		ExchangeMarkets: []ExchangeMarket{{ExchangeId: "x", CurrentBid: 2}, {ExchangeId: "y", CurrentBid: 3}},
+	}
+	mockPairC := Pair{
+		BaseAssetId:     "BTC",
+		QuoteAssetId:    "ETH",
+		ExchangeMarkets: []ExchangeMarket{{ExchangeId: "y", CurrentBid: 4}},
+	}
+	mockMarket := Market{
+		PairsById: map[string]Pair{
+			mockPairA.Id(): mockPairA,
+			mockPairB.Id(): mockPairB,
+			mockPairC.Id(): mockPairC,
+		},
+	}
+	registry := NewAssetRegistry()
+	registry.Alias("BTC", "XBT")
+	usdBtc := Pair{BaseAssetId: "USD", QuoteAssetId: "BTC"}
+
+	Convey("merges pairs of the same canonical assets", t, func() {
+		actual, _ := registry.Canonicalize(&mockMarket)
+
+		So(actual.PairsById, ShouldHaveLength, 2)
+		So(actual.PairsById[usdBtc.Id()].ExchangeMarkets, ShouldResemble, []ExchangeMarket{
+			{ExchangeId: "x", CurrentBid: 2}, {ExchangeId: "y", CurrentBid: 3}, {ExchangeId: "x", CurrentBid: 1},
+		})
+	})
+	Convey("restores the original pairs", t, func() {
+		canonicalMarket, originalSymbols := registry.Canonicalize(&mockMarket)
+
+		So(originalSymbols.Restore(&canonicalMarket), ShouldResemble, mockMarket)
+	})
+	Convey("restores the original pairs of exchange markets that were filtered out", t, func() {
+		canonicalMarket, originalSymbols := registry.Canonicalize(&mockMarket)
+		filteredMarket := canonicalMarket.Filter(Filter{ExcludeExchanges: []string{"y"}})
+
+		actual := originalSymbols.Restore(&filteredMarket)
+
+		So(actual.PairsById, ShouldHaveLength, 2)
+		So(actual.PairsById[mockPairA.Id()], ShouldResemble, mockPairA)
+		So(actual.PairsById[mockPairB.Id()].ExchangeMarkets, ShouldResemble, []ExchangeMarket{{ExchangeId: "x", CurrentBid: 2}})
+	})
+	Convey("lists the original symbols of canonical pairs", t, func() {
+		_, originalSymbols := registry.Canonicalize(&mockMarket)
+		ethSol := Pair{BaseAssetId: "ETH", QuoteAssetId: "SOL"}
+
+		So(originalSymbols.Symbols(usdBtc.Id()), ShouldResemble, []string{"USD/BTC", "usd/XBT"})
+		So(originalSymbols.Symbols(mockPairC.Id()), ShouldResemble, []string{"BTC/ETH"})
+		So(originalSymbols.Symbols(ethSol.Id()), ShouldBeNil)
+	})
+	Convey("leaves out pairs of equivalent assets", t, func() {
+		mockPairD := Pair{
+			BaseAssetId:     "BTC",
+			QuoteAssetId:    "xbt",
+			ExchangeMarkets: []ExchangeMarket{{ExchangeId: "x", CurrentBid: 0.999}},
+		}
+		mockMarketWithLoop := Market{PairsById: map[string]Pair{mockPairC.Id(): mockPairC, mockPairD.Id(): mockPairD}}
+
+		canonicalMarket, originalSymbols := registry.Canonicalize(&mockMarketWithLoop)
+
+		So(canonicalMarket.PairsById, ShouldResemble, map[string]Pair{mockPairC.Id(): mockPairC})
+		So(originalSymbols.Restore(&canonicalMarket).PairsById, ShouldResemble, map[string]Pair{mockPairC.Id(): mockPairC})
+	})
+	Convey("canonical filters", t, func() {
+		actual := registry.CanonicalFilter(Filter{IncludeAssets: []string{"xbt"}, ExcludePairs: []string{"usd/XBT"}})
+
+		So(actual.IncludeAssets, ShouldResemble, []string{"BTC"})
+		So(actual.ExcludePairs, ShouldResemble, []string{"USD/BTC"})
+	})
+}
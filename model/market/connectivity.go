@@ -0,0 +1,265 @@
+package market
+
+import "sort"
+
+/**
+How the assets and pairs of a market connect to a rebase asset, within a maximum rebase path length.
+Rebase paths lead from a pair to a pair based in the rebase asset, either through the pairs quoted in each hop's base
+asset, or through the pairs based in each hop's quote asset, so pairs are reachable in the same way a rebase reaches
+them. Pairs that no path within the maximum length reaches can't be rebased and come out of a rebase with zero rates.
+*/
+type Connectivity struct {
+	RebaseAssetId string
+	// groups of assets connected by pairs in either direction, each sorted, largest first
+	Components [][]string
+	// length of the shortest rebase path of every reachable pair
+	PairDistances map[string]int
+	// fewest hops to price every reachable asset, the length of the shortest rebase path of any of its pairs
+	AssetDistances map[string]int
+	// sorted
+	UnreachableAssetIds []string
+	// sorted
+	UnreachablePairIds []string
+	// missing pairs that would make unreachable pairs reachable, the most unreachable volume first
+	Suggestions []PairSuggestion
+}
+
+/**
+Pair based in the rebase asset that the market lacks, along with the unreachable pairs that adding it would make
+reachable. Every such pair connects through the suggested quote asset, so Volume is their combined base volume in that
+asset, converted at the mids of the market's pairs along the fewest hops. Pairs whose base asset no priced pairs connect
+to the quote asset don't count towards it.
+*/
+type PairSuggestion struct {
+	BaseAssetId  string
+	QuoteAssetId string
+	// sorted
+	PairIds []string
+	Volume  float32
+}
+
+// hop distances from and to the rebase asset, following pairs from their base asset to their quote asset
+type assetDistances struct {
+	rebaseId string
+	from     map[string]int
+	to       map[string]int
+	// whether any pair is based in the rebase asset, which every rebase path ends in
+	rebasePairs bool
+}
+
+/**
+Analyzes how the market connects to the rebase asset, with rebase paths of at most maxDepth pairs, or of any length for
+a maxDepth of 0.
+*/
+func (m *Market) Connectivity(rebaseId string, maxDepth uint8) Connectivity {
+	return m.ConnectivityThrough(rebaseId, maxDepth, m)
+}
+
+/**
+Analyzes how the market connects to the rebase asset like Connectivity, with rebase paths that only go through the pairs
+of the hop market, like the hop market of a rebase holds the pairs within its liquidity limits. Pairs that aren't hops
+can still be reached, but never lead to other pairs.
+*/
+func (m *Market) ConnectivityThrough(rebaseId string, maxDepth uint8, hops *Market) Connectivity {
+	connectivity := Connectivity{
+		RebaseAssetId:  rebaseId,
+		Components:     m.Components(),
+		PairDistances:  map[string]int{},
+		AssetDistances: map[string]int{rebaseId: 0},
+	}
+	distances := hops.assetDistances(rebaseId, nil)
+	for pairId, pair := range m.PairsById {
+		distance, ok := distances.pathLength(pair)
+		if !ok || !withinDepth(distance, maxDepth) {
+			connectivity.UnreachablePairIds = append(connectivity.UnreachablePairIds, pairId)
+			continue
+		}
+		connectivity.PairDistances[pairId] = distance
+		for _, assetId := range []string{pair.BaseAssetId, pair.QuoteAssetId} {
+			if assetDistance, ok := connectivity.AssetDistances[assetId]; !ok || distance < assetDistance {
+				connectivity.AssetDistances[assetId] = distance
+			}
+		}
+	}
+	for _, component := range connectivity.Components {
+		for _, assetId := range component {
+			if _, ok := connectivity.AssetDistances[assetId]; !ok {
+				connectivity.UnreachableAssetIds = append(connectivity.UnreachableAssetIds, assetId)
+			}
+		}
+	}
+	sort.Strings(connectivity.UnreachableAssetIds)
+	sort.Strings(connectivity.UnreachablePairIds)
+	connectivity.Suggestions = m.suggestPairs(rebaseId, maxDepth, connectivity, hops)
+	return connectivity
+}
+
+/**
+Groups of assets connected by pairs in either direction, each sorted, largest first.
+*/
+func (m *Market) Components() [][]string {
+	neighborAssetIds := map[string][]string{}
+	for _, pair := range m.PairsById {
+		neighborAssetIds[pair.BaseAssetId] = append(neighborAssetIds[pair.BaseAssetId], pair.QuoteAssetId)
+		neighborAssetIds[pair.QuoteAssetId] = append(neighborAssetIds[pair.QuoteAssetId], pair.BaseAssetId)
+	}
+
+	components := [][]string{}
+	visited := map[string]bool{}
+	for assetId := range neighborAssetIds {
+		if visited[assetId] {
+			continue
+		}
+		visited[assetId] = true
+		component := []string{}
+		queue := []string{assetId}
+		for len(queue) > 0 {
+			component = append(component, queue[0])
+			for _, neighborAssetId := range neighborAssetIds[queue[0]] {
+				if !visited[neighborAssetId] {
+					visited[neighborAssetId] = true
+					queue = append(queue, neighborAssetId)
+				}
+			}
+			queue = queue[1:]
+		}
+		sort.Strings(component)
+		components = append(components, component)
+	}
+	sort.Slice(components, func(i, j int) bool {
+		if len(components[i]) != len(components[j]) {
+			return len(components[i]) > len(components[j])
+		}
+		return components[i][0] < components[j][0]
+	})
+	return components
+}
+
+// a pair based in the rebase asset for every unreachable asset, if it would make any unreachable pair reachable, those
+// that reach the most volume first
+func (m *Market) suggestPairs(rebaseId string, maxDepth uint8, connectivity Connectivity, hops *Market) []PairSuggestion {
+	suggestions := []PairSuggestion{}
+	for _, assetId := range connectivity.UnreachableAssetIds {
+		suggestedPair := Pair{BaseAssetId: rebaseId, QuoteAssetId: assetId}
+		if _, ok := m.PairsById[suggestedPair.Id()]; ok || assetId == rebaseId {
+			continue
+		}
+		distances := hops.assetDistances(rebaseId, &suggestedPair)
+		prices := m.pricesIn(assetId)
+		suggestion := PairSuggestion{BaseAssetId: rebaseId, QuoteAssetId: assetId}
+		for _, pairId := range connectivity.UnreachablePairIds {
+			pair := m.PairsById[pairId]
+			if distance, ok := distances.pathLength(pair); ok && withinDepth(distance, maxDepth) {
+				suggestion.PairIds = append(suggestion.PairIds, pairId)
+				suggestion.Volume += pair.CombinedBaseVolume() * prices[pair.BaseAssetId]
+			}
+		}
+		if len(suggestion.PairIds) > 0 {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	// unreachable assets are sorted, so suggestions that reach as much stay in the order of their quote assets
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Volume != suggestions[j].Volume {
+			return suggestions[i].Volume > suggestions[j].Volume
+		}
+		return len(suggestions[i].PairIds) > len(suggestions[j].PairIds)
+	})
+	return suggestions
+}
+
+/**
+Price in the asset of every asset that pairs with a positive mid connect to it in either direction, along the fewest
+hops. Ties between paths that are as short are broken by pair id, so the prices don't change between calls.
+*/
+func (m *Market) pricesIn(assetId string) map[string]float32 {
+	pairIdsByAsset := map[string][]string{}
+	for pairId, pair := range m.PairsById {
+		if pair.BaseVolumeWeightedSpreadAverage() > 0 {
+			pairIdsByAsset[pair.BaseAssetId] = append(pairIdsByAsset[pair.BaseAssetId], pairId)
+			pairIdsByAsset[pair.QuoteAssetId] = append(pairIdsByAsset[pair.QuoteAssetId], pairId)
+		}
+	}
+	prices := map[string]float32{assetId: 1}
+	queue := []string{assetId}
+	for len(queue) > 0 {
+		pairIds := pairIdsByAsset[queue[0]]
+		sort.Strings(pairIds)
+		for _, pairId := range pairIds {
+			pair := m.PairsById[pairId]
+			// the mid is the price of the quote asset in the base asset
+			mid := pair.BaseVolumeWeightedSpreadAverage()
+			if _, ok := prices[pair.QuoteAssetId]; !ok {
+				prices[pair.QuoteAssetId] = prices[pair.BaseAssetId] * mid
+				queue = append(queue, pair.QuoteAssetId)
+			} else if _, ok := prices[pair.BaseAssetId]; !ok {
+				prices[pair.BaseAssetId] = prices[pair.QuoteAssetId] / mid
+				queue = append(queue, pair.BaseAssetId)
+			}
+		}
+		queue = queue[1:]
+	}
+	return prices
+}
+
+// hop distances of the market's assets, as if the market also had the extra pair unless it's nil
+func (m *Market) assetDistances(rebaseId string, extraPair *Pair) assetDistances {
+	quoteAssetIds := map[string][]string{}
+	baseAssetIds := map[string][]string{}
+	distances := assetDistances{rebaseId: rebaseId}
+	addPair := func(pair Pair) {
+		quoteAssetIds[pair.BaseAssetId] = append(quoteAssetIds[pair.BaseAssetId], pair.QuoteAssetId)
+		baseAssetIds[pair.QuoteAssetId] = append(baseAssetIds[pair.QuoteAssetId], pair.BaseAssetId)
+		if pair.BaseAssetId == rebaseId {
+			distances.rebasePairs = true
+		}
+	}
+	for _, pair := range m.PairsById {
+		addPair(pair)
+	}
+	if extraPair != nil {
+		addPair(*extraPair)
+	}
+	distances.from = hopDistances(rebaseId, quoteAssetIds)
+	distances.to = hopDistances(rebaseId, baseAssetIds)
+	return distances
+}
+
+/**
+Length of the pair's shortest rebase path. Paths through the pairs quoted in the pair's base asset take the hops from the
+rebase asset to that base asset, and paths through the pairs based in its quote asset take the hops from that quote
+asset back to the rebase asset, and end in any pair based in the rebase asset.
+*/
+func (d assetDistances) pathLength(pair Pair) (int, bool) {
+	if pair.BaseAssetId == d.rebaseId {
+		return 1, true
+	}
+	length, ok := 0, false
+	if distance, fromOk := d.from[pair.BaseAssetId]; fromOk {
+		length, ok = distance+1, true
+	}
+	if distance, toOk := d.to[pair.QuoteAssetId]; toOk && d.rebasePairs && (!ok || distance+2 < length) {
+		length, ok = distance+2, true
+	}
+	return length, ok
+}
+
+// fewest hops from the asset to every asset it leads to through the neighbors
+func hopDistances(assetId string, neighborAssetIds map[string][]string) map[string]int {
+	distances := map[string]int{assetId: 0}
+	queue := []string{assetId}
+	for len(queue) > 0 {
+		for _, neighborAssetId := range neighborAssetIds[queue[0]] {
+			if _, ok := distances[neighborAssetId]; !ok {
+				distances[neighborAssetId] = distances[queue[0]] + 1
+				queue = append(queue, neighborAssetId)
+			}
+		}
+		queue = queue[1:]
+	}
+	return distances
+}
+
+func withinDepth(distance int, maxDepth uint8) bool {
+	return maxDepth == 0 || distance <= int(maxDepth)
+}
@@ -0,0 +1,113 @@
+package market
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMarket_Connectivity(t *testing.T) {
+	usdEur := mockVolumePair("USD", "EUR", 10)
+	eurGbp := mockVolumePair("EUR", "GBP", 10)
+	gbpJpy := mockVolumePair("GBP", "JPY", 10)
+	btcEth := mockVolumePair("BTC", "ETH", 5)
+	ethSol := mockVolumePair("ETH", "SOL", 20)
+
+	Convey("measures the shortest rebase path of every pair and asset", t, func() {
+		mockMarket := mockMarketOf(usdEur, eurGbp, gbpJpy)
+
+		actual := mockMarket.Connectivity("USD", 0)
+
+		So(actual.PairDistances, ShouldResemble, map[string]int{usdEur.Id(): 1, eurGbp.Id(): 2, gbpJpy.Id(): 3})
+		So(actual.AssetDistances, ShouldResemble, map[string]int{"USD": 0, "EUR": 1, "GBP": 2, "JPY": 3})
+		So(actual.UnreachableAssetIds, ShouldBeEmpty)
+		So(actual.UnreachablePairIds, ShouldBeEmpty)
+	})
+	Convey("reaches pairs through the pairs based in their quote asset", t, func() {
+		eurUsd := mockVolumePair("EUR", "USD", 10)
+		mockMarket := mockMarketOf(eurUsd, mockVolumePair("USD", "GBP", 10))
+
+		actual := mockMarket.Connectivity("USD", 0)
+
+		So(actual.PairDistances[eurUsd.Id()], ShouldEqual, 2)
+	})
+	Convey("leaves pairs beyond the maximum path length unreachable", t, func() {
+		mockMarket := mockMarketOf(usdEur, eurGbp, gbpJpy)
+
+		actual := mockMarket.Connectivity("USD", 2)
+
+		So(actual.UnreachablePairIds, ShouldResemble, []string{gbpJpy.Id()})
+		So(actual.UnreachableAssetIds, ShouldResemble, []string{"JPY"})
+	})
+	Convey("lists connected components, largest first", t, func() {
+		mockMarket := mockMarketOf(usdEur, eurGbp, gbpJpy, btcEth)
+
+		actual := mockMarket.Connectivity("USD", 0)
+
+		So(actual.Components, ShouldResemble, [][]string{{"EUR", "GBP", "JPY", "USD"}, {"BTC", "ETH"}})
+		So(actual.UnreachableAssetIds, ShouldResemble, []string{"BTC", "ETH"})
+	})
+	Convey("suggests the missing pairs that connect the most unreachable volume first, if they connect any", t, func() {
+		mockMarket := mockMarketOf(usdEur, btcEth, ethSol)
+
+		actual := mockMarket.Connectivity("USD", 0)
+
+		So(actual.Suggestions, ShouldHaveLength, 2)
+		So(actual.Suggestions[0].BaseAssetId, ShouldEqual, "USD")
+		So(actual.Suggestions[0].QuoteAssetId, ShouldEqual, "BTC")
+		So(actual.Suggestions[0].PairIds, ShouldHaveLength, 2)
+		So(actual.Suggestions[0].Volume, ShouldEqual, 25)
+		So(actual.Suggestions[1].QuoteAssetId, ShouldEqual, "ETH")
+		So(actual.Suggestions[1].PairIds, ShouldResemble, []string{ethSol.Id()})
+		So(actual.Suggestions[1].Volume, ShouldEqual, 20)
+	})
+	Convey("converts the volume a suggestion reaches into its quote asset at the market's mids", t, func() {
+		btcEth := mockVolumePair("BTC", "ETH", 5)
+		btcEth.ExchangeMarkets[0].CurrentBid, btcEth.ExchangeMarkets[0].CurrentAsk = 0.05, 0.05
+		ethSol := mockVolumePair("ETH", "SOL", 20)
+		ethSol.ExchangeMarkets[0].CurrentBid, ethSol.ExchangeMarkets[0].CurrentAsk = 0.1, 0.1
+		mockMarket := mockMarketOf(usdEur, btcEth, ethSol)
+
+		actual := mockMarket.Connectivity("USD", 0)
+
+		So(actual.Suggestions, ShouldHaveLength, 2)
+		So(actual.Suggestions[0].QuoteAssetId, ShouldEqual, "ETH")
+		So(actual.Suggestions[0].Volume, ShouldEqual, 20)
+		So(actual.Suggestions[1].QuoteAssetId, ShouldEqual, "BTC")
+		So(actual.Suggestions[1].PairIds, ShouldHaveLength, 2)
+		So(actual.Suggestions[1].Volume, ShouldAlmostEqual, 6, 0.0001)
+	})
+	Convey("only suggests pairs that reach unreachable pairs within the maximum path length", t, func() {
+		mockMarket := mockMarketOf(usdEur, btcEth, ethSol)
+
+		actual := mockMarket.Connectivity("USD", 1)
+
+		So(actual.Suggestions, ShouldBeEmpty)
+	})
+}
+
+func TestMarket_ConnectivityThrough(t *testing.T) {
+	usdEur := mockVolumePair("USD", "EUR", 10)
+	eurGbp := mockVolumePair("EUR", "GBP", 10)
+	gbpJpy := mockVolumePair("GBP", "JPY", 10)
+
+	Convey("only follows rebase paths through hops", t, func() {
+		mockMarket := mockMarketOf(usdEur, eurGbp, gbpJpy)
+		hops := mockMarketOf(usdEur, gbpJpy)
+
+		actual := mockMarket.ConnectivityThrough("USD", 0, &hops)
+
+		So(actual.PairDistances, ShouldResemble, map[string]int{usdEur.Id(): 1, eurGbp.Id(): 2})
+		So(actual.UnreachablePairIds, ShouldResemble, []string{gbpJpy.Id()})
+		So(actual.UnreachableAssetIds, ShouldResemble, []string{"JPY"})
+		So(actual.Components, ShouldResemble, [][]string{{"EUR", "GBP", "JPY", "USD"}})
+	})
+}
+
+func TestMarket_Components(t *testing.T) {
+	Convey("has no components without pairs", t, func() {
+		mockMarket := mockMarketOf()
+
+		So(mockMarket.Components(), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,205 @@
+package market
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// standard names of the CSV columns, in the order of files without a header row
+const (
+	CSVBase        = "base"
+	CSVQuote       = "quote"
+	CSVExchange    = "exchange"
+	CSVBid         = "bid"
+	CSVAsk         = "ask"
+	CSVBaseVolume  = "baseVolume"
+	CSVQuoteVolume = "quoteVolume"
+)
+
+var csvColumns = []string{CSVBase, CSVQuote, CSVExchange, CSVBid, CSVAsk, CSVBaseVolume, CSVQuoteVolume}
+
+/**
+Layout of a flat CSV file with one exchange market per row. The zero value reads and writes comma separated files with
+a header row of the standard column names. The quoteVolume column is optional.
+*/
+type CSVFormat struct {
+	// defaults to ','
+	Delimiter rune
+	// files without a header row have their columns in the standard order
+	NoHeader bool
+	// header names to use instead of the standard column names, by standard column name
+	HeaderNames map[string]string
+}
+
+func (f CSVFormat) delimiter() rune {
+	if f.Delimiter == 0 {
+		return ','
+	}
+	return f.Delimiter
+}
+
+func (f CSVFormat) headerName(column string) string {
+	if name, ok := f.HeaderNames[column]; ok {
+		return name
+	}
+	return column
+}
+
+/**
+Reads a CSV file into a market, grouping rows of the same base and quote into one pair's exchange markets.
+Header names are matched case-insensitively and columns may be in any order.
+*/
+func ReadCSV(r io.Reader, format CSVFormat) (Market, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = format.delimiter()
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	columnIndexes := map[string]int{}
+	if format.NoHeader {
+		for i, column := range csvColumns {
+			columnIndexes[column] = i
+		}
+	} else {
+		header, err := reader.Read()
+		if err != nil {
+			return Market{}, fmt.Errorf("reading CSV header: %w", err)
+		}
+		for _, column := range csvColumns {
+			for i, name := range header {
+				if strings.EqualFold(strings.TrimSpace(name), format.headerName(column)) {
+					columnIndexes[column] = i
+				}
+			}
+		}
+		for _, column := range csvColumns[:len(csvColumns)-1] {
+			if _, ok := columnIndexes[column]; !ok {
+				return Market{}, fmt.Errorf(`CSV header has no "%s" column`, format.headerName(column))
+			}
+		}
+	}
+
+	market := Market{PairsById: map[string]Pair{}}
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return market, nil
+		}
+		if err != nil {
+			return Market{}, err
+		}
+		if err := addCSVRecord(&market, record, columnIndexes); err != nil {
+			return Market{}, fmt.Errorf("CSV row %d: %w", row, err)
+		}
+	}
+}
+
+func addCSVRecord(market *Market, record []string, columnIndexes map[string]int) error {
+	field := func(column string) string {
+		if i, ok := columnIndexes[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	number := func(column string) (float32, error) {
+		value := field(column)
+		if value == "" {
+			return 0, nil
+		}
+		number, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return 0, fmt.Errorf(`invalid %s "%s"`, column, value)
+		}
+		return float32(number), nil
+	}
+
+	pair := Pair{
+		BaseAssetId:  field(CSVBase),
+		QuoteAssetId: field(CSVQuote),
+	}
+	if pair.BaseAssetId == "" || pair.QuoteAssetId == "" {
+		return fmt.Errorf("missing %s or %s", CSVBase, CSVQuote)
+	}
+	exchangeMarket := ExchangeMarket{ExchangeId: field(CSVExchange)}
+	var err error
+	for column, value := range map[string]*float32{
+		CSVBid:         &exchangeMarket.CurrentBid,
+		CSVAsk:         &exchangeMarket.CurrentAsk,
+		CSVBaseVolume:  &exchangeMarket.BaseVolume,
+		CSVQuoteVolume: &exchangeMarket.QuoteVolume,
+	} {
+		if *value, err = number(column); err != nil {
+			return err
+		}
+	}
+
+	pairId := pair.Id()
+	if existingPair, ok := market.PairsById[pairId]; ok {
+		pair = existingPair
+	}
+	pair.ExchangeMarkets = append(pair.ExchangeMarkets, exchangeMarket)
+	market.PairsById[pairId] = pair
+	return nil
+}
+
+/**
+Writes a market as CSV, one row per exchange market, ordered by pair symbol.
+The quoteVolume column is only written if any exchange market has a quote volume.
+*/
+func WriteCSV(w io.Writer, market *Market, format CSVFormat) error {
+	pairs := []Pair{}
+	withQuoteVolume := false
+	for _, pair := range market.PairsById {
+		pairs = append(pairs, pair)
+		if pair.CombinedQuoteVolume() != 0 {
+			withQuoteVolume = true
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Symbol() < pairs[j].Symbol()
+	})
+	columns := csvColumns
+	if !withQuoteVolume {
+		columns = csvColumns[:len(csvColumns)-1]
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = format.delimiter()
+	if !format.NoHeader {
+		header := []string{}
+		for _, column := range columns {
+			header = append(header, format.headerName(column))
+		}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+	}
+	for _, pair := range pairs {
+		for _, emd := range pair.ExchangeMarkets {
+			record := []string{
+				pair.BaseAssetId,
+				pair.QuoteAssetId,
+				emd.ExchangeId,
+				formatCSVNumber(emd.CurrentBid),
+				formatCSVNumber(emd.CurrentAsk),
+				formatCSVNumber(emd.BaseVolume),
+			}
+			if withQuoteVolume {
+				record = append(record, formatCSVNumber(emd.QuoteVolume))
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatCSVNumber(number float32) string {
+	return strconv.FormatFloat(float64(number), 'f', -1, 32)
+}
@@ -0,0 +1,112 @@
+package market
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadCSV(t *testing.T) {
+	usdEur := Pair{BaseAssetId: "USD", QuoteAssetId: "EUR"}
+
+	Convey("groups rows of the same pair into its exchange markets", t, func() {
+		data := "base,quote,exchange,bid,ask,baseVolume\n" +
+			"USD,EUR,a,1.12,1.13,100\n" +
+			"EUR,GBP,a,1.15,1.16,100\n" +
+			"USD,EUR,b,1.11,1.14,50\n"
+
+		market, err := ReadCSV(strings.NewReader(data), CSVFormat{})
+
+		So(err, ShouldBeNil)
+		So(market.PairsById, ShouldHaveLength, 2)
+		pair := market.PairsById[usdEur.Id()]
+		So(pair.ExchangeMarkets, ShouldResemble, []ExchangeMarket{
+			{ExchangeId: "a", CurrentBid: 1.12, CurrentAsk: 1.13, BaseVolume: 100},
+			{ExchangeId: "b", CurrentBid: 1.11, CurrentAsk: 1.14, BaseVolume: 50},
+		})
+	})
+	Convey("maps columns by their header names in any order", t, func() {
+		data := "Volume;Ask;Bid;Venue;Quote;Base;quoteVolume\n100;1.13;1.12;a;EUR;USD;89\n"
+		format := CSVFormat{
+			Delimiter:   ';',
+			HeaderNames: map[string]string{CSVBase: "Base", CSVQuote: "Quote", CSVExchange: "Venue", CSVBid: "Bid", CSVAsk: "Ask", CSVBaseVolume: "Volume"},
+		}
+
+		market, err := ReadCSV(strings.NewReader(data), format)
+
+		So(err, ShouldBeNil)
+		So(market.PairsById[usdEur.Id()].ExchangeMarkets, ShouldResemble, []ExchangeMarket{
+			{ExchangeId: "a", CurrentBid: 1.12, CurrentAsk: 1.13, BaseVolume: 100, QuoteVolume: 89},
+		})
+	})
+	Convey("takes the standard column order without a header", t, func() {
+		market, err := ReadCSV(strings.NewReader("USD,EUR,a,1.12,1.13,100\n"), CSVFormat{NoHeader: true})
+
+		So(err, ShouldBeNil)
+		So(market.PairsById[usdEur.Id()].ExchangeMarkets, ShouldHaveLength, 1)
+	})
+	Convey("invalid files", t, func() {
+		_, err := ReadCSV(strings.NewReader("base,quote,bid,ask,baseVolume\n"), CSVFormat{})
+		So(err.Error(), ShouldContainSubstring, `"exchange"`)
+
+		_, err = ReadCSV(strings.NewReader("base,quote,exchange,bid,ask,baseVolume\nUSD,EUR,a,1.12,1.13,100\nUSD,EUR,b,x,1.13,100\n"), CSVFormat{})
+		So(err.Error(), ShouldContainSubstring, "row 2")
+
+		_, err = ReadCSV(strings.NewReader("base,quote,exchange,bid,ask,baseVolume\n,EUR,a,1,1,1\n"), CSVFormat{})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestWriteCSV(t *testing.T) {
+	mockPairA := Pair{
+		BaseAssetId:  "USD",
+		QuoteAssetId: "EUR",
+		ExchangeMarkets: []ExchangeMarket{
+			{ExchangeId: "a", CurrentBid: 1.12, CurrentAsk: 1.13, BaseVolume: 100},
+			{ExchangeId: "b", CurrentBid: 1.11, CurrentAsk: 1.14, BaseVolume: 50},
+		},
+	}
+	mockPairB := Pair{
+		BaseAssetId:     "EUR",
+		QuoteAssetId:    "GBP",
+		ExchangeMarkets: []ExchangeMarket{{ExchangeId: "a", CurrentBid: 1.15, CurrentAsk: 1.16, BaseVolume: 100}},
+	}
+	mockMarket := Market{
+		PairsById: map[string]Pair{
+			mockPairA.Id(): mockPairA,
+			mockPairB.Id(): mockPairB,
+		},
+	}
+
+	Convey("writes one row per exchange market ordered by symbol", t, func() {
+		var buffer bytes.Buffer
+
+		err := WriteCSV(&buffer, &mockMarket, CSVFormat{})
+
+		So(err, ShouldBeNil)
+		So(buffer.String(), ShouldEqual, "base,quote,exchange,bid,ask,baseVolume\n"+
+			"EUR,GBP,a,1.15,1.16,100\n"+
+			"USD,EUR,a,1.12,1.13,100\n"+
+			"USD,EUR,b,1.11,1.14,50\n")
+	})
+	Convey("writes in the configured format", t, func() {
+		var buffer bytes.Buffer
+
+		err := WriteCSV(&buffer, &mockMarket, CSVFormat{Delimiter: '\t', NoHeader: true})
+
+		So(err, ShouldBeNil)
+		So(strings.Split(buffer.String(), "\n")[0], ShouldEqual, "EUR\tGBP\ta\t1.15\t1.16\t100")
+	})
+	Convey("round-trips through ReadCSV", t, func() {
+		var buffer bytes.Buffer
+		format := CSVFormat{Delimiter: ';', HeaderNames: map[string]string{CSVExchange: "venue"}}
+		So(WriteCSV(&buffer, &mockMarket, format), ShouldBeNil)
+
+		actual, err := ReadCSV(&buffer, format)
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, mockMarket)
+	})
+}
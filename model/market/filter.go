@@ -0,0 +1,73 @@
+package market
+
+/**
+Allow-lists and deny-lists for the assets, pairs and exchanges in a market.
+Empty allow-lists allow everything. Deny-lists take precedence over allow-lists.
+Pairs are listed by symbol, e.g. "ETH/USD".
+*/
+type Filter struct {
+	IncludeAssets    []string `json:"includeAssets,omitempty"`
+	ExcludeAssets    []string `json:"excludeAssets,omitempty"`
+	IncludePairs     []string `json:"includePairs,omitempty"`
+	ExcludePairs     []string `json:"excludePairs,omitempty"`
+	IncludeExchanges []string `json:"includeExchanges,omitempty"`
+	ExcludeExchanges []string `json:"excludeExchanges,omitempty"`
+}
+
+func (f *Filter) AllowsAsset(assetId string) bool {
+	return allows(f.IncludeAssets, f.ExcludeAssets, assetId)
+}
+
+func (f *Filter) AllowsPair(pair Pair) bool {
+	return f.AllowsAsset(pair.BaseAssetId) &&
+		f.AllowsAsset(pair.QuoteAssetId) &&
+		allows(f.IncludePairs, f.ExcludePairs, pair.Symbol())
+}
+
+func (f *Filter) AllowsExchange(exchangeId string) bool {
+	return allows(f.IncludeExchanges, f.ExcludeExchanges, exchangeId)
+}
+
+/**
+Market with only the pairs and exchange markets the filter allows.
+Pairs that are left without any exchange markets are dropped.
+*/
+func (m *Market) Filter(f Filter) Market {
+	filteredMarket := Market{PairsById: map[string]Pair{}}
+	for pairId, pair := range m.PairsById {
+		if !f.AllowsPair(pair) {
+			continue
+		}
+		filteredPair := Pair{
+			BaseAssetId:  pair.BaseAssetId,
+			QuoteAssetId: pair.QuoteAssetId,
+		}
+		for _, emd := range pair.ExchangeMarkets {
+			if f.AllowsExchange(emd.ExchangeId) {
+				filteredPair.ExchangeMarkets = append(filteredPair.ExchangeMarkets, emd)
+			}
+		}
+		if len(pair.ExchangeMarkets) > 0 && len(filteredPair.ExchangeMarkets) == 0 {
+			continue
+		}
+		filteredMarket.PairsById[pairId] = filteredPair
+	}
+	return filteredMarket
+}
+
+func allows(includes []string, excludes []string, id string) bool {
+	for _, exclude := range excludes {
+		if exclude == id {
+			return false
+		}
+	}
+	if len(includes) == 0 {
+		return true
+	}
+	for _, include := range includes {
+		if include == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package market
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMarket_Filter(t *testing.T) {
+	mockPairA := Pair{
+		BaseAssetId:  "USD",
+		QuoteAssetId: "EUR",
+		ExchangeMarkets: []ExchangeMarket{
+			{
+				ExchangeId: "x",
+				CurrentBid: 1,
+			},
+			{
+				ExchangeId: "y",
+				CurrentBid: 2,
+			},
+		},
+	}
+	mockPairB := Pair{
+		BaseAssetId:  "EUR",
+		QuoteAssetId: "GBP",
+		ExchangeMarkets: []ExchangeMarket{
+			{
+				ExchangeId: "y",
+				CurrentBid: 3,
+			},
+		},
+	}
+	mockMarket := Market{
+		PairsById: map[string]Pair{
+			mockPairA.Id(): mockPairA,
+			mockPairB.Id(): mockPairB,
+		},
+	}
+
+	Convey("empty filter keeps the whole market", t, func() {
+		actual := mockMarket.Filter(Filter{})
+
+		So(actual, ShouldResemble, mockMarket)
+	})
+	Convey("excluded assets drop every pair they're part of", t, func() {
+		actual := mockMarket.Filter(Filter{ExcludeAssets: []string{"GBP"}})
+
+		So(actual.PairsById, ShouldContainKey, mockPairA.Id())
+		So(actual.PairsById, ShouldNotContainKey, mockPairB.Id())
+	})
+	Convey("included assets drop pairs with any other asset", t, func() {
+		actual := mockMarket.Filter(Filter{IncludeAssets: []string{"EUR", "GBP"}})
+
+		So(actual.PairsById, ShouldNotContainKey, mockPairA.Id())
+		So(actual.PairsById, ShouldContainKey, mockPairB.Id())
+	})
+	Convey("pairs are filtered by symbol", t, func() {
+		actual := mockMarket.Filter(Filter{IncludePairs: []string{"USD/EUR", "EUR/GBP"}, ExcludePairs: []string{"EUR/GBP"}})
+
+		So(actual.PairsById, ShouldContainKey, mockPairA.Id())
+		So(actual.PairsById, ShouldNotContainKey, mockPairB.Id())
+	})
+	Convey("excluded exchanges drop their exchange markets", t, func() {
+		actual := mockMarket.Filter(Filter{ExcludeExchanges: []string{"y"}})
+
+		So(actual.PairsById[mockPairA.Id()].ExchangeMarkets, ShouldResemble, mockPairA.ExchangeMarkets[:1])
+		So(actual.PairsById, ShouldNotContainKey, mockPairB.Id())
+	})
+	Convey("included exchanges drop exchange markets of other exchanges", t, func() {
+		actual := mockMarket.Filter(Filter{IncludeExchanges: []string{"y"}})
+
+		So(actual.PairsById[mockPairA.Id()].ExchangeMarkets, ShouldResemble, mockPairA.ExchangeMarkets[1:])
+		So(actual.PairsById[mockPairB.Id()], ShouldResemble, mockPairB)
+	})
+}
@@ -0,0 +1,237 @@
+package market
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// edge labels of a graph
+const (
+	GraphMid    = "mid"
+	GraphSpread = "spread"
+	GraphVolume = "volume"
+)
+
+// fill colors of the nodes of the first connected components, later components are gray
+var graphColors = []string{"#8dd3c7", "#ffffb3", "#bebada", "#fb8072", "#80b1d3", "#fdb462", "#b3de69", "#fccde5"}
+
+const (
+	graphOtherColor     = "#d9d9d9"
+	graphHighlightColor = "#e41a1c"
+)
+
+/**
+Nodes and edges of a market, for visualization. Marshaled as JSON, it's the common nodes/edges format of graph libraries.
+*/
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+type GraphNode struct {
+	// asset id, or the symbol of a pair in a graph of pairs
+	Id string `json:"id"`
+	// index of the node's connected component, largest first
+	Component   int  `json:"component"`
+	Highlighted bool `json:"highlighted,omitempty"`
+}
+
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	// pair from whose base asset to whose quote asset the edge leads, the target pair in a graph of pairs
+	PairId      string `json:"pairId"`
+	Label       string `json:"label,omitempty"`
+	Highlighted bool   `json:"highlighted,omitempty"`
+}
+
+/**
+What graphs of markets show. Labels are any of GraphMid, GraphSpread and GraphVolume, shown in that order on every edge.
+Highlighted pairs, e.g. those of the rebase paths of a pair, are drawn along with the assets they connect.
+*/
+type GraphOptions struct {
+	Labels    []string
+	Highlight map[string]bool
+}
+
+/**
+Graph of the market's assets, with an edge from every pair's base asset to its quote asset.
+*/
+func (m *Market) Graph(options GraphOptions) Graph {
+	components := m.Components()
+	graph := Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	highlightedAssetIds := map[string]bool{}
+	for _, pairId := range sortedPairIds(m.PairsById) {
+		pair := m.PairsById[pairId]
+		graph.Edges = append(graph.Edges, GraphEdge{
+			Source:      pair.BaseAssetId,
+			Target:      pair.QuoteAssetId,
+			PairId:      pairId,
+			Label:       options.label(pair),
+			Highlighted: options.Highlight[pairId],
+		})
+		if options.Highlight[pairId] {
+			highlightedAssetIds[pair.BaseAssetId] = true
+			highlightedAssetIds[pair.QuoteAssetId] = true
+		}
+	}
+	for i, component := range components {
+		for _, assetId := range component {
+			graph.Nodes = append(graph.Nodes, GraphNode{
+				Id:          assetId,
+				Component:   i,
+				Highlighted: highlightedAssetIds[assetId],
+			})
+		}
+	}
+	return graph
+}
+
+/**
+Graph of the market's pairs, named by symbol, with an edge from every pair to the pairs that continue rebase paths in its
+quote asset, as given by rebaseNeighbors. Highlighted pairs are drawn along with the edges between them.
+*/
+func (m *Market) NeighborGraph(rebaseNeighbors map[string]Neighbors, options GraphOptions) Graph {
+	components := componentIndexes(m.Components())
+	graph := Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	pairIds := sortedPairIds(m.PairsById)
+	for _, pairId := range pairIds {
+		pair := m.PairsById[pairId]
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			Id:          pair.Symbol(),
+			Component:   components[pair.BaseAssetId],
+			Highlighted: options.Highlight[pairId],
+		})
+	}
+	for _, pairId := range pairIds {
+		pair := m.PairsById[pairId]
+		quoteNeighborIds := append([]string{}, rebaseNeighbors[pairId].Quote...)
+		sort.Strings(quoteNeighborIds)
+		for _, neighborId := range quoteNeighborIds {
+			neighbor, ok := m.PairsById[neighborId]
+			if !ok {
+				continue
+			}
+			graph.Edges = append(graph.Edges, GraphEdge{
+				Source:      pair.Symbol(),
+				Target:      neighbor.Symbol(),
+				PairId:      neighborId,
+				Label:       options.label(neighbor),
+				Highlighted: options.Highlight[pairId] && options.Highlight[neighborId],
+			})
+		}
+	}
+	return graph
+}
+
+// label of a pair's edge, empty without labels
+func (o GraphOptions) label(pair Pair) string {
+	var parts []string
+	for _, label := range []string{GraphMid, GraphSpread, GraphVolume} {
+		if !containsString(o.Labels, label) {
+			continue
+		}
+		switch label {
+		case GraphMid:
+			parts = append(parts, "mid "+formatGraphNumber(pair.BaseVolumeWeightedSpreadAverage()))
+		case GraphSpread:
+			parts = append(parts, "spread "+formatGraphNumber(pair.BaseVolumeWeightedRelativeSpread()))
+		case GraphVolume:
+			parts = append(parts, "volume "+formatGraphNumber(pair.CombinedBaseVolume()))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+/**
+Writes a graph in the Graphviz DOT language, with nodes filled by connected component and highlighted nodes and edges
+drawn thick and red, e.g. to render with `dot -Tsvg`.
+*/
+func WriteDOT(w io.Writer, graph Graph) error {
+	var dot strings.Builder
+	dot.WriteString("digraph market {\n")
+	dot.WriteString("  rankdir=LR;\n")
+	dot.WriteString("  node [shape=ellipse, style=filled];\n")
+	for _, node := range graph.Nodes {
+		attributes := []string{"fillcolor=" + quoteDOT(ComponentColor(node.Component))}
+		if node.Highlighted {
+			attributes = append(attributes, "color="+quoteDOT(graphHighlightColor), "penwidth=3")
+		}
+		fmt.Fprintf(&dot, "  %s [%s];\n", quoteDOT(node.Id), strings.Join(attributes, ", "))
+	}
+	for _, edge := range graph.Edges {
+		var attributes []string
+		if edge.Label != "" {
+			attributes = append(attributes, "label="+quoteDOT(edge.Label))
+		}
+		if edge.Highlighted {
+			attributes = append(attributes, "color="+quoteDOT(graphHighlightColor), "penwidth=3")
+		}
+		fmt.Fprintf(&dot, "  %s -> %s", quoteDOT(edge.Source), quoteDOT(edge.Target))
+		if len(attributes) > 0 {
+			fmt.Fprintf(&dot, " [%s]", strings.Join(attributes, ", "))
+		}
+		dot.WriteString(";\n")
+	}
+	dot.WriteString("}\n")
+	_, err := io.WriteString(w, dot.String())
+	return err
+}
+
+// component index of every asset
+func componentIndexes(components [][]string) map[string]int {
+	indexes := map[string]int{}
+	for i, component := range components {
+		for _, assetId := range component {
+			indexes[assetId] = i
+		}
+	}
+	return indexes
+}
+
+/**
+Fill color of the nodes of a connected component, as WriteDOT draws them.
+*/
+func ComponentColor(component int) string {
+	if component < len(graphColors) {
+		return graphColors[component]
+	}
+	return graphOtherColor
+}
+
+// double quoted DOT id, with quotes and backslashes escaped and newlines as DOT line breaks
+func quoteDOT(id string) string {
+	id = strings.ReplaceAll(id, `\`, `\\`)
+	id = strings.ReplaceAll(id, `"`, `\"`)
+	id = strings.ReplaceAll(id, "\n", `\n`)
+	return `"` + id + `"`
+}
+
+func formatGraphNumber(number float32) string {
+	return strconv.FormatFloat(float64(number), 'g', 6, 32)
+}
+
+func sortedPairIds(pairsById map[string]Pair) []string {
+	pairIds := make([]string, 0, len(pairsById))
+	for pairId := range pairsById {
+		pairIds = append(pairIds, pairId)
+	}
+	sort.Slice(pairIds, func(i, j int) bool {
+		pairI := pairsById[pairIds[i]]
+		pairJ := pairsById[pairIds[j]]
+		return pairI.Symbol() < pairJ.Symbol()
+	})
+	return pairIds
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
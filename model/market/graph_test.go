@@ -0,0 +1,89 @@
+package market
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMarket_Graph(t *testing.T) {
+	usdEur := mockVolumePair("USD", "EUR", 10)
+	eurGbp := mockVolumePair("EUR", "GBP", 20)
+	btcEth := mockVolumePair("BTC", "ETH", 5)
+
+	Convey("has a node for every asset and an edge for every pair", t, func() {
+		mockMarket := mockMarketOf(usdEur, eurGbp, btcEth)
+
+		actual := mockMarket.Graph(GraphOptions{})
+
+		So(actual.Nodes, ShouldResemble, []GraphNode{
+			{Id: "EUR", Component: 0},
+			{Id: "GBP", Component: 0},
+			{Id: "USD", Component: 0},
+			{Id: "BTC", Component: 1},
+			{Id: "ETH", Component: 1},
+		})
+		So(actual.Edges, ShouldResemble, []GraphEdge{
+			{Source: "BTC", Target: "ETH", PairId: btcEth.Id()},
+			{Source: "EUR", Target: "GBP", PairId: eurGbp.Id()},
+			{Source: "USD", Target: "EUR", PairId: usdEur.Id()},
+		})
+	})
+	Convey("labels edges and highlights pairs along with their assets", t, func() {
+		mockMarket := mockMarketOf(usdEur, eurGbp)
+
+		actual := mockMarket.Graph(GraphOptions{
+			Labels:    []string{GraphVolume, GraphMid},
+			Highlight: map[string]bool{usdEur.Id(): true},
+		})
+
+		So(actual.Edges[1].Label, ShouldEqual, "mid 1\nvolume 10")
+		So(actual.Edges[1].Highlighted, ShouldBeTrue)
+		So(actual.Edges[0].Highlighted, ShouldBeFalse)
+		So(actual.Nodes[0].Highlighted, ShouldBeTrue)
+		So(actual.Nodes[1].Highlighted, ShouldBeFalse)
+	})
+}
+
+func TestMarket_NeighborGraph(t *testing.T) {
+	Convey("has a node for every pair and an edge to every pair that continues its paths", t, func() {
+		usdEur := mockVolumePair("USD", "EUR", 10)
+		eurGbp := mockVolumePair("EUR", "GBP", 20)
+		mockMarket := mockMarketOf(usdEur, eurGbp)
+
+		actual := mockMarket.NeighborGraph(mockMarket.RebaseNeighbors(), GraphOptions{
+			Highlight: map[string]bool{usdEur.Id(): true, eurGbp.Id(): true},
+		})
+
+		So(actual.Nodes, ShouldResemble, []GraphNode{
+			{Id: "EUR/GBP", Component: 0, Highlighted: true},
+			{Id: "USD/EUR", Component: 0, Highlighted: true},
+		})
+		So(actual.Edges, ShouldResemble, []GraphEdge{
+			{Source: "USD/EUR", Target: "EUR/GBP", PairId: eurGbp.Id(), Highlighted: true},
+		})
+	})
+}
+
+func TestWriteDOT(t *testing.T) {
+	Convey("writes nodes filled by component and highlighted edges", t, func() {
+		graph := Graph{
+			Nodes: []GraphNode{{Id: "USD", Highlighted: true}, {Id: `E"UR`, Component: 9}},
+			Edges: []GraphEdge{{Source: "USD", Target: `E"UR`, Label: "mid 1\nvolume 10", Highlighted: true}},
+		}
+		var actual bytes.Buffer
+
+		err := WriteDOT(&actual, graph)
+
+		So(err, ShouldBeNil)
+		So(actual.String(), ShouldEqual, `digraph market {
+  rankdir=LR;
+  node [shape=ellipse, style=filled];
+  "USD" [fillcolor="#8dd3c7", color="#e41a1c", penwidth=3];
+  "E\"UR" [fillcolor="#d9d9d9"];
+  "USD" -> "E\"UR" [label="mid 1\nvolume 10", color="#e41a1c", penwidth=3];
+}
+`)
+	})
+}
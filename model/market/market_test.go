@@ -5,6 +5,22 @@ import (
 	"testing"
 )
 
+func mockVolumePair(baseAssetId string, quoteAssetId string, baseVolume float32) Pair {
+	return Pair{
+		BaseAssetId:     baseAssetId,
+		QuoteAssetId:    quoteAssetId,
+		ExchangeMarkets: []ExchangeMarket{{CurrentBid: 1, CurrentAsk: 1, BaseVolume: baseVolume}},
+	}
+}
+
+func mockMarketOf(pairs ...Pair) Market {
+	market := Market{PairsById: map[string]Pair{}}
+	for _, pair := range pairs {
+		market.PairsById[pair.Id()] = pair
+	}
+	return market
+}
+
 func TestMarket_RebaseNeighbors(t *testing.T) {
 	Convey("adjacent pairs see each other as neighbors", t, func() {
 		mockPairA := Pair{
@@ -0,0 +1,142 @@
+package market
+
+/**
+Rebase neighbors of a changing set of pairs, kept in sync with every change instead of being recomputed from scratch.
+Only hops, pairs that can be rebased through, are listed as neighbors of other pairs, so with every pair a hop it holds
+what Market.RebaseNeighbors returns for the same pairs, neighbors possibly listed in a different order.
+A NeighborIndex isn't safe for concurrent use.
+*/
+type NeighborIndex struct {
+	neighbors map[string]Neighbors
+	// only the assets of the pairs, their market data doesn't matter
+	pairsById map[string]Pair
+	hops      map[string]bool
+	// ids of the pairs based and quoted in every asset
+	baseAssetPairIds  map[string]map[string]bool
+	quoteAssetPairIds map[string]map[string]bool
+}
+
+func NewNeighborIndex() *NeighborIndex {
+	return &NeighborIndex{
+		neighbors:         map[string]Neighbors{},
+		pairsById:         map[string]Pair{},
+		hops:              map[string]bool{},
+		baseAssetPairIds:  map[string]map[string]bool{},
+		quoteAssetPairIds: map[string]map[string]bool{},
+	}
+}
+
+/**
+Adds the pair, or replaces the pair with the same id, as a hop or only with neighbors of its own.
+*/
+func (x *NeighborIndex) Connect(pair Pair, hop bool) {
+	pairId := pair.Id()
+	if _, ok := x.pairsById[pairId]; ok {
+		x.Disconnect(pairId)
+	}
+	x.pairsById[pairId] = Pair{BaseAssetId: pair.BaseAssetId, QuoteAssetId: pair.QuoteAssetId}
+	x.hops[pairId] = hop
+	addToSet(x.baseAssetPairIds, pair.BaseAssetId, pairId)
+	addToSet(x.quoteAssetPairIds, pair.QuoteAssetId, pairId)
+
+	neighbors := Neighbors{Base: []string{}, Quote: []string{}}
+	for otherPairId := range x.quoteAssetPairIds[pair.BaseAssetId] {
+		if x.hops[otherPairId] {
+			neighbors.Base = append(neighbors.Base, otherPairId)
+		}
+		// a pair whose base is its quote is its own neighbor only once in each direction
+		if hop && otherPairId != pairId {
+			otherNeighbors := x.neighbors[otherPairId]
+			otherNeighbors.Quote = append(otherNeighbors.Quote, pairId)
+			x.neighbors[otherPairId] = otherNeighbors
+		}
+	}
+	for otherPairId := range x.baseAssetPairIds[pair.QuoteAssetId] {
+		if x.hops[otherPairId] {
+			neighbors.Quote = append(neighbors.Quote, otherPairId)
+		}
+		if hop && otherPairId != pairId {
+			otherNeighbors := x.neighbors[otherPairId]
+			otherNeighbors.Base = append(otherNeighbors.Base, pairId)
+			x.neighbors[otherPairId] = otherNeighbors
+		}
+	}
+	x.neighbors[pairId] = neighbors
+}
+
+/**
+Removes the pair from its neighbors' neighbors and drops its own. Unknown pairs are ignored.
+*/
+func (x *NeighborIndex) Disconnect(pairId string) {
+	pair, ok := x.pairsById[pairId]
+	if !ok {
+		return
+	}
+	if x.hops[pairId] {
+		for otherPairId := range x.quoteAssetPairIds[pair.BaseAssetId] {
+			otherNeighbors := x.neighbors[otherPairId]
+			x.neighbors[otherPairId] = Neighbors{Base: otherNeighbors.Base, Quote: without(otherNeighbors.Quote, pairId)}
+		}
+		for otherPairId := range x.baseAssetPairIds[pair.QuoteAssetId] {
+			otherNeighbors := x.neighbors[otherPairId]
+			x.neighbors[otherPairId] = Neighbors{Base: without(otherNeighbors.Base, pairId), Quote: otherNeighbors.Quote}
+		}
+	}
+	delete(x.neighbors, pairId)
+	delete(x.pairsById, pairId)
+	delete(x.hops, pairId)
+	removeFromSet(x.baseAssetPairIds, pair.BaseAssetId, pairId)
+	removeFromSet(x.quoteAssetPairIds, pair.QuoteAssetId, pairId)
+}
+
+/**
+Rebase neighbors of every pair. The index keeps changing the map, so callers must copy it to keep it, and must not
+modify it.
+*/
+func (x *NeighborIndex) Neighbors() map[string]Neighbors {
+	return x.neighbors
+}
+
+/**
+Pairs that list the pair as a neighbor, those whose Base neighbors include it as Base and those whose Quote neighbors
+include it as Quote. Only hops are neighbors of other pairs, so other pairs and unknown pairs have none. Looked up in
+the pairs by asset that every change keeps in sync, it costs no more than the number of pairs found.
+*/
+func (x *NeighborIndex) PreviousNeighbors(pairId string) Neighbors {
+	previous := Neighbors{Base: []string{}, Quote: []string{}}
+	pair, ok := x.pairsById[pairId]
+	if !ok || !x.hops[pairId] {
+		return previous
+	}
+	for otherPairId := range x.baseAssetPairIds[pair.QuoteAssetId] {
+		previous.Base = append(previous.Base, otherPairId)
+	}
+	for otherPairId := range x.quoteAssetPairIds[pair.BaseAssetId] {
+		previous.Quote = append(previous.Quote, otherPairId)
+	}
+	return previous
+}
+
+func addToSet(sets map[string]map[string]bool, key string, id string) {
+	if sets[key] == nil {
+		sets[key] = map[string]bool{}
+	}
+	sets[key][id] = true
+}
+
+func removeFromSet(sets map[string]map[string]bool, key string, id string) {
+	delete(sets[key], id)
+	if len(sets[key]) == 0 {
+		delete(sets, key)
+	}
+}
+
+func without(ids []string, id string) []string {
+	result := []string{}
+	for _, otherId := range ids {
+		if otherId != id {
+			result = append(result, otherId)
+		}
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+package market
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestNeighborIndex(t *testing.T) {
+	Convey("holds the rebase neighbors of its pairs through every change", t, func() {
+		mockPairA, mockPairB, mockPairC := mockSharedPairs()
+		mockPairLoop := Pair{BaseAssetId: "2", QuoteAssetId: "2"}
+		index := NewNeighborIndex()
+		market := Market{PairsById: map[string]Pair{}}
+
+		for _, pair := range []Pair{mockPairA, mockPairB, mockPairC, mockPairLoop, mockPairA} {
+			index.Connect(pair, true)
+			market.PairsById[pair.Id()] = pair
+			So(sortedNeighbors(index.Neighbors()), ShouldResemble, sortedNeighbors(market.RebaseNeighbors()))
+		}
+		for _, pair := range []Pair{mockPairB, mockPairLoop, mockPairA, mockPairA} {
+			index.Disconnect(pair.Id())
+			delete(market.PairsById, pair.Id())
+			So(sortedNeighbors(index.Neighbors()), ShouldResemble, sortedNeighbors(market.RebaseNeighbors()))
+		}
+	})
+	Convey("lists the pairs that have each pair as a neighbor through every change", t, func() {
+		mockPairA, mockPairB, mockPairC := mockSharedPairs()
+		mockPairLoop := Pair{BaseAssetId: "2", QuoteAssetId: "2"}
+		index := NewNeighborIndex()
+		// the pairs whose neighbors include each pair, reversed from all neighbors
+		reversed := func() map[string]Neighbors {
+			previous := map[string]Neighbors{}
+			for pairId := range index.Neighbors() {
+				previous[pairId] = Neighbors{Base: []string{}, Quote: []string{}}
+			}
+			for pairId, neighbors := range index.Neighbors() {
+				for _, neighborId := range neighbors.Base {
+					previous[neighborId] = Neighbors{Base: append(previous[neighborId].Base, pairId), Quote: previous[neighborId].Quote}
+				}
+				for _, neighborId := range neighbors.Quote {
+					previous[neighborId] = Neighbors{Base: previous[neighborId].Base, Quote: append(previous[neighborId].Quote, pairId)}
+				}
+			}
+			return sortedNeighbors(previous)
+		}
+		previousNeighbors := func() map[string]Neighbors {
+			previous := map[string]Neighbors{}
+			for pairId := range index.Neighbors() {
+				previous[pairId] = index.PreviousNeighbors(pairId)
+			}
+			return sortedNeighbors(previous)
+		}
+
+		index.Connect(mockPairA, true)
+		index.Connect(mockPairB, false)
+		index.Connect(mockPairC, true)
+		index.Connect(mockPairLoop, true)
+		So(previousNeighbors(), ShouldResemble, reversed())
+		So(index.PreviousNeighbors(mockPairB.Id()), ShouldResemble, Neighbors{Base: []string{}, Quote: []string{}})
+
+		index.Connect(mockPairB, true)
+		So(previousNeighbors(), ShouldResemble, reversed())
+		index.Disconnect(mockPairLoop.Id())
+		So(previousNeighbors(), ShouldResemble, reversed())
+		index.Disconnect(mockPairA.Id())
+		So(previousNeighbors(), ShouldResemble, reversed())
+		So(index.PreviousNeighbors(mockPairA.Id()), ShouldResemble, Neighbors{Base: []string{}, Quote: []string{}})
+	})
+	Convey("only lists hops as neighbors of other pairs", t, func() {
+		mockPairA, mockPairB, mockPairC := mockSharedPairs()
+		index := NewNeighborIndex()
+		index.Connect(mockPairA, true)
+		index.Connect(mockPairB, false)
+		index.Connect(mockPairC, true)
+
+		So(sortedNeighbors(index.Neighbors()), ShouldResemble, map[string]Neighbors{
+			mockPairA.Id(): {Base: []string{mockPairC.Id()}, Quote: []string{}},
+			mockPairB.Id(): {Base: []string{mockPairA.Id()}, Quote: []string{mockPairC.Id()}},
+			mockPairC.Id(): {Base: []string{}, Quote: []string{mockPairA.Id()}},
+		})
+
+		index.Connect(mockPairB, true)
+		index.Connect(mockPairA, false)
+
+		So(sortedNeighbors(index.Neighbors()), ShouldResemble, map[string]Neighbors{
+			mockPairA.Id(): {Base: []string{mockPairC.Id()}, Quote: []string{mockPairB.Id()}},
+			mockPairB.Id(): {Base: []string{}, Quote: []string{mockPairC.Id()}},
+			mockPairC.Id(): {Base: []string{mockPairB.Id()}, Quote: []string{}},
+		})
+	})
+}
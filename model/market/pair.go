@@ -19,16 +19,26 @@ type Pair struct {
 Exchange-specific market data.
 */
 type ExchangeMarket struct {
+	// optional, identifies the exchange the market data comes from
+	ExchangeId string  `json:"exchangeId,omitempty"`
 	CurrentBid float32 `json:"currentBid"`
 	CurrentAsk float32 `json:"currentAsk"`
 	BaseVolume float32 `json:"baseVolume"`
+	// optional, zero when the exchange doesn't report volume in the quote asset
+	QuoteVolume float32 `json:"quoteVolume,omitempty"`
 }
 
-func (p *Pair) Id() string {
-	idString := fmt.Sprintf("%s/%s", p.BaseAssetId, p.QuoteAssetId)
+func (em *ExchangeMarket) SpreadAverage() float32 {
+	return (em.CurrentBid + em.CurrentAsk) / 2
+}
+
+func (p *Pair) Symbol() string {
+	return fmt.Sprintf("%s/%s", p.BaseAssetId, p.QuoteAssetId)
+}
 
+func (p *Pair) Id() string {
 	hash := sha1.New()
-	hash.Write([]byte(idString))
+	hash.Write([]byte(p.Symbol()))
 	result := hash.Sum(nil)
 
 	return hex.EncodeToString(result)
@@ -42,6 +52,14 @@ func (p *Pair) CombinedBaseVolume() float32 {
 	return sum
 }
 
+func (p *Pair) CombinedQuoteVolume() float32 {
+	var sum float32 = 0
+	for _, emd := range p.ExchangeMarkets {
+		sum += emd.QuoteVolume
+	}
+	return sum
+}
+
 func (p *Pair) BaseVolumeWeightedCurrentBidSum() float32 {
 	var sum float32 = 0
 	for _, emd := range p.ExchangeMarkets {
@@ -67,3 +85,13 @@ func (p *Pair) BaseVolumeWeightedSpreadAverage() float32 {
 		return weightedAverage
 	}
 }
+
+func (p *Pair) BaseVolumeWeightedRelativeSpread() float32 {
+	bidSum := p.BaseVolumeWeightedCurrentBidSum()
+	askSum := p.BaseVolumeWeightedCurrentAskSum()
+	if bidSum+askSum == float32(0) {
+		return float32(0)
+	} else {
+		return (askSum - bidSum) / ((bidSum + askSum) / 2)
+	}
+}
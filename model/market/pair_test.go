@@ -9,14 +9,16 @@ import (
 var mockPair = Pair{
 	ExchangeMarkets: []ExchangeMarket{
 		{
-			BaseVolume: 1.5,
-			CurrentBid: 100.7,
-			CurrentAsk: 103.5,
+			BaseVolume:  1.5,
+			QuoteVolume: 0.0147,
+			CurrentBid:  100.7,
+			CurrentAsk:  103.5,
 		},
 		{
-			BaseVolume: 3,
-			CurrentBid: 150.1,
-			CurrentAsk: 155.2,
+			BaseVolume:  3,
+			QuoteVolume: 0.0196,
+			CurrentBid:  150.1,
+			CurrentAsk:  155.2,
 		},
 	},
 }
@@ -32,6 +34,28 @@ func TestCombinedBaseVolume(t *testing.T) {
 	})
 }
 
+func TestCombinedQuoteVolume(t *testing.T) {
+	Convey("works as expected for basic mock pair", t, func() {
+		expected := mockPair.ExchangeMarkets[0].QuoteVolume +
+			mockPair.ExchangeMarkets[1].QuoteVolume
+
+		actual := mockPair.CombinedQuoteVolume()
+
+		So(actual, ShouldEqual, expected)
+	})
+}
+
+func TestSpreadAverage(t *testing.T) {
+	Convey("works as expected for basic mock exchange market", t, func() {
+		expected := (mockPair.ExchangeMarkets[0].CurrentBid +
+			mockPair.ExchangeMarkets[0].CurrentAsk) / 2
+
+		actual := mockPair.ExchangeMarkets[0].SpreadAverage()
+
+		So(actual, ShouldEqual, expected)
+	})
+}
+
 func TestBaseVolumeWeightedCurrentBidSum(t *testing.T) {
 	Convey("works as expected for basic mock pair", t, func() {
 		expected := mockPair.ExchangeMarkets[0].CurrentBid*
@@ -83,3 +107,30 @@ func TestBaseVolumeWeightedSpreadAverage(t *testing.T) {
 		So(actual, ShouldEqual, expected)
 	})
 }
+
+func TestBaseVolumeWeightedRelativeSpread(t *testing.T) {
+	Convey("works as expected for basic mock pair", t, func() {
+		expected := (mockPair.BaseVolumeWeightedCurrentAskSum() -
+			mockPair.BaseVolumeWeightedCurrentBidSum()) /
+			((mockPair.BaseVolumeWeightedCurrentAskSum() +
+				mockPair.BaseVolumeWeightedCurrentBidSum()) /
+				2)
+
+		actual := mockPair.BaseVolumeWeightedRelativeSpread()
+
+		So(actual, ShouldEqual, expected)
+	})
+	Convey("combined base volume of pair is zero", t, func() {
+		mockPair := Pair{
+			ExchangeMarkets: []ExchangeMarket{
+				{
+					BaseVolume: float32(0),
+				},
+			},
+		}
+		expected := float32(0)
+		actual := mockPair.BaseVolumeWeightedRelativeSpread()
+
+		So(actual, ShouldEqual, expected)
+	})
+}
@@ -0,0 +1,117 @@
+package market
+
+import (
+	"sync"
+)
+
+/**
+Market that's safe to read and update from many goroutines at once, e.g. by several goroutines ingesting feeds.
+Its rebase neighbors are kept in sync with every change instead of being recomputed from scratch.
+Pairs it returns share their exchange markets with the shared market and must not be modified.
+*/
+type SharedMarket struct {
+	mutex     sync.RWMutex
+	pairsById map[string]Pair
+	neighbors *NeighborIndex
+}
+
+// copies the pairs of the given market, if any
+func NewSharedMarket(market *Market) *SharedMarket {
+	sharedMarket := SharedMarket{
+		pairsById: map[string]Pair{},
+		neighbors: NewNeighborIndex(),
+	}
+	if market != nil {
+		for _, pair := range market.PairsById {
+			sharedMarket.upsert(pair)
+		}
+	}
+	return &sharedMarket
+}
+
+// adds the pair, or replaces the pair with the same id
+func (s *SharedMarket) Upsert(pair Pair) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.upsert(pair)
+}
+
+// removes the pair and returns whether it was there
+func (s *SharedMarket) Remove(pairId string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.pairsById[pairId]; !ok {
+		return false
+	}
+	s.neighbors.Disconnect(pairId)
+	delete(s.pairsById, pairId)
+	return true
+}
+
+func (s *SharedMarket) Get(pairId string) (Pair, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	pair, ok := s.pairsById[pairId]
+	return pair, ok
+}
+
+func (s *SharedMarket) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.pairsById)
+}
+
+/**
+Copy of the market as it is now. Later changes to the shared market don't affect it.
+*/
+func (s *SharedMarket) Snapshot() Market {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	snapshot := Market{PairsById: make(map[string]Pair, len(s.pairsById))}
+	for pairId, pair := range s.pairsById {
+		snapshot.PairsById[pairId] = pair
+	}
+	return snapshot
+}
+
+/**
+Calls f for every pair until it returns false. Iterates over a snapshot, so f may change the shared market.
+*/
+func (s *SharedMarket) Range(f func(pairId string, pair Pair) bool) {
+	snapshot := s.Snapshot()
+	for pairId, pair := range snapshot.PairsById {
+		if !f(pairId, pair) {
+			return
+		}
+	}
+}
+
+/**
+Copy of the rebase neighbors of every pair, like Market.RebaseNeighbors returns for a snapshot.
+Neighbors may be listed in a different order.
+*/
+func (s *SharedMarket) RebaseNeighbors() map[string]Neighbors {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	rebaseNeighbors := make(map[string]Neighbors, len(s.neighbors.Neighbors()))
+	for pairId, neighbors := range s.neighbors.Neighbors() {
+		rebaseNeighbors[pairId] = Neighbors{
+			Base:  append([]string{}, neighbors.Base...),
+			Quote: append([]string{}, neighbors.Quote...),
+		}
+	}
+	return rebaseNeighbors
+}
+
+func (s *SharedMarket) upsert(pair Pair) {
+	// keep callers from changing stored exchange markets through their own slice
+	pair.ExchangeMarkets = append([]ExchangeMarket(nil), pair.ExchangeMarkets...)
+
+	pairId := pair.Id()
+	_, existed := s.pairsById[pairId]
+	s.pairsById[pairId] = pair
+	// a pair's id is derived from its assets, so replacing it doesn't change any neighbors
+	if !existed {
+		s.neighbors.Connect(pair, true)
+	}
+}
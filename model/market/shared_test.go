@@ -0,0 +1,172 @@
+package market
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func mockSharedPairs() (Pair, Pair, Pair) {
+	mockPairA := Pair{
+		BaseAssetId:  "1",
+		QuoteAssetId: "2",
+		ExchangeMarkets: []ExchangeMarket{
+			{
+				CurrentBid: 2,
+				CurrentAsk: 2,
+				BaseVolume: 1,
+			},
+		},
+	}
+	mockPairB := Pair{
+		BaseAssetId:  "2",
+		QuoteAssetId: "3",
+	}
+	mockPairC := Pair{
+		BaseAssetId:  "3",
+		QuoteAssetId: "1",
+	}
+	return mockPairA, mockPairB, mockPairC
+}
+
+func sortedNeighbors(rebaseNeighbors map[string]Neighbors) map[string]Neighbors {
+	for _, neighbors := range rebaseNeighbors {
+		sort.Strings(neighbors.Base)
+		sort.Strings(neighbors.Quote)
+	}
+	return rebaseNeighbors
+}
+
+func TestSharedMarket(t *testing.T) {
+	Convey("starts with a copy of the given market", t, func() {
+		mockPairA, mockPairB, _ := mockSharedPairs()
+		mockMarket := Market{
+			PairsById: map[string]Pair{
+				mockPairA.Id(): mockPairA,
+				mockPairB.Id(): mockPairB,
+			},
+		}
+
+		actual := NewSharedMarket(&mockMarket)
+		delete(mockMarket.PairsById, mockPairB.Id())
+
+		So(actual.Len(), ShouldEqual, 2)
+		So(NewSharedMarket(nil).Len(), ShouldEqual, 0)
+	})
+	Convey("upserted pairs can be got", t, func() {
+		mockPairA, _, _ := mockSharedPairs()
+		sharedMarket := NewSharedMarket(nil)
+
+		sharedMarket.Upsert(mockPairA)
+		actual, ok := sharedMarket.Get(mockPairA.Id())
+
+		So(ok, ShouldBeTrue)
+		So(actual, ShouldResemble, mockPairA)
+	})
+	Convey("upserting a pair with the same id replaces it", t, func() {
+		mockPairA, _, _ := mockSharedPairs()
+		sharedMarket := NewSharedMarket(nil)
+		updatedPairA := Pair{BaseAssetId: "1", QuoteAssetId: "2"}
+
+		sharedMarket.Upsert(mockPairA)
+		sharedMarket.Upsert(updatedPairA)
+		actual, _ := sharedMarket.Get(mockPairA.Id())
+
+		So(sharedMarket.Len(), ShouldEqual, 1)
+		So(actual.ExchangeMarkets, ShouldBeEmpty)
+	})
+	Convey("upserted exchange markets are copied", t, func() {
+		mockPairA, _, _ := mockSharedPairs()
+		sharedMarket := NewSharedMarket(nil)
+
+		sharedMarket.Upsert(mockPairA)
+		mockPairA.ExchangeMarkets[0].CurrentBid = 3
+		actual, _ := sharedMarket.Get(mockPairA.Id())
+
+		So(actual.ExchangeMarkets[0].CurrentBid, ShouldEqual, 2)
+	})
+	Convey("removed pairs are gone", t, func() {
+		mockPairA, _, _ := mockSharedPairs()
+		sharedMarket := NewSharedMarket(nil)
+		sharedMarket.Upsert(mockPairA)
+
+		So(sharedMarket.Remove(mockPairA.Id()), ShouldBeTrue)
+		So(sharedMarket.Remove(mockPairA.Id()), ShouldBeFalse)
+		_, ok := sharedMarket.Get(mockPairA.Id())
+		So(ok, ShouldBeFalse)
+	})
+	Convey("snapshots don't change with the shared market", t, func() {
+		mockPairA, mockPairB, _ := mockSharedPairs()
+		sharedMarket := NewSharedMarket(nil)
+		sharedMarket.Upsert(mockPairA)
+
+		actual := sharedMarket.Snapshot()
+		sharedMarket.Upsert(mockPairB)
+
+		So(actual.PairsById, ShouldResemble, map[string]Pair{mockPairA.Id(): mockPairA})
+	})
+	Convey("range stops when f returns false and allows changes", t, func() {
+		mockPairA, mockPairB, mockPairC := mockSharedPairs()
+		sharedMarket := NewSharedMarket(nil)
+		sharedMarket.Upsert(mockPairA)
+		sharedMarket.Upsert(mockPairB)
+		sharedMarket.Upsert(mockPairC)
+
+		visited := 0
+		sharedMarket.Range(func(pairId string, pair Pair) bool {
+			visited++
+			sharedMarket.Remove(pairId)
+			return visited < 2
+		})
+
+		So(visited, ShouldEqual, 2)
+		So(sharedMarket.Len(), ShouldEqual, 1)
+	})
+	Convey("rebase neighbors stay in sync with changes", t, func() {
+		mockPairA, mockPairB, mockPairC := mockSharedPairs()
+		mockPairLoop := Pair{BaseAssetId: "2", QuoteAssetId: "2"}
+		sharedMarket := NewSharedMarket(nil)
+
+		for _, pair := range []Pair{mockPairA, mockPairB, mockPairC, mockPairLoop} {
+			sharedMarket.Upsert(pair)
+			snapshot := sharedMarket.Snapshot()
+			So(sortedNeighbors(sharedMarket.RebaseNeighbors()), ShouldResemble, sortedNeighbors(snapshot.RebaseNeighbors()))
+		}
+		for _, pair := range []Pair{mockPairB, mockPairLoop, mockPairA} {
+			sharedMarket.Remove(pair.Id())
+			snapshot := sharedMarket.Snapshot()
+			So(sortedNeighbors(sharedMarket.RebaseNeighbors()), ShouldResemble, sortedNeighbors(snapshot.RebaseNeighbors()))
+		}
+	})
+	Convey("concurrent readers and writers", t, func() {
+		mockPairA, mockPairB, mockPairC := mockSharedPairs()
+		sharedMarket := NewSharedMarket(nil)
+
+		var waitGroup sync.WaitGroup
+		for _, pair := range []Pair{mockPairA, mockPairB, mockPairC} {
+			waitGroup.Add(2)
+			go func(pair Pair) {
+				defer waitGroup.Done()
+				for i := 0; i < 100; i++ {
+					sharedMarket.Upsert(pair)
+					sharedMarket.Remove(pair.Id())
+					sharedMarket.Upsert(pair)
+				}
+			}(pair)
+			go func(pair Pair) {
+				defer waitGroup.Done()
+				for i := 0; i < 100; i++ {
+					sharedMarket.Get(pair.Id())
+					sharedMarket.Snapshot()
+					sharedMarket.RebaseNeighbors()
+				}
+			}(pair)
+		}
+		waitGroup.Wait()
+
+		snapshot := sharedMarket.Snapshot()
+		So(snapshot.PairsById, ShouldHaveLength, 3)
+		So(sortedNeighbors(sharedMarket.RebaseNeighbors()), ShouldResemble, sortedNeighbors(snapshot.RebaseNeighbors()))
+	})
+}
@@ -0,0 +1,173 @@
+package rebasing
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+/**
+Asset of a basket and its weight.
+*/
+type BasketComponent struct {
+	AssetId string
+	Weight  float32
+}
+
+/**
+Numeraire made up of several assets, for prices that don't move with a single currency.
+By default, one unit of the basket holds Weight units of every component, e.g. 0.6 USD and 0.4 EUR. A geometric basket
+is an index instead: its price is the weighted geometric mean of the components' prices, with the weights normalized to
+sum to 1. Without any weights, all components weigh the same, which makes a geometric basket an equal-weight index.
+*/
+type Basket struct {
+	// id the rebased market is expressed in, which can't be an asset of the market
+	Id         string
+	Components []BasketComponent
+	Geometric  bool
+}
+
+/**
+Geometric basket of the assets, all weighing the same.
+*/
+func EqualWeightIndex(id string, assetIds ...string) Basket {
+	basket := Basket{Id: id, Geometric: true}
+	for _, assetId := range assetIds {
+		basket.Components = append(basket.Components, BasketComponent{AssetId: assetId})
+	}
+	return basket
+}
+
+func (b Basket) validate(market *m.Market) error {
+	if b.Id == "" {
+		return fmt.Errorf("basket has no id")
+	}
+	if len(b.Components) == 0 {
+		return fmt.Errorf(`basket "%s" has no assets`, b.Id)
+	}
+	assetIds := map[string]bool{}
+	for _, component := range b.Components {
+		if component.Weight < 0 {
+			return fmt.Errorf(`basket "%s" has a negative weight for asset "%s"`, b.Id, component.AssetId)
+		}
+		if assetIds[component.AssetId] {
+			return fmt.Errorf(`basket "%s" has asset "%s" more than once`, b.Id, component.AssetId)
+		}
+		assetIds[component.AssetId] = true
+	}
+	for _, pair := range market.PairsById {
+		if pair.BaseAssetId == b.Id || pair.QuoteAssetId == b.Id {
+			return fmt.Errorf(`basket id "%s" is an asset of the market`, b.Id)
+		}
+	}
+	return nil
+}
+
+// weights of the components in order, equal if none are given and summing to 1 for geometric baskets
+func (b Basket) weights() []float64 {
+	var sum float64
+	for _, component := range b.Components {
+		sum += float64(component.Weight)
+	}
+	weights := make([]float64, len(b.Components))
+	for i, component := range b.Components {
+		switch {
+		case sum == 0:
+			weights[i] = 1 / float64(len(b.Components))
+		case b.Geometric:
+			weights[i] = float64(component.Weight) / sum
+		default:
+			weights[i] = float64(component.Weight)
+		}
+	}
+	return weights
+}
+
+/**
+Price of one unit of the basket, given the prices of its components in any one asset, in that asset.
+Fails if a component has no price.
+*/
+func (b Basket) Price(prices map[string]float32) (float32, error) {
+	weights := b.weights()
+	var price float64
+	if b.Geometric {
+		price = 1
+	}
+	for i, component := range b.Components {
+		componentPrice, ok := prices[component.AssetId]
+		if !ok || componentPrice <= 0 {
+			return 0, fmt.Errorf(`no price for asset "%s" of basket "%s"`, component.AssetId, b.Id)
+		}
+		if b.Geometric {
+			price *= math.Pow(float64(componentPrice), weights[i])
+		} else {
+			price += weights[i] * float64(componentPrice)
+		}
+	}
+	return float32(price), nil
+}
+
+/**
+Rebases a market in a basket. The market is rebased in the basket's first asset, and all rates and volumes are then
+expressed in units of the basket, priced from the rebased market, or from the fitted prices with least squares. The
+prices of the basket's assets in the basket are part of the result, even for assets the rebased market can't price
+anymore because they're only ever a base asset.
+Like Rebase, a budget that runs out or a context that's done return the partial result along with the error, unless
+the basket can't be priced from it.
+*/
+func (e *Engine) RebaseInBasket(ctx context.Context, basket Basket, market *m.Market) (*Result, error) {
+	if err := basket.validate(market); err != nil {
+		return nil, err
+	}
+	anchorId := basket.Components[0].AssetId
+	result, err := e.Rebase(ctx, anchorId, market)
+	prices := result.Prices
+	if prices == nil {
+		prices = AssetPrices(result.Market, anchorId)
+	}
+	basketPrice, priceErr := basket.Price(prices)
+	if priceErr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("%w, the rebase was cut short: %v", priceErr, err)
+		}
+		return nil, priceErr
+	}
+
+	basketResult := *result
+	basketResult.Market = rebaseMarketIn(result.Market, basketPrice)
+	basketResult.BasketPrices = map[string]float32{}
+	for _, component := range basket.Components {
+		basketResult.BasketPrices[component.AssetId] = prices[component.AssetId] / basketPrice
+	}
+	if result.Prices != nil {
+		basketResult.Prices = map[string]float32{basket.Id: 1}
+		for assetId, price := range result.Prices {
+			basketResult.Prices[assetId] = price / basketPrice
+		}
+	}
+	return &basketResult, err
+}
+
+// market rebased in an asset, expressed in another asset priced at assetPrice in the first
+func rebaseMarketIn(rebasedMarket *m.Market, assetPrice float32) *m.Market {
+	market := m.Market{PairsById: map[string]m.Pair{}}
+	for pairId, pair := range rebasedMarket.PairsById {
+		newPair := m.Pair{
+			BaseAssetId:  pair.BaseAssetId,
+			QuoteAssetId: pair.QuoteAssetId,
+		}
+		for _, emd := range pair.ExchangeMarkets {
+			newPair.ExchangeMarkets = append(newPair.ExchangeMarkets, m.ExchangeMarket{
+				ExchangeId:  emd.ExchangeId,
+				CurrentBid:  emd.CurrentBid / assetPrice,
+				CurrentAsk:  emd.CurrentAsk / assetPrice,
+				BaseVolume:  emd.BaseVolume / assetPrice,
+				QuoteVolume: emd.QuoteVolume / assetPrice,
+			})
+		}
+		market.PairsById[pairId] = newPair
+	}
+	return &market
+}
@@ -0,0 +1,111 @@
+package rebasing
+
+import (
+	"context"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+func mockBasketMarket() m.Market {
+	return mockMarketOf(mockRatePair("USD", "EUR", 1.1), mockRatePair("EUR", "GBP", 1.2))
+}
+
+func TestEngine_RebaseInBasket(t *testing.T) {
+	usdEur := mockRatePair("USD", "EUR", 1.1)
+	eurGbp := mockRatePair("EUR", "GBP", 1.2)
+
+	Convey("expresses rates and volumes in units of the basket", t, func() {
+		mockMarket := mockBasketMarket()
+		basket := Basket{Id: "B", Components: []BasketComponent{{AssetId: "USD", Weight: 0.6}, {AssetId: "EUR", Weight: 0.4}}}
+
+		actual, err := NewEngine(WithMaxPathDepth(2)).RebaseInBasket(context.Background(), basket, &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.PathDepth, ShouldEqual, 2)
+		usdEurEmd := actual.Market.PairsById[usdEur.Id()].ExchangeMarkets[0]
+		So(usdEurEmd.CurrentBid, ShouldAlmostEqual, 1.1/1.04, 0.0001)
+		So(usdEurEmd.BaseVolume, ShouldAlmostEqual, 100/1.04, 0.01)
+		eurGbpEmd := actual.Market.PairsById[eurGbp.Id()].ExchangeMarkets[0]
+		So(eurGbpEmd.CurrentAsk, ShouldAlmostEqual, 1.32/1.04, 0.0001)
+		So(eurGbpEmd.BaseVolume, ShouldAlmostEqual, 110/1.04, 0.01)
+		So(actual.BasketPrices["USD"], ShouldAlmostEqual, 1/1.04, 0.0001)
+		So(actual.BasketPrices["EUR"], ShouldAlmostEqual, 1.1/1.04, 0.0001)
+	})
+	Convey("prices an equal-weight index geometrically", t, func() {
+		mockMarket := mockBasketMarket()
+
+		actual, err := NewEngine(WithMaxPathDepth(2)).
+			RebaseInBasket(context.Background(), EqualWeightIndex("IDX", "USD", "EUR"), &mockMarket)
+
+		So(err, ShouldBeNil)
+		prices := AssetPrices(actual.Market, "IDX")
+		So(prices["EUR"], ShouldAlmostEqual, 1.1/math.Sqrt(1.1), 0.0001)
+		So(prices["GBP"], ShouldAlmostEqual, 1.32/math.Sqrt(1.1), 0.0001)
+	})
+	Convey("expresses fitted prices in units of the basket", t, func() {
+		mockMarket := mockBasketMarket()
+
+		actual, err := NewEngine(WithLeastSquares()).
+			RebaseInBasket(context.Background(), EqualWeightIndex("IDX", "USD", "EUR"), &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Prices["IDX"], ShouldEqual, 1)
+		So(actual.Prices["GBP"], ShouldAlmostEqual, 1.32/math.Sqrt(1.1), 0.0001)
+		So(actual.BasketPrices["USD"], ShouldAlmostEqual, 1/math.Sqrt(1.1), 0.0001)
+	})
+	Convey("fails for baskets of assets without a price", t, func() {
+		mockMarket := mockBasketMarket()
+
+		_, err := NewEngine(WithMaxPathDepth(2)).
+			RebaseInBasket(context.Background(), EqualWeightIndex("IDX", "USD", "JPY"), &mockMarket)
+
+		So(err, ShouldNotBeNil)
+	})
+	Convey("fails for invalid baskets", t, func() {
+		mockMarket := mockBasketMarket()
+		for _, basket := range []Basket{
+			{Components: []BasketComponent{{AssetId: "USD"}}},
+			{Id: "B"},
+			{Id: "B", Components: []BasketComponent{{AssetId: "USD", Weight: -1}}},
+			{Id: "B", Components: []BasketComponent{{AssetId: "USD"}, {AssetId: "USD"}}},
+			{Id: "GBP", Components: []BasketComponent{{AssetId: "USD"}}},
+		} {
+			_, err := NewEngine(WithMaxPathDepth(2)).RebaseInBasket(context.Background(), basket, &mockMarket)
+
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestBasket_Price(t *testing.T) {
+	prices := map[string]float32{"USD": 1, "EUR": 1.1, "GBP": 1.32}
+
+	Convey("holds the weights as amounts of its assets", t, func() {
+		basket := Basket{Id: "B", Components: []BasketComponent{{AssetId: "USD", Weight: 0.6}, {AssetId: "EUR", Weight: 0.4}}}
+
+		actual, err := basket.Price(prices)
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldAlmostEqual, 1.04, 0.0001)
+	})
+	Convey("normalizes the weights of geometric baskets", t, func() {
+		basket := Basket{
+			Id:         "B",
+			Components: []BasketComponent{{AssetId: "USD", Weight: 3}, {AssetId: "GBP", Weight: 1}},
+			Geometric:  true,
+		}
+
+		actual, err := basket.Price(prices)
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldAlmostEqual, math.Pow(1.32, 0.25), 0.0001)
+	})
+	Convey("weighs all assets the same without weights", t, func() {
+		actual, err := Basket{Id: "B", Components: []BasketComponent{{AssetId: "USD"}, {AssetId: "EUR"}}}.Price(prices)
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldAlmostEqual, 1.05, 0.0001)
+	})
+}
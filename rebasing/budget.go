@@ -0,0 +1,168 @@
+package rebasing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// returned, possibly wrapped, along with a partially rebased market when a budget runs out
+var ErrBudgetExceeded = errors.New("rebase budget exceeded")
+
+/**
+Limits on the work a single rebase may do. Zero values don't limit anything.
+MaxPathsPerPair and MaxPaths count every path the search for rebase paths goes through, including those that never
+reach the rebase asset, so they bound the search in dense markets too.
+When MaxPathsPerPair is hit, the pair is rebased using the paths found so far.
+When MaxPaths or MaxDuration is hit, pairs that haven't been rebased yet are left out of the result.
+*/
+type Budget struct {
+	MaxPathsPerPair int
+	MaxPaths        int
+	MaxDuration     time.Duration
+}
+
+// tracks the use of a budget and the cancellation of its context during one rebase
+type budgetTracker struct {
+	ctx      context.Context
+	budget   Budget
+	deadline time.Time
+	mutex    sync.Mutex
+	paths    int
+	// set once the whole rebase has to stop
+	err error
+	// set once any pair had to stop looking for paths
+	pairErr error
+	// budget that other rebases count against too, which limits the paths and duration of this one instead of its own
+	shared *budgetTracker
+}
+
+// tracks the paths of a single pair against the budget of the whole rebase
+type pairBudgetTracker struct {
+	*budgetTracker
+	paths int
+	full  bool
+}
+
+func newBudgetTracker(ctx context.Context, budget Budget) *budgetTracker {
+	tracker := budgetTracker{ctx: ctx, budget: budget}
+	if budget.MaxDuration > 0 {
+		tracker.deadline = time.Now().Add(budget.MaxDuration)
+	}
+	return &tracker
+}
+
+// tracker of a rebase that counts against this budget along with other rebases, only limiting its paths per pair itself
+func (t *budgetTracker) forRebase(ctx context.Context) *budgetTracker {
+	tracker := newBudgetTracker(ctx, Budget{MaxPathsPerPair: t.budget.MaxPathsPerPair})
+	tracker.shared = t
+	return tracker
+}
+
+// tracker of a rebase that counts against this budget and also runs out at the deadline, limit being the time it allows
+func (t *budgetTracker) until(deadline time.Time, limit time.Duration) *budgetTracker {
+	tracker := t.forRebase(t.ctx)
+	tracker.budget.MaxDuration = limit
+	tracker.deadline = deadline
+	return tracker
+}
+
+func (t *budgetTracker) forPair() *pairBudgetTracker {
+	if t == nil {
+		return nil
+	}
+	return &pairBudgetTracker{budgetTracker: t}
+}
+
+// whether the budget of the whole rebase has run out or its context is done
+func (t *budgetTracker) exceeded() bool {
+	if t == nil {
+		return false
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.err == nil && t.ctx.Err() != nil {
+		t.err = t.ctx.Err()
+	}
+	if t.err == nil && !t.deadline.IsZero() && time.Now().After(t.deadline) {
+		t.err = fmt.Errorf("%w: took longer than %s", ErrBudgetExceeded, t.budget.MaxDuration)
+	}
+	if t.err == nil && t.shared != nil && t.shared.exceeded() {
+		t.err = t.shared.error()
+	}
+	return t.err != nil
+}
+
+// first budget that ran out, if any
+func (t *budgetTracker) error() error {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.err != nil {
+		return t.err
+	}
+	return t.pairErr
+}
+
+// whether the pair has to stop looking for paths
+func (t *pairBudgetTracker) exceeded() bool {
+	if t == nil {
+		return false
+	}
+	return t.full || t.budgetTracker.exceeded()
+}
+
+// counts a found path, returns false if it doesn't fit in the budget
+func (t *pairBudgetTracker) addPath() bool {
+	if t == nil {
+		return true
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.budget.MaxPathsPerPair > 0 && t.paths >= t.budget.MaxPathsPerPair {
+		t.full = true
+		if t.pairErr == nil {
+			t.pairErr = fmt.Errorf("%w: more than %d paths for a single pair", ErrBudgetExceeded, t.budget.MaxPathsPerPair)
+		}
+		return false
+	}
+	if t.budget.MaxPaths > 0 && t.budgetTracker.paths >= t.budget.MaxPaths {
+		if t.err == nil {
+			t.err = fmt.Errorf("%w: more than %d paths in total", ErrBudgetExceeded, t.budget.MaxPaths)
+		}
+		return false
+	}
+	if t.shared != nil && !t.shared.addSharedPath() {
+		if t.err == nil {
+			t.err = t.shared.error()
+		}
+		return false
+	}
+	t.paths++
+	t.budgetTracker.paths++
+	return true
+}
+
+// counts a path of one of the rebases sharing the budget, returns false if it doesn't fit in the budget
+func (t *budgetTracker) addSharedPath() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.budget.MaxPaths > 0 && t.paths >= t.budget.MaxPaths {
+		if t.err == nil {
+			t.err = fmt.Errorf("%w: more than %d paths in total", ErrBudgetExceeded, t.budget.MaxPaths)
+		}
+		return false
+	}
+	if t.shared != nil && !t.shared.addSharedPath() {
+		if t.err == nil {
+			t.err = t.shared.error()
+		}
+		return false
+	}
+	t.paths++
+	return true
+}
@@ -0,0 +1,103 @@
+package rebasing
+
+import (
+	"context"
+	"errors"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestRebaseMarketWithBudget(t *testing.T) {
+	mockMarket := mockChainMarket()
+	// gives pair "3/4" a second path
+	mockPairShortcut := m.Pair{
+		BaseAssetId:  "1",
+		QuoteAssetId: "3",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 5,
+				CurrentAsk: 5,
+				BaseVolume: 1,
+			},
+		},
+	}
+	mockMarket.PairsById[mockPairShortcut.Id()] = mockPairShortcut
+
+	Convey("no budget doesn't limit anything", t, func() {
+		actualMarket, err := RebaseMarketWithOptions("1", 3, &mockMarket, Options{})
+
+		So(err, ShouldBeNil)
+		So(actualMarket.PairsById, ShouldHaveLength, 4)
+	})
+	Convey("pairs with too many paths are rebased using the paths found so far", t, func() {
+		// "3/4" goes through up to 3 paths before finding one that reaches "1", and through 4 before finding both
+		options := Options{Budget: Budget{MaxPathsPerPair: 3}}
+		actualMarket, err := RebaseMarketWithOptions("1", 3, &mockMarket, options)
+
+		So(errors.Is(err, ErrBudgetExceeded), ShouldBeTrue)
+		So(actualMarket.PairsById, ShouldHaveLength, 4)
+		mockPairC := m.Pair{BaseAssetId: "3", QuoteAssetId: "4"}
+		So(actualMarket.PairsById[mockPairC.Id()].ExchangeMarkets[0].CurrentBid, ShouldBeGreaterThan, 0)
+	})
+	Convey("pairs aren't rebased once the total number of paths runs out", t, func() {
+		options := Options{Budget: Budget{MaxPaths: 2}}
+		actualMarket, err := RebaseMarketWithOptions("1", 3, &mockMarket, options)
+
+		So(errors.Is(err, ErrBudgetExceeded), ShouldBeTrue)
+		So(len(actualMarket.PairsById), ShouldBeLessThan, 4)
+	})
+	Convey("pairs aren't rebased once the time runs out", t, func() {
+		options := Options{Budget: Budget{MaxDuration: time.Nanosecond}}
+		time.Sleep(time.Millisecond)
+		actualMarket, err := RebaseMarketWithOptions("1", 3, &mockMarket, options)
+
+		So(errors.Is(err, ErrBudgetExceeded), ShouldBeTrue)
+		So(len(actualMarket.PairsById), ShouldBeLessThan, 4)
+	})
+	Convey("path limits bound the search in dense markets where few paths reach the rebase asset", t, func() {
+		// every pair of 8 assets in both directions, and the rebase asset traded only against an asset outside of them
+		denseMarket := mockMarketOf(mockRatePair("R", "X", 1))
+		assetIds := []string{"A", "B", "C", "D", "E", "F", "G", "H"}
+		for _, baseId := range assetIds {
+			for _, quoteId := range assetIds {
+				if baseId != quoteId {
+					pair := mockRatePair(baseId, quoteId, 1)
+					denseMarket.PairsById[pair.Id()] = pair
+				}
+			}
+		}
+		start := time.Now()
+
+		_, err := RebaseMarketWithOptions("R", 8, &denseMarket, Options{Budget: Budget{MaxPathsPerPair: 1000, MaxPaths: 10000}})
+
+		So(errors.Is(err, ErrBudgetExceeded), ShouldBeTrue)
+		So(time.Since(start), ShouldBeLessThan, time.Second)
+	})
+}
+
+func TestPairBudgetTracker(t *testing.T) {
+	Convey("nil trackers never run out", t, func() {
+		var tracker *budgetTracker
+
+		So(tracker.exceeded(), ShouldBeFalse)
+		So(tracker.forPair().addPath(), ShouldBeTrue)
+		So(tracker.error(), ShouldBeNil)
+	})
+	Convey("paths are counted per pair and in total", t, func() {
+		tracker := newBudgetTracker(context.Background(), Budget{MaxPathsPerPair: 2, MaxPaths: 3})
+		pairTrackerA := tracker.forPair()
+		pairTrackerB := tracker.forPair()
+
+		So(pairTrackerA.addPath(), ShouldBeTrue)
+		So(pairTrackerA.addPath(), ShouldBeTrue)
+		So(pairTrackerA.exceeded(), ShouldBeFalse)
+		So(pairTrackerA.addPath(), ShouldBeFalse)
+		So(pairTrackerA.exceeded(), ShouldBeTrue)
+		So(tracker.exceeded(), ShouldBeFalse)
+		So(pairTrackerB.addPath(), ShouldBeTrue)
+		So(pairTrackerB.addPath(), ShouldBeFalse)
+		So(tracker.exceeded(), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,67 @@
+package rebasing
+
+import (
+	"context"
+	"errors"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestRebaseMarketContext(t *testing.T) {
+	Convey("rebases the whole market if the context isn't done", t, func() {
+		mockMarket := mockChainMarket()
+
+		actualMarket, err := RebaseMarketContext(context.Background(), "1", 3, &mockMarket, Options{})
+
+		So(err, ShouldBeNil)
+		So(actualMarket, ShouldResemble, RebaseMarket("1", 3, &mockMarket))
+	})
+	Convey("returns the context's error and leaves out pairs that weren't rebased if it's cancelled", t, func() {
+		mockMarket := mockChainMarket()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		actualMarket, err := RebaseMarketContext(ctx, "1", 3, &mockMarket, Options{})
+
+		So(errors.Is(err, context.Canceled), ShouldBeTrue)
+		So(actualMarket.PairsById, ShouldBeEmpty)
+	})
+	Convey("returns the context's error if its deadline passes", t, func() {
+		mockMarket := mockChainMarket()
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+		defer cancel()
+
+		actualMarket, err := RebaseMarketContext(ctx, "1", 3, &mockMarket, Options{})
+
+		So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+		So(actualMarket.PairsById, ShouldBeEmpty)
+	})
+	Convey("automatic depth returns the context's error", t, func() {
+		mockMarket := mockChainMarket()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, _, err := RebaseMarketAutoDepthContext(ctx, "1", &mockMarket, AutoDepth{}, Options{})
+
+		So(errors.Is(err, context.Canceled), ShouldBeTrue)
+	})
+}
+
+func TestRebasePathsCancellation(t *testing.T) {
+	Convey("path enumeration stops once the context is done", t, func() {
+		mockMarket := mockChainMarket()
+		mockPairC := m.Pair{
+			BaseAssetId:  "3",
+			QuoteAssetId: "4",
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		run := newRebaseRun(ctx, "1", 3, &mockMarket, Options{})
+
+		actual := run.rebasePaths(BASE, []string{mockPairC.Id()}, run.budget.forPair())
+
+		So(actual, ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,183 @@
+package rebasing
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+const (
+	DefaultAutoDepthTolerance = float32(0.001)
+	DefaultAutoDepthMaxDepth  = uint8(6)
+)
+
+/**
+Settings for choosing the maximum path depth automatically.
+Tolerance is the largest relative change of any rebased rate that still counts as converged.
+MaxDepth caps the depth and TimeBudget caps the time spent trying deeper paths, counted from the start of the rebase. A
+deeper depth that is still running when it's up is abandoned. A zero TimeBudget means no limit.
+Zero Tolerance and MaxDepth use their defaults.
+*/
+type AutoDepth struct {
+	Tolerance  float32
+	MaxDepth   uint8
+	TimeBudget time.Duration
+}
+
+/**
+Rebases a market at the shallowest path depth at which every reachable pair has a rebase path and one more level of depth
+changes no rebased rate by more than the tolerance. Returns the rebased market along with the depth it was rebased at.
+The budget covers the rebases at all depths together. If it, or the time budget, runs out at a depth beyond the first,
+the result of the previous depth is returned.
+*/
+func RebaseMarketAutoDepth(rebaseId string, market *m.Market, autoDepth AutoDepth, options Options) (*m.Market, uint8, error) {
+	return RebaseMarketAutoDepthContext(context.Background(), rebaseId, market, autoDepth, options)
+}
+
+/**
+Like RebaseMarketAutoDepth, until the context is done.
+If the context is done at a depth beyond the first, the result of the previous depth is returned along with ctx.Err().
+*/
+func RebaseMarketAutoDepthContext(ctx context.Context, rebaseId string, market *m.Market, autoDepth AutoDepth, options Options) (*m.Market, uint8, error) {
+	result, err := NewEngine(WithAutoDepth(autoDepth), WithOptions(options)).Rebase(ctx, rebaseId, market)
+	return result.Market, result.PathDepth, err
+}
+
+func (e *Engine) rebaseAutoDepth(ctx context.Context, rebaseId string, market *m.Market) (*Result, error) {
+	start := time.Now()
+	tolerance := e.autoDepth.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultAutoDepthTolerance
+	}
+	maxDepth := e.autoDepth.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultAutoDepthMaxDepth
+	}
+
+	filteredMarket, _, rebaseNeighbors, syntheticPairIds := rebaseGraph(rebaseId, market, e.options)
+	depth := reachDepth(rebaseId, filteredMarket, rebaseNeighbors, syntheticPairIds)
+	if depth > maxDepth {
+		depth = maxDepth
+	}
+
+	// one budget for all depths, so that trying deeper paths doesn't multiply the time and paths a rebase may take
+	budget := e.budgetTracker(ctx)
+	result, err := e.rebaseAtDepth(rebaseId, depth, market, budget)
+	if err != nil {
+		return result, err
+	}
+	deeperBudget := budget
+	if e.autoDepth.TimeBudget > 0 {
+		// deeper depths also stop once the time budget is up, however far they've got
+		deeperBudget = budget.until(start.Add(e.autoDepth.TimeBudget), e.autoDepth.TimeBudget)
+	}
+	for depth < maxDepth {
+		deeperResult, err := e.rebaseAtDepth(rebaseId, depth+1, market, deeperBudget)
+		if errors.Is(err, ErrBudgetExceeded) {
+			break
+		} else if err != nil {
+			return result, err
+		}
+		if converged(result.Market, deeperResult.Market, tolerance) {
+			break
+		}
+		result = deeperResult
+		depth++
+	}
+	return result, nil
+}
+
+// smallest depth at which every pair that can be rebased at all, other than the pairs of pegs, has at least one rebase path
+func reachDepth(rebaseId string, market *m.Market, rebaseNeighbors map[string]m.Neighbors, syntheticPairIds map[string]bool) uint8 {
+	baseDistances := pathDistances(BASE, rebaseId, market, rebaseNeighbors)
+	quoteDistances := pathDistances(QUOTE, rebaseId, market, rebaseNeighbors)
+
+	depth := uint8(1)
+	for pairId := range market.PairsById {
+		if syntheticPairIds[pairId] {
+			continue
+		}
+		distance, ok := baseDistances[pairId]
+		if quoteDistance, quoteOk := quoteDistances[pairId]; quoteOk && (!ok || quoteDistance < distance) {
+			distance, ok = quoteDistance, true
+		}
+		if !ok {
+			continue
+		}
+		if distance > math.MaxUint8 {
+			distance = math.MaxUint8
+		}
+		if uint8(distance) > depth {
+			depth = uint8(distance)
+		}
+	}
+	return depth
+}
+
+// length of the shortest rebase path in the given direction for every pair that has one
+func pathDistances(direction rebaseDirection, rebaseId string, market *m.Market, rebaseNeighbors map[string]m.Neighbors) map[string]int {
+	previousPairIds := previousNeighbors(direction, rebaseNeighbors)
+
+	distances := map[string]int{}
+	var queue []string
+	for pairId, pair := range market.PairsById {
+		if pair.BaseAssetId == rebaseId {
+			distances[pairId] = 1
+			queue = append(queue, pairId)
+		}
+	}
+	for len(queue) > 0 {
+		pairId := queue[0]
+		queue = queue[1:]
+		for _, previousPairId := range previousPairIds[pairId] {
+			if _, ok := distances[previousPairId]; !ok {
+				distances[previousPairId] = distances[pairId] + 1
+				queue = append(queue, previousPairId)
+			}
+		}
+	}
+	return distances
+}
+
+// pairs that can continue their paths in the given direction through each pair
+func previousNeighbors(direction rebaseDirection, rebaseNeighbors map[string]m.Neighbors) map[string][]string {
+	previousPairIds := map[string][]string{}
+	for pairId, neighbors := range rebaseNeighbors {
+		nextNeighborIds := neighbors.Base
+		if direction == QUOTE {
+			nextNeighborIds = neighbors.Quote
+		}
+		for _, nextNeighborId := range nextNeighborIds {
+			previousPairIds[nextNeighborId] = append(previousPairIds[nextNeighborId], pairId)
+		}
+	}
+	return previousPairIds
+}
+
+// whether no rebased rate differs by more than the relative tolerance between both markets
+func converged(rebasedMarket *m.Market, deeperRebasedMarket *m.Market, tolerance float32) bool {
+	for pairId, pair := range rebasedMarket.PairsById {
+		deeperPair := deeperRebasedMarket.PairsById[pairId]
+		for i, emd := range pair.ExchangeMarkets {
+			deeperEmd := deeperPair.ExchangeMarkets[i]
+			if !withinTolerance(emd.CurrentBid, deeperEmd.CurrentBid, tolerance) ||
+				!withinTolerance(emd.CurrentAsk, deeperEmd.CurrentAsk, tolerance) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func withinTolerance(rate float32, otherRate float32, tolerance float32) bool {
+	if rate == otherRate {
+		return true
+	} else if rate == 0 {
+		return false
+	} else {
+		return float32(math.Abs(float64((otherRate-rate)/rate))) <= tolerance
+	}
+}
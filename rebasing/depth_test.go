@@ -0,0 +1,203 @@
+package rebasing
+
+import (
+	"context"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func mockChainMarket() m.Market {
+	mockPairA := m.Pair{
+		BaseAssetId:  "1",
+		QuoteAssetId: "2",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 2,
+				CurrentAsk: 2,
+				BaseVolume: 1,
+			},
+		},
+	}
+	mockPairB := m.Pair{
+		BaseAssetId:  "2",
+		QuoteAssetId: "3",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 3,
+				CurrentAsk: 3,
+				BaseVolume: 1,
+			},
+		},
+	}
+	mockPairC := m.Pair{
+		BaseAssetId:  "3",
+		QuoteAssetId: "4",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 4,
+				CurrentAsk: 4,
+				BaseVolume: 1,
+			},
+		},
+	}
+	return m.Market{
+		PairsById: map[string]m.Pair{
+			mockPairA.Id(): mockPairA,
+			mockPairB.Id(): mockPairB,
+			mockPairC.Id(): mockPairC,
+		},
+	}
+}
+
+// chain market with a shortcut from "1" to "3" at a different price than the path through "2", so that rebasing at depth
+// 3 changes the rates of depth 2
+func mockShortcutMarket() m.Market {
+	mockMarket := mockChainMarket()
+	mockPairShortcut := m.Pair{
+		BaseAssetId:  "1",
+		QuoteAssetId: "3",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 5,
+				CurrentAsk: 5,
+				BaseVolume: 1,
+			},
+		},
+	}
+	mockMarket.PairsById[mockPairShortcut.Id()] = mockPairShortcut
+	return mockMarket
+}
+
+// weighs paths like the volume weighter, sleeping on every call
+type sleepingWeighter struct {
+	sleep time.Duration
+	calls *int
+}
+
+func (w sleepingWeighter) Weights(paths []PathLiquidity) []float32 {
+	*w.calls++
+	time.Sleep(w.sleep)
+	return VolumeWeighter{}.Weights(paths)
+}
+
+func TestReachDepth(t *testing.T) {
+	Convey("depth of the longest shortest path", t, func() {
+		mockMarket := mockChainMarket()
+
+		actual := reachDepth("1", &mockMarket, mockMarket.RebaseNeighbors(), nil)
+
+		So(actual, ShouldEqual, 3)
+	})
+	Convey("unreachable pairs don't count", t, func() {
+		mockMarket := mockChainMarket()
+		mockPairD := m.Pair{
+			BaseAssetId:  "5",
+			QuoteAssetId: "6",
+		}
+		mockMarket.PairsById[mockPairD.Id()] = mockPairD
+
+		actual := reachDepth("1", &mockMarket, mockMarket.RebaseNeighbors(), nil)
+
+		So(actual, ShouldEqual, 3)
+	})
+}
+
+func TestRebaseMarketAutoDepth(t *testing.T) {
+	Convey("stops at the depth that reaches every pair if deeper paths don't change anything", t, func() {
+		mockMarket := mockChainMarket()
+
+		actualMarket, actualDepth, _ := RebaseMarketAutoDepth("1", &mockMarket, AutoDepth{}, Options{})
+
+		So(actualDepth, ShouldEqual, 3)
+		So(actualMarket, ShouldResemble, RebaseMarket("1", 3, &mockMarket))
+	})
+	Convey("goes deeper while deeper paths change rates", t, func() {
+		mockMarket := mockShortcutMarket()
+
+		actualMarket, actualDepth, _ := RebaseMarketAutoDepth("1", &mockMarket, AutoDepth{}, Options{})
+
+		So(actualDepth, ShouldEqual, 3)
+		So(actualMarket, ShouldResemble, RebaseMarket("1", 3, &mockMarket))
+	})
+	Convey("doesn't go deeper than the maximum depth", t, func() {
+		mockMarket := mockChainMarket()
+
+		_, actualDepth, _ := RebaseMarketAutoDepth("1", &mockMarket, AutoDepth{MaxDepth: 2}, Options{})
+
+		So(actualDepth, ShouldEqual, 2)
+	})
+	Convey("counts the paths of all depths against one budget", t, func() {
+		mockMarket := mockShortcutMarket()
+		pathsAt := func(depth uint8) int {
+			budget := newBudgetTracker(context.Background(), Budget{})
+			_, err := NewEngine().rebaseAtDepth("1", depth, &mockMarket, budget)
+			So(err, ShouldBeNil)
+			return budget.paths
+		}
+		options := Options{Budget: Budget{MaxPaths: pathsAt(2) + pathsAt(3) - 1}}
+
+		_, actualDepth, err := RebaseMarketAutoDepth("1", &mockMarket, AutoDepth{}, options)
+
+		So(err, ShouldBeNil)
+		So(actualDepth, ShouldEqual, 2)
+	})
+	Convey("counts the paths of deeper depths limited by the time budget against a shared budget", t, func() {
+		mockMarket := mockShortcutMarket()
+		pathsAt := func(depth uint8) int {
+			budget := newBudgetTracker(context.Background(), Budget{})
+			_, err := NewEngine().rebaseAtDepth("1", depth, &mockMarket, budget)
+			So(err, ShouldBeNil)
+			return budget.paths
+		}
+		engine := NewEngine(WithAutoDepth(AutoDepth{TimeBudget: time.Hour}), WithBudget(Budget{MaxPaths: pathsAt(2) + pathsAt(3) - 1}))
+
+		actual, err := engine.SharingBudget().Rebase(context.Background(), "1", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.PathDepth, ShouldEqual, 2)
+	})
+	Convey("spends at most the budget's duration on all depths together", t, func() {
+		mockMarket := mockShortcutMarket()
+		calls := 0
+		_, _ = RebaseMarketWithOptions("1", 2, &mockMarket, Options{PathWeighter: sleepingWeighter{calls: &calls}})
+		maxDuration := 100 * time.Millisecond
+		// every depth takes most of the budget on its own
+		weighter := sleepingWeighter{sleep: maxDuration * 8 / 10 / time.Duration(calls), calls: &calls}
+		options := Options{PathWeighter: weighter, Budget: Budget{MaxDuration: maxDuration}}
+		start := time.Now()
+
+		_, actualDepth, err := RebaseMarketAutoDepth("1", &mockMarket, AutoDepth{}, options)
+
+		So(err, ShouldBeNil)
+		So(actualDepth, ShouldEqual, 2)
+		So(time.Since(start), ShouldBeLessThan, 2*maxDuration)
+	})
+	Convey("abandons a deeper depth that overruns the time budget", t, func() {
+		mockMarket := mockShortcutMarket()
+		calls := 0
+		_, _ = RebaseMarketWithOptions("1", 2, &mockMarket, Options{PathWeighter: sleepingWeighter{calls: &calls}})
+		timeBudget := 100 * time.Millisecond
+		// the first depth leaves time to start the next one, which can't finish within the time budget
+		weighter := sleepingWeighter{sleep: timeBudget * 6 / 10 / time.Duration(calls), calls: &calls}
+		start := time.Now()
+
+		_, actualDepth, err := RebaseMarketAutoDepth("1", &mockMarket, AutoDepth{TimeBudget: timeBudget}, Options{PathWeighter: weighter})
+
+		So(err, ShouldBeNil)
+		So(actualDepth, ShouldEqual, 2)
+		So(time.Since(start), ShouldBeLessThan, timeBudget+timeBudget/2)
+	})
+}
+
+func TestWithinTolerance(t *testing.T) {
+	Convey("relative changes up to the tolerance", t, func() {
+		So(withinTolerance(100, 100.1, 0.001), ShouldBeTrue)
+		So(withinTolerance(100, 101, 0.001), ShouldBeFalse)
+	})
+	Convey("newly rebased rates never are within tolerance", t, func() {
+		So(withinTolerance(0, 0, 0.001), ShouldBeTrue)
+		So(withinTolerance(0, 1, 0.001), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,211 @@
+package rebasing
+
+import (
+	"context"
+	"fmt"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+/**
+Rebases markets with a configuration built from functional options.
+Without options, an engine chooses its path depth automatically, weighs paths by volume, uses all market data, has no
+budget and rebases one pair at a time.
+*/
+type Engine struct {
+	maxPathDepth uint8
+	// nil when rebasing at maxPathDepth
+	autoDepth   *AutoDepth
+	concurrency int
+	options     Options
+	// fits prices to all pairs at once instead of rebasing along paths
+	leastSquares bool
+	// budget that all runs count against together, nil when every run has a budget of its own
+	sharedBudget *budgetTracker
+}
+
+type Option func(*Engine)
+
+/**
+Outcome of a rebase.
+*/
+type Result struct {
+	Market *m.Market
+	// path depth the market was rebased at
+	PathDepth uint8
+	// symbols of the pegs that the rates of rebased pairs rely on, by pair id, only for pairs that rely on any
+	PeggedPairs map[string][]string
+	// symbols of the reference rates that the rates of rebased pairs rely on, by pair id, only for pairs that rely on any
+	ReferencedPairs map[string][]string
+	// with a basket, the price of one unit of each of its assets in the basket
+	BasketPrices map[string]float32
+	// with least squares, the fitted price of one unit of every asset connected to the rebase asset
+	Prices map[string]float32
+	// with least squares, the log of every fitted pair's mid minus the log of the mid that the fitted prices imply
+	Residuals map[string]float32
+}
+
+func NewEngine(opts ...Option) *Engine {
+	engine := Engine{
+		autoDepth:   &AutoDepth{},
+		concurrency: 1,
+	}
+	for _, opt := range opts {
+		opt(&engine)
+	}
+	return &engine
+}
+
+// rebases at a fixed path depth instead of choosing one automatically
+func WithMaxPathDepth(maxPathDepth uint8) Option {
+	return func(e *Engine) {
+		e.maxPathDepth = maxPathDepth
+		e.autoDepth = nil
+	}
+}
+
+func WithAutoDepth(autoDepth AutoDepth) Option {
+	return func(e *Engine) {
+		e.autoDepth = &autoDepth
+	}
+}
+
+func WithPathWeighter(pathWeighter PathWeighter) Option {
+	return func(e *Engine) {
+		e.options.PathWeighter = pathWeighter
+	}
+}
+
+func WithLiquidityLimits(liquidityLimits LiquidityLimits) Option {
+	return func(e *Engine) {
+		e.options.LiquidityLimits = liquidityLimits
+	}
+}
+
+func WithFilter(filter m.Filter) Option {
+	return func(e *Engine) {
+		e.options.Filter = filter
+	}
+}
+
+func WithReferenceRates(referenceRates ReferenceRates) Option {
+	return func(e *Engine) {
+		e.options.ReferenceRates = referenceRates
+	}
+}
+
+func WithPegs(pegs ...Peg) Option {
+	return func(e *Engine) {
+		e.options.Pegs = pegs
+	}
+}
+
+func WithBudget(budget Budget) Option {
+	return func(e *Engine) {
+		e.options.Budget = budget
+	}
+}
+
+// maximum number of pairs rebased at the same time
+func WithConcurrency(concurrency int) Option {
+	return func(e *Engine) {
+		e.concurrency = concurrency
+	}
+}
+
+// replaces all settings that Options covers at once
+func WithOptions(options Options) Option {
+	return func(e *Engine) {
+		e.options = options
+	}
+}
+
+/**
+Rebases a market in rebaseId until the context is done.
+If the context is done first, the partial result is returned along with ctx.Err().
+If the budget runs out, the partial result is returned along with an error wrapping ErrBudgetExceeded.
+*/
+func (e *Engine) Rebase(ctx context.Context, rebaseId string, market *m.Market) (*Result, error) {
+	if e.leastSquares {
+		return e.rebaseLeastSquares(ctx, rebaseId, market)
+	}
+	if e.autoDepth != nil {
+		return e.rebaseAutoDepth(ctx, rebaseId, market)
+	}
+	return e.rebaseAtDepth(rebaseId, e.maxPathDepth, market, e.budgetTracker(ctx))
+}
+
+/**
+Copy of the engine whose rebases, reconciliations and path searches all count against one budget, started now, instead
+of each against a budget of its own, e.g. for the runs that one request makes on the same market. Every run still stops
+when its own context is done.
+*/
+func (e *Engine) SharingBudget() *Engine {
+	engine := *e
+	engine.sharedBudget = newBudgetTracker(context.Background(), e.options.Budget)
+	return &engine
+}
+
+// tracker of the budget of a single run, which counts against the shared budget if the engine has one
+func (e *Engine) budgetTracker(ctx context.Context) *budgetTracker {
+	if e.sharedBudget != nil {
+		return e.sharedBudget.forRebase(ctx)
+	}
+	return newBudgetTracker(ctx, e.options.Budget)
+}
+
+/**
+Market of the pairs that a rebase in rebaseId goes through, the filtered market along with the synthetic pairs of the
+engine's reference rates and pegs, the hop market of its pairs within the liquidity limits, and the neighbors that its
+rebase paths follow through them.
+*/
+func (e *Engine) Graph(rebaseId string, market *m.Market) (*m.Market, *m.Market, map[string]m.Neighbors) {
+	graph, hopMarket, rebaseNeighbors, _ := rebaseGraph(rebaseId, market, e.options)
+	return graph, hopMarket, rebaseNeighbors
+}
+
+/**
+Rebase paths of a pair, each a list of pair ids from a pair based in rebaseId to the pair, as a rebase finds them at the
+engine's path depth. With an automatic path depth, they're the paths at the depth that reaches every pair.
+*/
+func (e *Engine) Paths(ctx context.Context, rebaseId string, pairId string, market *m.Market) ([][]string, error) {
+	depth := e.maxPathDepth
+	if e.autoDepth != nil {
+		filteredMarket, _, rebaseNeighbors, syntheticPairIds := rebaseGraph(rebaseId, market, e.options)
+		depth = reachDepth(rebaseId, filteredMarket, rebaseNeighbors, syntheticPairIds)
+		if maxDepth := e.MaxPathDepth(); depth > maxDepth {
+			depth = maxDepth
+		}
+	}
+	run := newRebaseRun(ctx, rebaseId, depth, market, e.options)
+	run.budget = e.budgetTracker(ctx)
+	if _, ok := run.market.PairsById[pairId]; !ok {
+		return nil, fmt.Errorf(`no pair "%s" in the market`, pairId)
+	}
+	paths := run.pairRebasePaths(pairId)
+	return append(paths.Base, paths.Quote...), run.budget.error()
+}
+
+// length of the longest rebase paths, the maximum depth when choosing the depth automatically
+func (e *Engine) MaxPathDepth() uint8 {
+	if e.autoDepth == nil {
+		return e.maxPathDepth
+	}
+	if e.autoDepth.MaxDepth == 0 {
+		return DefaultAutoDepthMaxDepth
+	}
+	return e.autoDepth.MaxDepth
+}
+
+// rebases at the depth within the budget, which may be shared with rebases at other depths
+func (e *Engine) rebaseAtDepth(rebaseId string, maxPathDepth uint8, market *m.Market, budget *budgetTracker) (*Result, error) {
+	run := newRebaseRun(budget.ctx, rebaseId, maxPathDepth, market, e.options)
+	run.budget = budget
+	rebasedMarket, err := run.rebase(e.concurrency)
+	return &Result{
+		Market:          rebasedMarket,
+		PathDepth:       maxPathDepth,
+		PeggedPairs:     run.peggedPairs,
+		ReferencedPairs: run.referencedPairs,
+	}, err
+}
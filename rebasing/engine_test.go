@@ -0,0 +1,159 @@
+package rebasing
+
+import (
+	"context"
+	"errors"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestNewEngine(t *testing.T) {
+	Convey("chooses the path depth automatically by default", t, func() {
+		actual := NewEngine()
+
+		So(actual.autoDepth, ShouldResemble, &AutoDepth{})
+		So(actual.concurrency, ShouldEqual, 1)
+		So(actual.options, ShouldResemble, Options{})
+	})
+	Convey("a fixed path depth turns off automatic depth", t, func() {
+		actual := NewEngine(WithAutoDepth(AutoDepth{MaxDepth: 4}), WithMaxPathDepth(3))
+
+		So(actual.autoDepth, ShouldBeNil)
+		So(actual.maxPathDepth, ShouldEqual, 3)
+	})
+	Convey("options are applied in order", t, func() {
+		filter := m.Filter{ExcludeAssets: []string{"1"}}
+		budget := Budget{MaxPaths: 5}
+
+		actual := NewEngine(
+			WithOptions(Options{Budget: Budget{MaxPaths: 1}}),
+			WithPathWeighter(EqualWeighter{}),
+			WithLiquidityLimits(LiquidityLimits{MinExchanges: 2}),
+			WithFilter(filter),
+			WithBudget(budget),
+			WithConcurrency(4),
+		)
+
+		So(actual.options, ShouldResemble, Options{
+			PathWeighter:    EqualWeighter{},
+			LiquidityLimits: LiquidityLimits{MinExchanges: 2},
+			Filter:          filter,
+			Budget:          budget,
+		})
+		So(actual.concurrency, ShouldEqual, 4)
+	})
+}
+
+func TestEngine_Rebase(t *testing.T) {
+	Convey("rebases at a fixed path depth like RebaseMarket", t, func() {
+		mockMarket := mockChainMarket()
+
+		actual, err := NewEngine(WithMaxPathDepth(2)).Rebase(context.Background(), "1", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.PathDepth, ShouldEqual, 2)
+		So(actual.Market, ShouldResemble, RebaseMarket("1", 2, &mockMarket))
+	})
+	Convey("reports the automatically chosen path depth", t, func() {
+		mockMarket := mockChainMarket()
+
+		actual, err := NewEngine().Rebase(context.Background(), "1", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.PathDepth, ShouldEqual, 3)
+		So(actual.Market, ShouldResemble, RebaseMarket("1", 3, &mockMarket))
+	})
+	Convey("rebases concurrently with the same results", t, func() {
+		mockMarket := mockChainMarket()
+
+		actual, err := NewEngine(WithMaxPathDepth(3), WithConcurrency(8)).Rebase(context.Background(), "1", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Market, ShouldResemble, RebaseMarket("1", 3, &mockMarket))
+	})
+}
+
+func TestEngine_Graph(t *testing.T) {
+	Convey("includes the synthetic pairs of pegs along with their neighbors", t, func() {
+		mockMarket := mockMarketOf(mockRatePair("USDT", "ETH", 2000))
+
+		actual, actualHops, actualNeighbors := NewEngine(WithPegs(Peg{AssetId: "USDT", PeggedTo: "USD"})).Graph("USD", &mockMarket)
+
+		usdUsdt := m.Pair{BaseAssetId: "USD", QuoteAssetId: "USDT"}
+		So(actual.PairsById, ShouldContainKey, usdUsdt.Id())
+		So(actualHops.PairsById, ShouldContainKey, usdUsdt.Id())
+		So(actualNeighbors[usdUsdt.Id()].Quote, ShouldHaveLength, 2)
+	})
+}
+
+func TestEngine_Paths(t *testing.T) {
+	usdEur := mockRatePair("USD", "EUR", 1.1)
+	eurGbp := mockRatePair("EUR", "GBP", 1.2)
+
+	Convey("finds the rebase paths of a pair", t, func() {
+		mockMarket := mockMarketOf(usdEur, eurGbp)
+
+		actual, err := NewEngine(WithMaxPathDepth(3)).Paths(context.Background(), "USD", eurGbp.Id(), &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, [][]string{{usdEur.Id(), eurGbp.Id()}})
+	})
+	Convey("finds them at the depth that reaches every pair with an automatic depth", t, func() {
+		mockMarket := mockMarketOf(usdEur, eurGbp, mockRatePair("GBP", "EUR", 0.8))
+
+		actual, err := NewEngine().Paths(context.Background(), "USD", eurGbp.Id(), &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldHaveLength, 1)
+	})
+	Convey("fails for pairs that aren't in the market", t, func() {
+		mockMarket := mockMarketOf(usdEur)
+
+		_, err := NewEngine().Paths(context.Background(), "USD", eurGbp.Id(), &mockMarket)
+
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestEngine_MaxPathDepth(t *testing.T) {
+	Convey("is the fixed depth or the automatic depth's maximum", t, func() {
+		So(NewEngine(WithMaxPathDepth(4)).MaxPathDepth(), ShouldEqual, 4)
+		So(NewEngine().MaxPathDepth(), ShouldEqual, DefaultAutoDepthMaxDepth)
+		So(NewEngine(WithAutoDepth(AutoDepth{MaxDepth: 2})).MaxPathDepth(), ShouldEqual, 2)
+	})
+}
+
+func TestEngine_SharingBudget(t *testing.T) {
+	// a rebase of the market goes through 7 paths, a reconciliation through 4
+	engine := NewEngine(WithMaxPathDepth(3), WithBudget(Budget{MaxPaths: 7}))
+
+	Convey("counts the paths of all runs against one budget", t, func() {
+		mockMarket := mockReconcileMarket()
+		shared := engine.SharingBudget()
+
+		_, err := shared.Rebase(context.Background(), "USD", &mockMarket)
+		So(err, ShouldBeNil)
+
+		_, err = shared.Reconcile(context.Background(), "USD", &mockMarket)
+		So(errors.Is(err, ErrBudgetExceeded), ShouldBeTrue)
+	})
+	Convey("leaves the engine it copies with a budget for every run", t, func() {
+		mockMarket := mockReconcileMarket()
+		engine.SharingBudget()
+
+		_, err := engine.Rebase(context.Background(), "USD", &mockMarket)
+		So(err, ShouldBeNil)
+
+		_, err = engine.Reconcile(context.Background(), "USD", &mockMarket)
+		So(err, ShouldBeNil)
+	})
+	Convey("stops a run when its own context is done", t, func() {
+		mockMarket := mockReconcileMarket()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := engine.SharingBudget().Rebase(ctx, "USD", &mockMarket)
+		So(err, ShouldEqual, context.Canceled)
+	})
+}
@@ -0,0 +1,339 @@
+package rebasing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+// returned, wrapped, when a rebaser is asked to change a pair that isn't in its market
+var ErrUnknownPair = errors.New("no pair in market")
+
+/**
+Keeps a rebased market up to date as single pairs change, without rebasing the whole market again.
+The rebaser keeps its graph, the rebase paths of every pair and an index of which pairs each pair's rates depend on.
+A change only recomputes the paths of pairs that could route through the changed pair, and the rates of pairs that
+depend on it. Every change returns the rebased pairs that changed.
+When a budget or context cuts a change short, the pairs it didn't get to are kept stale and rebased by the next change.
+A Rebaser isn't safe for concurrent use.
+*/
+type Rebaser struct {
+	run    *rebaseRun
+	filter m.Filter
+	limits LiquidityLimits
+	budget Budget
+	paths  map[string]rebasePathsType
+	// ids of the pairs each pair's rebased rates are looked up from, including ids of pairs that don't exist (yet)
+	dependencies map[string][]string
+	// ids of the pairs whose rebased rates are looked up from each pair id
+	dependents map[string]map[string]bool
+	// rebase neighbors of the run, kept in sync with the pairs and which of them are hops
+	neighbors *m.NeighborIndex
+	// ids of the pairs a change that was cut short didn't rebase, true for those whose paths need to be found again
+	stale map[string]bool
+}
+
+/**
+Rebases the market and returns a Rebaser to keep it up to date.
+An engine that chooses its path depth automatically fixes the depth it chose for the initial market. Which pegs are
+used is decided for the initial market as well.
+*/
+func (e *Engine) NewRebaser(ctx context.Context, rebaseId string, market *m.Market) (*Rebaser, error) {
+	maxPathDepth := e.maxPathDepth
+	if e.autoDepth != nil {
+		result, err := e.rebaseAutoDepth(ctx, rebaseId, market)
+		if err != nil {
+			return nil, err
+		}
+		maxPathDepth = result.PathDepth
+	}
+
+	rebaser := Rebaser{
+		run:          newRebaseRun(ctx, rebaseId, maxPathDepth, market, e.options),
+		filter:       e.options.Filter,
+		limits:       e.options.LiquidityLimits,
+		budget:       e.options.Budget,
+		paths:        map[string]rebasePathsType{},
+		dependencies: map[string][]string{},
+		dependents:   map[string]map[string]bool{},
+		neighbors:    m.NewNeighborIndex(),
+		stale:        map[string]bool{},
+	}
+	pairIds := map[string]bool{}
+	for pairId, pair := range rebaser.run.market.PairsById {
+		_, isHop := rebaser.run.hopMarket.PairsById[pairId]
+		rebaser.neighbors.Connect(pair, isHop)
+		pairIds[pairId] = true
+	}
+	rebaser.run.rebaseNeighbors = rebaser.neighbors.Neighbors()
+	_, err := rebaser.rebasePairs(ctx, pairIds, nil)
+	return &rebaser, err
+}
+
+// path depth the rebaser rebases at
+func (r *Rebaser) PathDepth() uint8 {
+	return r.run.maxPathDepth
+}
+
+// copy of the current rebased market
+func (r *Rebaser) Market() *m.Market {
+	rebasedMarket := m.Market{PairsById: map[string]m.Pair{}}
+	for pairId, pair := range r.run.rebasedMarket.PairsById {
+		rebasedMarket.PairsById[pairId] = pair
+	}
+	return &rebasedMarket
+}
+
+// symbols of the pegs that the rates of rebased pairs rely on, by pair id, only for pairs that rely on any
+func (r *Rebaser) PeggedPairs() map[string][]string {
+	peggedPairs := map[string][]string{}
+	for pairId, pegSymbols := range r.run.peggedPairs {
+		peggedPairs[pairId] = pegSymbols
+	}
+	return peggedPairs
+}
+
+// symbols of the reference rates that the rates of rebased pairs rely on, by pair id, only for pairs that rely on any
+func (r *Rebaser) ReferencedPairs() map[string][]string {
+	referencedPairs := map[string][]string{}
+	for pairId, referenceSymbols := range r.run.referencedPairs {
+		referencedPairs[pairId] = referenceSymbols
+	}
+	return referencedPairs
+}
+
+/**
+Replaces the pair's exchange market with the same exchange id, or adds it if the pair has none.
+Exchange markets the filter doesn't allow are ignored. Fails with ErrUnknownPair if the market has no such pair.
+*/
+func (r *Rebaser) UpdateExchangeMarket(ctx context.Context, pairId string, exchangeMarket m.ExchangeMarket) ([]m.Pair, error) {
+	pair, ok := r.run.market.PairsById[pairId]
+	if !ok {
+		return nil, fmt.Errorf(`%w: no pair with id "%s"`, ErrUnknownPair, pairId)
+	}
+	if !r.filter.AllowsExchange(exchangeMarket.ExchangeId) {
+		return nil, nil
+	}
+	// market data replaces a synthetic pair of a reference rate or peg
+	if r.run.syntheticPairIds[pairId] {
+		pair.ExchangeMarkets = nil
+	}
+
+	updatedPair := m.Pair{
+		BaseAssetId:  pair.BaseAssetId,
+		QuoteAssetId: pair.QuoteAssetId,
+	}
+	updated := false
+	for _, emd := range pair.ExchangeMarkets {
+		if emd.ExchangeId == exchangeMarket.ExchangeId {
+			emd = exchangeMarket
+			updated = true
+		}
+		updatedPair.ExchangeMarkets = append(updatedPair.ExchangeMarkets, emd)
+	}
+	if !updated {
+		updatedPair.ExchangeMarkets = append(updatedPair.ExchangeMarkets, exchangeMarket)
+	}
+	return r.setPair(ctx, pairId, updatedPair)
+}
+
+/**
+Adds the pair, or replaces the pair with the same id. Market data the filter doesn't allow is ignored.
+*/
+func (r *Rebaser) AddPair(ctx context.Context, pair m.Pair) ([]m.Pair, error) {
+	pairId := pair.Id()
+	singlePairMarket := m.Market{PairsById: map[string]m.Pair{pairId: pair}}
+	filteredPair, ok := singlePairMarket.Filter(r.filter).PairsById[pairId]
+	if !ok {
+		return nil, nil
+	}
+	return r.setPair(ctx, pairId, filteredPair)
+}
+
+/**
+Removes the pair and returns it, as it was in the market, along with the rebased pairs that changed without it.
+Fails with ErrUnknownPair if the market has no such pair.
+*/
+func (r *Rebaser) RemovePair(ctx context.Context, pairId string) (m.Pair, []m.Pair, error) {
+	removedPair, ok := r.run.market.PairsById[pairId]
+	if !ok {
+		return m.Pair{}, nil, fmt.Errorf(`%w: no pair with id "%s"`, ErrUnknownPair, pairId)
+	}
+
+	affectedPairIds := copySet(r.dependents[pairId])
+	delete(affectedPairIds, pairId)
+
+	r.neighbors.Disconnect(pairId)
+	r.indexPaths(pairId, nil)
+	delete(r.paths, pairId)
+	delete(r.run.market.PairsById, pairId)
+	delete(r.run.hopMarket.PairsById, pairId)
+	delete(r.run.rebasedMarket.PairsById, pairId)
+	delete(r.run.peggedPairs, pairId)
+	delete(r.run.referencedPairs, pairId)
+	delete(r.run.syntheticPairIds, pairId)
+
+	changedPairs, err := r.rebasePairs(ctx, affectedPairIds, nil)
+	return removedPair, changedPairs, err
+}
+
+func (r *Rebaser) setPair(ctx context.Context, pairId string, pair m.Pair) ([]m.Pair, error) {
+	_, existed := r.run.market.PairsById[pairId]
+	_, wasHop := r.run.hopMarket.PairsById[pairId]
+	isHop := r.limits.Allows(pair)
+
+	delete(r.run.syntheticPairIds, pairId)
+	r.run.market.PairsById[pairId] = pair
+	if isHop {
+		r.run.hopMarket.PairsById[pairId] = pair
+	} else {
+		delete(r.run.hopMarket.PairsById, pairId)
+	}
+
+	// rates that depend on the pair change, paths only change if the graph does
+	pathPairIds := map[string]bool{pairId: true}
+	ratePairIds := copySet(r.dependents[pairId])
+	if !existed || wasHop != isHop {
+		r.neighbors.Connect(pair, isHop)
+		for reachingPairId := range r.reachingPairs(pairId) {
+			pathPairIds[reachingPairId] = true
+		}
+		for dependentPairId := range r.dependents[pairId] {
+			pathPairIds[dependentPairId] = true
+		}
+	}
+	return r.rebasePairs(ctx, pathPairIds, ratePairIds)
+}
+
+/**
+Rebases the given pairs along with the stale ones, finding new paths for pathPairIds first, and returns the rebased pairs
+that changed. Pairs it doesn't get to when the budget runs out are left stale.
+*/
+func (r *Rebaser) rebasePairs(ctx context.Context, pathPairIds map[string]bool, ratePairIds map[string]bool) ([]m.Pair, error) {
+	r.run.budget = newBudgetTracker(ctx, r.budget)
+
+	pathPairIds = copySet(pathPairIds)
+	pairIds := copySet(pathPairIds)
+	for pairId := range ratePairIds {
+		pairIds[pairId] = true
+	}
+	for pairId, pathsStale := range r.stale {
+		pairIds[pairId] = true
+		if pathsStale {
+			pathPairIds[pairId] = true
+		}
+	}
+
+	var changedPairs []m.Pair
+	for pairId := range pairIds {
+		if _, ok := r.run.market.PairsById[pairId]; !ok || r.run.syntheticPairIds[pairId] {
+			delete(r.stale, pairId)
+			continue
+		}
+		if r.run.budget.exceeded() {
+			r.stale[pairId] = r.stale[pairId] || pathPairIds[pairId]
+			continue
+		}
+		if pathPairIds[pairId] {
+			rebasePaths := r.run.pairRebasePaths(pairId)
+			if r.run.budget.exceeded() {
+				r.stale[pairId] = true
+				continue
+			}
+			r.indexPaths(pairId, &rebasePaths)
+		}
+		delete(r.stale, pairId)
+
+		previousPair, existed := r.run.rebasedMarket.PairsById[pairId]
+		r.run.rebasePairAlong(pairId, r.paths[pairId])
+		rebasedPair := r.run.rebasedMarket.PairsById[pairId]
+		if !existed || !reflect.DeepEqual(previousPair, rebasedPair) {
+			changedPairs = append(changedPairs, rebasedPair)
+		}
+	}
+
+	sort.Slice(changedPairs, func(i, j int) bool {
+		return changedPairs[i].Symbol() < changedPairs[j].Symbol()
+	})
+	return changedPairs, r.run.budget.error()
+}
+
+// stores the pair's paths and indexes the pairs its rates depend on, nil paths remove the pair from the index
+func (r *Rebaser) indexPaths(pairId string, rebasePaths *rebasePathsType) {
+	for _, dependencyId := range r.dependencies[pairId] {
+		delete(r.dependents[dependencyId], pairId)
+	}
+	delete(r.dependencies, pairId)
+	if rebasePaths == nil {
+		return
+	}
+
+	r.paths[pairId] = *rebasePaths
+	dependencyIds := map[string]bool{pairId: true}
+	for _, paths := range [][][]string{rebasePaths.Base, rebasePaths.Quote} {
+		for _, path := range paths {
+			for _, hopId := range path {
+				hop := r.run.market.PairsById[hopId]
+				dependencyIds[hopId] = true
+				dependencyIds[pairIdOf(hop.QuoteAssetId, hop.BaseAssetId)] = true
+				dependencyIds[pairIdOf(r.run.rebaseId, hop.BaseAssetId)] = true
+				dependencyIds[pairIdOf(r.run.rebaseId, hop.QuoteAssetId)] = true
+			}
+		}
+	}
+	for dependencyId := range dependencyIds {
+		r.dependencies[pairId] = append(r.dependencies[pairId], dependencyId)
+		if r.dependents[dependencyId] == nil {
+			r.dependents[dependencyId] = map[string]bool{}
+		}
+		r.dependents[dependencyId][pairId] = true
+	}
+}
+
+// pairs that could have a rebase path through the pair
+func (r *Rebaser) reachingPairs(pairId string) map[string]bool {
+	reachingPairIds := map[string]bool{}
+	for _, direction := range []rebaseDirection{BASE, QUOTE} {
+		visited := map[string]bool{pairId: true}
+		frontier := []string{pairId}
+		for depth := 1; depth < int(r.run.maxPathDepth) && len(frontier) > 0; depth++ {
+			var nextFrontier []string
+			for _, frontierPairId := range frontier {
+				previous := r.neighbors.PreviousNeighbors(frontierPairId)
+				previousPairIds := previous.Base
+				if direction == QUOTE {
+					previousPairIds = previous.Quote
+				}
+				for _, previousPairId := range previousPairIds {
+					if !visited[previousPairId] {
+						visited[previousPairId] = true
+						reachingPairIds[previousPairId] = true
+						nextFrontier = append(nextFrontier, previousPairId)
+					}
+				}
+			}
+			frontier = nextFrontier
+		}
+	}
+	return reachingPairIds
+}
+
+func pairIdOf(baseId string, quoteId string) string {
+	pair := m.Pair{
+		BaseAssetId:  baseId,
+		QuoteAssetId: quoteId,
+	}
+	return pair.Id()
+}
+
+func copySet(set map[string]bool) map[string]bool {
+	result := map[string]bool{}
+	for key := range set {
+		result[key] = true
+	}
+	return result
+}
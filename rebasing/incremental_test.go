@@ -0,0 +1,202 @@
+package rebasing
+
+import (
+	"context"
+	"errors"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func mockRebaser(market *m.Market, opts ...Option) *Rebaser {
+	rebaser, err := NewEngine(append([]Option{WithMaxPathDepth(3)}, opts...)...).NewRebaser(context.Background(), "1", market)
+	So(err, ShouldBeNil)
+	return rebaser
+}
+
+func symbols(pairs []m.Pair) []string {
+	result := []string{}
+	for _, pair := range pairs {
+		result = append(result, pair.Symbol())
+	}
+	return result
+}
+
+func TestEngine_NewRebaser(t *testing.T) {
+	Convey("rebases the initial market like RebaseMarket", t, func() {
+		mockMarket := mockChainMarket()
+
+		actual := mockRebaser(&mockMarket)
+
+		So(actual.Market(), ShouldResemble, RebaseMarket("1", 3, &mockMarket))
+	})
+	Convey("fixes the automatically chosen path depth", t, func() {
+		mockMarket := mockChainMarket()
+
+		actual, err := NewEngine().NewRebaser(context.Background(), "1", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.run.maxPathDepth, ShouldEqual, 3)
+	})
+}
+
+func TestRebaser_UpdateExchangeMarket(t *testing.T) {
+	mockPairA := m.Pair{BaseAssetId: "1", QuoteAssetId: "2"}
+	mockPairC := m.Pair{BaseAssetId: "3", QuoteAssetId: "4"}
+
+	Convey("updating a pair every path goes through changes every pair", t, func() {
+		mockMarket := mockChainMarket()
+		rebaser := mockRebaser(&mockMarket)
+		exchangeMarket := m.ExchangeMarket{CurrentBid: 5, CurrentAsk: 5, BaseVolume: 1}
+
+		actual, err := rebaser.UpdateExchangeMarket(context.Background(), mockPairA.Id(), exchangeMarket)
+
+		So(err, ShouldBeNil)
+		So(symbols(actual), ShouldResemble, []string{"1/2", "2/3", "3/4"})
+		mockPairA.ExchangeMarkets = []m.ExchangeMarket{exchangeMarket}
+		mockMarket.PairsById[mockPairA.Id()] = mockPairA
+		So(rebaser.Market(), ShouldResemble, RebaseMarket("1", 3, &mockMarket))
+	})
+	Convey("updating a pair no path goes through only changes that pair", t, func() {
+		mockMarket := mockChainMarket()
+		rebaser := mockRebaser(&mockMarket)
+		exchangeMarket := m.ExchangeMarket{CurrentBid: 5, CurrentAsk: 5, BaseVolume: 1}
+
+		actual, err := rebaser.UpdateExchangeMarket(context.Background(), mockPairC.Id(), exchangeMarket)
+
+		So(err, ShouldBeNil)
+		So(symbols(actual), ShouldResemble, []string{"3/4"})
+		So(actual[0].ExchangeMarkets[0].CurrentBid, ShouldEqual, 30)
+	})
+	Convey("pairs an update cut short didn't get to are rebased by the next update", t, func() {
+		mockMarket := mockChainMarket()
+		rebaser := mockRebaser(&mockMarket)
+		exchangeMarketA := m.ExchangeMarket{CurrentBid: 5, CurrentAsk: 5, BaseVolume: 1}
+		exchangeMarketC := m.ExchangeMarket{CurrentBid: 4, CurrentAsk: 4, BaseVolume: 1}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		cutShort, err := rebaser.UpdateExchangeMarket(ctx, mockPairA.Id(), exchangeMarketA)
+		So(err, ShouldEqual, context.Canceled)
+		So(cutShort, ShouldBeEmpty)
+		actual, err := rebaser.UpdateExchangeMarket(context.Background(), mockPairC.Id(), exchangeMarketC)
+
+		So(err, ShouldBeNil)
+		So(symbols(actual), ShouldResemble, []string{"1/2", "2/3", "3/4"})
+		mockPairA.ExchangeMarkets = []m.ExchangeMarket{exchangeMarketA}
+		mockPairC.ExchangeMarkets = []m.ExchangeMarket{exchangeMarketC}
+		mockMarket.PairsById[mockPairA.Id()] = mockPairA
+		mockMarket.PairsById[mockPairC.Id()] = mockPairC
+		So(rebaser.Market(), ShouldResemble, RebaseMarket("1", 3, &mockMarket))
+	})
+	Convey("adds exchange markets of new exchanges", t, func() {
+		mockMarket := mockChainMarket()
+		rebaser := mockRebaser(&mockMarket)
+		exchangeMarket := m.ExchangeMarket{ExchangeId: "a", CurrentBid: 4, CurrentAsk: 4, BaseVolume: 1}
+
+		actual, err := rebaser.UpdateExchangeMarket(context.Background(), mockPairC.Id(), exchangeMarket)
+
+		So(err, ShouldBeNil)
+		So(actual[0].ExchangeMarkets, ShouldHaveLength, 2)
+	})
+	Convey("ignores exchanges the filter doesn't allow", t, func() {
+		mockMarket := mockChainMarket()
+		rebaser := mockRebaser(&mockMarket, WithFilter(m.Filter{ExcludeExchanges: []string{"a"}}))
+		exchangeMarket := m.ExchangeMarket{ExchangeId: "a", CurrentBid: 5, CurrentAsk: 5, BaseVolume: 1}
+
+		actual, err := rebaser.UpdateExchangeMarket(context.Background(), mockPairA.Id(), exchangeMarket)
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldBeEmpty)
+	})
+	Convey("pairs that become liquid enough to rebase through change the paths of other pairs", t, func() {
+		mockMarket := mockChainMarket()
+		rebaser := mockRebaser(&mockMarket, WithLiquidityLimits(LiquidityLimits{MinCombinedVolume: 1}))
+		illiquid := m.ExchangeMarket{CurrentBid: 3, CurrentAsk: 3, BaseVolume: 0.5}
+		liquid := m.ExchangeMarket{CurrentBid: 3, CurrentAsk: 3, BaseVolume: 2}
+		mockPairB := m.Pair{BaseAssetId: "2", QuoteAssetId: "3"}
+		options := Options{LiquidityLimits: LiquidityLimits{MinCombinedVolume: 1}}
+
+		_, err := rebaser.UpdateExchangeMarket(context.Background(), mockPairB.Id(), illiquid)
+		So(err, ShouldBeNil)
+		mockPairB.ExchangeMarkets = []m.ExchangeMarket{illiquid}
+		mockMarket.PairsById[mockPairB.Id()] = mockPairB
+		expected, _ := RebaseMarketWithOptions("1", 3, &mockMarket, options)
+		So(rebaser.Market(), ShouldResemble, expected)
+
+		_, err = rebaser.UpdateExchangeMarket(context.Background(), mockPairB.Id(), liquid)
+		So(err, ShouldBeNil)
+		mockPairB.ExchangeMarkets = []m.ExchangeMarket{liquid}
+		mockMarket.PairsById[mockPairB.Id()] = mockPairB
+		expected, _ = RebaseMarketWithOptions("1", 3, &mockMarket, options)
+		So(rebaser.Market(), ShouldResemble, expected)
+	})
+	Convey("unknown pair", t, func() {
+		mockMarket := mockChainMarket()
+		rebaser := mockRebaser(&mockMarket)
+
+		actual, err := rebaser.UpdateExchangeMarket(context.Background(), "foo", m.ExchangeMarket{})
+
+		So(actual, ShouldBeNil)
+		So(errors.Is(err, ErrUnknownPair), ShouldBeTrue)
+	})
+}
+
+func TestRebaser_AddPair(t *testing.T) {
+	Convey("a new pair is rebased and becomes part of other pairs' paths", t, func() {
+		mockMarket := mockChainMarket()
+		rebaser := mockRebaser(&mockMarket)
+		mockPairShortcut := m.Pair{
+			BaseAssetId:  "1",
+			QuoteAssetId: "3",
+			ExchangeMarkets: []m.ExchangeMarket{
+				{
+					CurrentBid: 8,
+					CurrentAsk: 8,
+					BaseVolume: 1,
+				},
+			},
+		}
+
+		actual, err := rebaser.AddPair(context.Background(), mockPairShortcut)
+
+		So(err, ShouldBeNil)
+		So(symbols(actual), ShouldResemble, []string{"1/3", "3/4"})
+		mockMarket.PairsById[mockPairShortcut.Id()] = mockPairShortcut
+		So(rebaser.Market(), ShouldResemble, RebaseMarket("1", 3, &mockMarket))
+	})
+	Convey("pairs the filter doesn't allow are ignored", t, func() {
+		mockMarket := mockChainMarket()
+		rebaser := mockRebaser(&mockMarket, WithFilter(m.Filter{ExcludeAssets: []string{"5"}}))
+
+		actual, err := rebaser.AddPair(context.Background(), m.Pair{BaseAssetId: "4", QuoteAssetId: "5"})
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldBeEmpty)
+		So(rebaser.Market().PairsById, ShouldHaveLength, 3)
+	})
+}
+
+func TestRebaser_RemovePair(t *testing.T) {
+	Convey("pairs that were rebased through the removed pair are rebased without it", t, func() {
+		mockMarket := mockChainMarket()
+		rebaser := mockRebaser(&mockMarket)
+		mockPairB := m.Pair{BaseAssetId: "2", QuoteAssetId: "3"}
+
+		removed, actual, err := rebaser.RemovePair(context.Background(), mockPairB.Id())
+
+		So(err, ShouldBeNil)
+		So(removed.Symbol(), ShouldEqual, "2/3")
+		So(symbols(actual), ShouldResemble, []string{"3/4"})
+		delete(mockMarket.PairsById, mockPairB.Id())
+		So(rebaser.Market(), ShouldResemble, RebaseMarket("1", 3, &mockMarket))
+	})
+	Convey("unknown pair", t, func() {
+		mockMarket := mockChainMarket()
+		rebaser := mockRebaser(&mockMarket)
+
+		_, _, err := rebaser.RemovePair(context.Background(), "foo")
+
+		So(errors.Is(err, ErrUnknownPair), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,236 @@
+package rebasing
+
+import (
+	"context"
+	"math"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+// number of times pairs are weighed again by their liquidity at the prices of the previous fit
+const leastSquaresReweightings = 2
+
+// relative residual at which the conjugate gradient solve of a fit stops
+const leastSquaresTolerance = 1e-10
+
+/**
+Rebases markets by fitting one price per asset to the mids of all pairs at once, instead of averaging paths per pair.
+The logarithms of the prices are fitted by weighted least squares: every pair asks for the log price of its quote asset
+minus that of its base asset to equal the log of its mid, weighted by the pair's combined base volume valued in the
+rebase asset. The rebase asset is pinned to a log price of 0. The first fit weighs all pairs equally, since volumes can
+only be valued once there are prices, and every later fit weighs them by their volume at the prices of the previous
+one. The prices are mutually consistent, and each pair's residual shows how far its own mid is off from them.
+*/
+func WithLeastSquares() Option {
+	return func(e *Engine) {
+		e.leastSquares = true
+	}
+}
+
+// single pair's equation in a fit, with variable index -1 for the pinned rebase asset
+type logPriceEquation struct {
+	pairId string
+	base   int
+	quote  int
+	logMid float64
+	// combined base volume, in the base asset
+	volume float64
+	weight float64
+}
+
+// weighted least squares fit of the log prices of all assets connected to the rebase asset
+type logPriceFit struct {
+	assetIds  []string
+	equations []logPriceEquation
+	logPrices []float64
+}
+
+func (e *Engine) rebaseLeastSquares(ctx context.Context, rebaseId string, market *m.Market) (*Result, error) {
+	budget := e.budgetTracker(ctx)
+	filteredMarket, hopMarket, _, syntheticPairIds := rebaseGraph(rebaseId, market, e.options)
+	fit := newLogPriceFit(rebaseId, precedentHopMarket(rebaseId, hopMarket, syntheticPairIds))
+	for i := 0; i <= leastSquaresReweightings && !budget.exceeded(); i++ {
+		if i > 0 {
+			fit.reweigh()
+		}
+		fit.solve(budget)
+	}
+
+	prices := map[string]float32{rebaseId: 1}
+	for i, assetId := range fit.assetIds {
+		prices[assetId] = float32(math.Exp(fit.logPrices[i]))
+	}
+	residuals := map[string]float32{}
+	for _, equation := range fit.equations {
+		if !syntheticPairIds[equation.pairId] {
+			residuals[equation.pairId] = float32(fit.residual(equation))
+		}
+	}
+
+	rebasedMarket := m.Market{PairsById: map[string]m.Pair{}}
+	for pairId, pair := range filteredMarket.PairsById {
+		baseAssetRate, ok := prices[pair.BaseAssetId]
+		if !ok || syntheticPairIds[pairId] {
+			continue
+		}
+		rebasedPair := m.Pair{
+			BaseAssetId:  pair.BaseAssetId,
+			QuoteAssetId: pair.QuoteAssetId,
+		}
+		for _, emd := range pair.ExchangeMarkets {
+			rebasedPair.ExchangeMarkets = append(rebasedPair.ExchangeMarkets, m.ExchangeMarket{
+				ExchangeId:  emd.ExchangeId,
+				CurrentBid:  emd.CurrentBid * baseAssetRate,
+				CurrentAsk:  emd.CurrentAsk * baseAssetRate,
+				BaseVolume:  rebaseVolume(emd.BaseVolume, baseAssetRate),
+				QuoteVolume: rebaseVolume(emd.QuoteVolume, emd.SpreadAverage()*baseAssetRate),
+			})
+		}
+		rebasedMarket.PairsById[pairId] = rebasedPair
+	}
+	return &Result{Market: &rebasedMarket, Prices: prices, Residuals: residuals}, budget.error()
+}
+
+/**
+Fit of the pairs with a mid and volume that connect assets to the rebase asset. Assets no such pair connects to the
+rebase asset have no price in it, and would leave the fit without a unique solution.
+*/
+func newLogPriceFit(rebaseId string, market *m.Market) *logPriceFit {
+	var pairs []m.Pair
+	pricedMarket := m.Market{PairsById: map[string]m.Pair{}}
+	for pairId, pair := range market.PairsById {
+		if pair.BaseVolumeWeightedSpreadAverage() > 0 && pair.CombinedBaseVolume() > 0 {
+			pairs = append(pairs, pair)
+			pricedMarket.PairsById[pairId] = pair
+		}
+	}
+
+	fit := logPriceFit{}
+	indexes := map[string]int{rebaseId: -1}
+	for assetId := range connectedAssets(rebaseId, &pricedMarket) {
+		if assetId != rebaseId {
+			indexes[assetId] = len(fit.assetIds)
+			fit.assetIds = append(fit.assetIds, assetId)
+		}
+	}
+	for _, pair := range pairs {
+		base, ok := indexes[pair.BaseAssetId]
+		if !ok {
+			continue
+		}
+		fit.equations = append(fit.equations, logPriceEquation{
+			pairId: pair.Id(),
+			base:   base,
+			quote:  indexes[pair.QuoteAssetId],
+			logMid: math.Log(float64(pair.BaseVolumeWeightedSpreadAverage())),
+			volume: float64(pair.CombinedBaseVolume()),
+			weight: 1,
+		})
+	}
+	fit.logPrices = make([]float64, len(fit.assetIds))
+	return &fit
+}
+
+func (f *logPriceFit) logPrice(index int) float64 {
+	if index < 0 {
+		return 0
+	}
+	return f.logPrices[index]
+}
+
+// log of the pair's mid minus the log of the mid that the fitted prices imply
+func (f *logPriceFit) residual(equation logPriceEquation) float64 {
+	return equation.logMid - (f.logPrice(equation.quote) - f.logPrice(equation.base))
+}
+
+// weighs every pair by its combined base volume, valued in the rebase asset at the current prices
+func (f *logPriceFit) reweigh() {
+	for i, equation := range f.equations {
+		f.equations[i].weight = equation.volume * math.Exp(f.logPrice(equation.base))
+	}
+}
+
+/**
+Solves the normal equations of the fit, a weighted graph Laplacian without the rebase asset's row and column, with the
+Jacobi preconditioned conjugate gradient method, starting from the current log prices.
+*/
+func (f *logPriceFit) solve(budget *budgetTracker) {
+	n := len(f.assetIds)
+	if n == 0 {
+		return
+	}
+	rhs := make([]float64, n)
+	diagonal := make([]float64, n)
+	for _, equation := range f.equations {
+		addAt(rhs, equation.quote, equation.weight*equation.logMid)
+		addAt(rhs, equation.base, -equation.weight*equation.logMid)
+		addAt(diagonal, equation.quote, equation.weight)
+		addAt(diagonal, equation.base, equation.weight)
+	}
+
+	residual := f.multiply(f.logPrices)
+	for i := range residual {
+		residual[i] = rhs[i] - residual[i]
+	}
+	preconditioned := make([]float64, n)
+	for i := range residual {
+		if diagonal[i] > 0 {
+			preconditioned[i] = residual[i] / diagonal[i]
+		}
+	}
+	direction := append([]float64{}, preconditioned...)
+	rho := dot(residual, preconditioned)
+	threshold := leastSquaresTolerance * math.Sqrt(dot(rhs, rhs))
+
+	for iteration := 0; iteration < 10*n+100; iteration++ {
+		if math.Sqrt(dot(residual, residual)) <= threshold || rho == 0 || budget.exceeded() {
+			return
+		}
+		product := f.multiply(direction)
+		step := rho / dot(direction, product)
+		for i := range f.logPrices {
+			f.logPrices[i] += step * direction[i]
+			residual[i] -= step * product[i]
+			if diagonal[i] > 0 {
+				preconditioned[i] = residual[i] / diagonal[i]
+			}
+		}
+		nextRho := dot(residual, preconditioned)
+		for i := range direction {
+			direction[i] = preconditioned[i] + nextRho/rho*direction[i]
+		}
+		rho = nextRho
+	}
+}
+
+// product of the fit's normal matrix and a vector of log prices
+func (f *logPriceFit) multiply(logPrices []float64) []float64 {
+	product := make([]float64, len(logPrices))
+	at := func(index int) float64 {
+		if index < 0 {
+			return 0
+		}
+		return logPrices[index]
+	}
+	for _, equation := range f.equations {
+		difference := equation.weight * (at(equation.quote) - at(equation.base))
+		addAt(product, equation.quote, difference)
+		addAt(product, equation.base, -difference)
+	}
+	return product
+}
+
+// adds to the vector's element at the index, unless it's the pinned rebase asset
+func addAt(vector []float64, index int, value float64) {
+	if index >= 0 {
+		vector[index] += value
+	}
+}
+
+func dot(a []float64, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,102 @@
+package rebasing
+
+import (
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+func TestEngine_RebaseLeastSquares(t *testing.T) {
+	usdEur := mockRatePair("USD", "EUR", 1.1)
+	eurGbp := mockRatePair("EUR", "GBP", 1.2)
+
+	Convey("fits the prices of a consistent market exactly", t, func() {
+		mockMarket := mockMarketOf(usdEur, eurGbp, mockRatePair("USD", "GBP", 1.32))
+
+		actual, err := NewEngine(WithLeastSquares()).Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Prices["USD"], ShouldEqual, 1)
+		So(actual.Prices["EUR"], ShouldAlmostEqual, 1.1, 0.0001)
+		So(actual.Prices["GBP"], ShouldAlmostEqual, 1.32, 0.0001)
+		So(actual.Residuals, ShouldHaveLength, 3)
+		for _, residual := range actual.Residuals {
+			So(residual, ShouldAlmostEqual, 0, 0.0001)
+		}
+	})
+	Convey("rebases the rates of every exchange at the fitted price of the pair's base asset", t, func() {
+		mockMarket := mockMarketOf(usdEur, eurGbp)
+
+		actual, err := NewEngine(WithLeastSquares()).Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.PathDepth, ShouldEqual, 0)
+		eurGbpEmd := actual.Market.PairsById[eurGbp.Id()].ExchangeMarkets[0]
+		So(eurGbpEmd.CurrentBid, ShouldAlmostEqual, 1.32, 0.0001)
+		So(eurGbpEmd.BaseVolume, ShouldAlmostEqual, 110, 0.01)
+	})
+	Convey("weighs pairs by liquidity and reports their residuals", t, func() {
+		usdGbp := mockRatePair("USD", "GBP", 1.5)
+		usdGbp.ExchangeMarkets[0].BaseVolume = 1000000
+		mockMarket := mockMarketOf(usdEur, eurGbp, usdGbp)
+
+		actual, err := NewEngine(WithLeastSquares()).Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Prices["GBP"], ShouldAlmostEqual, 1.5, 0.001)
+		So(actual.Residuals[usdGbp.Id()], ShouldAlmostEqual, 0, 0.001)
+		cycle := math.Log(1.1) + math.Log(1.2) - math.Log(1.5)
+		So(actual.Residuals[usdEur.Id()]+actual.Residuals[eurGbp.Id()], ShouldAlmostEqual, cycle, 0.001)
+	})
+	Convey("leaves out assets that aren't connected to the rebase asset", t, func() {
+		jpyKrw := mockRatePair("JPY", "KRW", 9)
+		mockMarket := mockMarketOf(usdEur, jpyKrw)
+
+		actual, err := NewEngine(WithLeastSquares()).Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Prices, ShouldNotContainKey, "JPY")
+		So(actual.Market.PairsById, ShouldNotContainKey, jpyKrw.Id())
+		So(actual.Residuals, ShouldNotContainKey, jpyKrw.Id())
+	})
+	Convey("fits through pegs without reporting their residuals", t, func() {
+		usdtEth := mockRatePair("USDT", "ETH", 2000)
+		mockMarket := mockMarketOf(usdtEth)
+
+		actual, err := NewEngine(WithLeastSquares(), WithPegs(Peg{AssetId: "USDT", PeggedTo: "USD"})).
+			Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Prices["ETH"], ShouldAlmostEqual, 2000, 0.1)
+		So(actual.Market.PairsById, ShouldHaveLength, 1)
+		So(actual.Residuals, ShouldHaveLength, 1)
+	})
+	Convey("stops when the context is done", t, func() {
+		mockMarket := mockMarketOf(usdEur, eurGbp)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := NewEngine(WithLeastSquares()).Rebase(ctx, "USD", &mockMarket)
+
+		So(err, ShouldEqual, context.Canceled)
+	})
+}
+
+func TestLogPriceFit(t *testing.T) {
+	Convey("spreads the inconsistency of a cycle of equally weighted pairs evenly", t, func() {
+		mockMarket := mockMarketOf(
+			mockRatePair("USD", "EUR", 1.1),
+			mockRatePair("EUR", "GBP", 1.2),
+			mockRatePair("USD", "GBP", 1.5),
+		)
+		fit := newLogPriceFit("USD", &mockMarket)
+
+		fit.solve(nil)
+
+		cycle := math.Log(1.1) + math.Log(1.2) - math.Log(1.5)
+		for _, equation := range fit.equations {
+			So(math.Abs(fit.residual(equation)), ShouldAlmostEqual, math.Abs(cycle)/3, 0.000001)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package rebasing
+
+import (
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+/**
+Minimum liquidity a pair needs to be used as a hop in a rebase path or as a conversion pair.
+Pairs that don't meet these limits are still rebased themselves. Zero values don't limit anything.
+*/
+type LiquidityLimits struct {
+	// combined base volume over all exchanges, in the pair's base asset
+	MinCombinedVolume float32
+	MinExchanges      int
+	// base volume weighted spread relative to the pair's base volume weighted spread average
+	MaxRelativeSpread float32
+}
+
+func (l LiquidityLimits) Allows(pair m.Pair) bool {
+	if pair.CombinedBaseVolume() < l.MinCombinedVolume {
+		return false
+	}
+	if len(pair.ExchangeMarkets) < l.MinExchanges {
+		return false
+	}
+	if l.MaxRelativeSpread > 0 && pair.BaseVolumeWeightedRelativeSpread() > l.MaxRelativeSpread {
+		return false
+	}
+	return true
+}
+
+// market of all pairs that are liquid enough to rebase through
+func hopMarket(market *m.Market, limits LiquidityLimits) *m.Market {
+	liquidMarket := m.Market{PairsById: map[string]m.Pair{}}
+	for pairId, pair := range market.PairsById {
+		if limits.Allows(pair) {
+			liquidMarket.PairsById[pairId] = pair
+		}
+	}
+	return &liquidMarket
+}
+
+// neighbors of every pair in market, restricted to pairs in hopMarket
+func hopNeighbors(rebaseNeighbors map[string]m.Neighbors, hopMarket *m.Market) map[string]m.Neighbors {
+	result := map[string]m.Neighbors{}
+	for pairId, neighbors := range rebaseNeighbors {
+		result[pairId] = m.Neighbors{
+			Base:  pairsInMarket(neighbors.Base, hopMarket),
+			Quote: pairsInMarket(neighbors.Quote, hopMarket),
+		}
+	}
+	return result
+}
+
+func pairsInMarket(pairIds []string, market *m.Market) []string {
+	result := []string{}
+	for _, pairId := range pairIds {
+		if _, ok := market.PairsById[pairId]; ok {
+			result = append(result, pairId)
+		}
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package rebasing
+
+import (
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestLiquidityLimits_Allows(t *testing.T) {
+	mockPair := m.Pair{
+		BaseAssetId:  "1",
+		QuoteAssetId: "2",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 9,
+				CurrentAsk: 11,
+				BaseVolume: 5,
+			},
+		},
+	}
+
+	Convey("zero limits allow any pair", t, func() {
+		So(LiquidityLimits{}.Allows(mockPair), ShouldBeTrue)
+		So(LiquidityLimits{}.Allows(m.Pair{}), ShouldBeTrue)
+	})
+	Convey("pair below minimum combined volume", t, func() {
+		So(LiquidityLimits{MinCombinedVolume: 5}.Allows(mockPair), ShouldBeTrue)
+		So(LiquidityLimits{MinCombinedVolume: 6}.Allows(mockPair), ShouldBeFalse)
+	})
+	Convey("pair below minimum number of exchanges", t, func() {
+		So(LiquidityLimits{MinExchanges: 1}.Allows(mockPair), ShouldBeTrue)
+		So(LiquidityLimits{MinExchanges: 2}.Allows(mockPair), ShouldBeFalse)
+	})
+	Convey("pair above maximum relative spread", t, func() {
+		So(LiquidityLimits{MaxRelativeSpread: 0.2}.Allows(mockPair), ShouldBeTrue)
+		So(LiquidityLimits{MaxRelativeSpread: 0.1}.Allows(mockPair), ShouldBeFalse)
+	})
+}
+
+func TestRebaseMarketWithLiquidityLimits(t *testing.T) {
+	mockPairA := m.Pair{
+		BaseAssetId:  "1",
+		QuoteAssetId: "2",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 2,
+				CurrentAsk: 2,
+				BaseVolume: 100,
+			},
+		},
+	}
+	mockPairB := m.Pair{
+		BaseAssetId:  "2",
+		QuoteAssetId: "3",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 3,
+				CurrentAsk: 3,
+				BaseVolume: 100,
+			},
+		},
+	}
+	// dust market with a wildly different price for "3"
+	mockPairDust := m.Pair{
+		BaseAssetId:  "1",
+		QuoteAssetId: "3",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 50,
+				CurrentAsk: 50,
+				BaseVolume: 0.01,
+			},
+		},
+	}
+	mockPairC := m.Pair{
+		BaseAssetId:  "3",
+		QuoteAssetId: "4",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 1,
+				CurrentAsk: 1,
+				BaseVolume: 1,
+			},
+		},
+	}
+	mockMarket := m.Market{
+		PairsById: map[string]m.Pair{
+			mockPairA.Id():    mockPairA,
+			mockPairB.Id():    mockPairB,
+			mockPairDust.Id(): mockPairDust,
+			mockPairC.Id():    mockPairC,
+		},
+	}
+
+	Convey("without limits the dust market takes part in rebasing", t, func() {
+		actualMarket, _ := RebaseMarketWithOptions("1", 3, &mockMarket, Options{})
+		actual := actualMarket.PairsById[mockPairC.Id()].ExchangeMarkets[0].CurrentBid
+
+		So(actual, ShouldBeGreaterThan, float32(6))
+	})
+	Convey("pairs below the limits aren't used as hops", t, func() {
+		options := Options{LiquidityLimits: LiquidityLimits{MinCombinedVolume: 1}}
+		actualMarket, _ := RebaseMarketWithOptions("1", 3, &mockMarket, options)
+		actual := actualMarket.PairsById[mockPairC.Id()].ExchangeMarkets[0].CurrentBid
+
+		So(actual, ShouldEqual, float32(6))
+	})
+	Convey("pairs below the limits are still rebased themselves", t, func() {
+		options := Options{LiquidityLimits: LiquidityLimits{MinCombinedVolume: 1}}
+		actualMarket, _ := RebaseMarketWithOptions("1", 3, &mockMarket, options)
+		actual := actualMarket.PairsById[mockPairDust.Id()].ExchangeMarkets[0].CurrentBid
+
+		So(actual, ShouldEqual, float32(50))
+	})
+}
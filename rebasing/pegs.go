@@ -0,0 +1,136 @@
+package rebasing
+
+import (
+	"fmt"
+	"math"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+// exchange id of the exchange markets of the synthetic pairs pegs insert
+const PegExchangeId = "peg"
+
+// volume a peg counts with when weighing paths through pegs against each other, unless it has one of its own
+const DefaultPegVolume = float32(1)
+
+/**
+Assumption that an asset trades at a fixed ratio to another, e.g. a stablecoin to the currency it tracks.
+A peg inserts synthetic pairs between both assets into the graph that rebase paths are found in, so paths that would
+dead-end at the pegged asset can continue. Markets that trade both assets against each other stay as they are. Paths
+through pegs are only used for pairs that no path avoiding pegs reaches.
+*/
+type Peg struct {
+	AssetId  string
+	PeggedTo string
+	// price of one unit of AssetId in PeggedTo, defaults to 1
+	Ratio float32
+	// relative band around the ratio that the synthetic pairs quote their bid and ask at, at least 0 and less than 1.
+	// When a pair of both assets trades outside of it, the peg counts as broken and isn't used. Zero quotes the ratio
+	// and never breaks the peg.
+	Tolerance float32
+	// only use the peg if no market path connects the pegged asset to the rebase asset
+	Fallback bool
+	// volume the synthetic pairs count with when weighing paths through pegs against each other, in PeggedTo, defaults
+	// to DefaultPegVolume
+	Volume float32
+}
+
+/**
+Fails for pegs whose synthetic pairs can't be quoted: a negative ratio, or a tolerance that isn't at least 0 and less
+than 1, at which the bid of the synthetic pair into PeggedTo would drop to zero or below.
+*/
+func (p Peg) Validate() error {
+	if p.Ratio < 0 {
+		return fmt.Errorf(`peg of "%s" to "%s" has a negative ratio`, p.AssetId, p.PeggedTo)
+	}
+	if !(p.Tolerance >= 0 && p.Tolerance < 1) {
+		return fmt.Errorf(`peg of "%s" to "%s" has a tolerance outside of [0, 1)`, p.AssetId, p.PeggedTo)
+	}
+	return nil
+}
+
+func (p Peg) ratio() float32 {
+	if p.Ratio <= 0 {
+		return 1
+	}
+	return p.Ratio
+}
+
+func (p Peg) volume() float32 {
+	if p.Volume <= 0 {
+		return DefaultPegVolume
+	}
+	return p.Volume
+}
+
+// whether a pair of both assets trades within the peg's tolerance, if there's one
+func (p Peg) holds(market *m.Market) bool {
+	if p.Tolerance <= 0 {
+		return true
+	}
+	if pair, ok := market.PairsById[pairIdOf(p.PeggedTo, p.AssetId)]; ok {
+		if mid := pair.BaseVolumeWeightedSpreadAverage(); mid > 0 && !p.within(mid) {
+			return false
+		}
+	}
+	if pair, ok := market.PairsById[pairIdOf(p.AssetId, p.PeggedTo)]; ok {
+		if mid := pair.BaseVolumeWeightedSpreadAverage(); mid > 0 && !p.within(1/mid) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p Peg) within(price float32) bool {
+	return math.Abs(float64(price/p.ratio()-1)) <= float64(p.Tolerance)
+}
+
+// synthetic pairs of the peg in both directions, quoted at the ratio within the tolerance band, none if it's invalid
+func (p Peg) pairs() []m.Pair {
+	if p.Validate() != nil {
+		return nil
+	}
+	ratio := p.ratio()
+	return []m.Pair{
+		{
+			BaseAssetId:  p.PeggedTo,
+			QuoteAssetId: p.AssetId,
+			ExchangeMarkets: []m.ExchangeMarket{{
+				ExchangeId: PegExchangeId,
+				CurrentBid: ratio * (1 - p.Tolerance),
+				CurrentAsk: ratio * (1 + p.Tolerance),
+				BaseVolume: p.volume(),
+			}},
+		},
+		{
+			BaseAssetId:  p.AssetId,
+			QuoteAssetId: p.PeggedTo,
+			ExchangeMarkets: []m.ExchangeMarket{{
+				ExchangeId: PegExchangeId,
+				CurrentBid: 1 / (ratio * (1 + p.Tolerance)),
+				CurrentAsk: 1 / (ratio * (1 - p.Tolerance)),
+				BaseVolume: p.volume() / ratio,
+			}},
+		},
+	}
+}
+
+/**
+Adds the synthetic pairs of all pegs that hold to the market, the hop market and syntheticPairIds.
+Invalid pegs, pegs of assets the filter doesn't allow, and fallback pegs of assets that market paths connect to the rebase asset, are
+left out.
+*/
+func addPegs(pegs []Peg, market *m.Market, hopMarket *m.Market, filter m.Filter, connectedAssetIds map[string]bool, syntheticPairIds map[string]bool) {
+	for _, peg := range pegs {
+		if peg.Validate() != nil || !filter.AllowsAsset(peg.AssetId) || !filter.AllowsAsset(peg.PeggedTo) || peg.AssetId == peg.PeggedTo {
+			continue
+		}
+		if peg.Fallback && connectedAssetIds[peg.AssetId] {
+			continue
+		}
+		if !peg.holds(market) {
+			continue
+		}
+		addSyntheticPairs(peg.pairs(), market, hopMarket, syntheticPairIds)
+	}
+}
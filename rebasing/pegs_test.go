@@ -0,0 +1,170 @@
+package rebasing
+
+import (
+	"context"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestEngine_RebaseWithPegs(t *testing.T) {
+	usdtEth := mockRatePair("USDT", "ETH", 2000)
+	usdEur := mockRatePair("USD", "EUR", 1.1)
+
+	Convey("continues paths that dead-end at a pegged asset", t, func() {
+		mockMarket := mockMarketOf(usdtEth, usdEur)
+
+		actual, err := NewEngine(WithMaxPathDepth(2), WithPegs(Peg{AssetId: "USDT", PeggedTo: "USD"})).
+			Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Market.PairsById, ShouldHaveLength, 2)
+		So(actual.Market.PairsById[usdtEth.Id()].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 2000, 0.01)
+		So(actual.PeggedPairs, ShouldResemble, map[string][]string{usdtEth.Id(): {"USD/USDT"}})
+	})
+	Convey("pegs at a fixed ratio", t, func() {
+		mockMarket := mockMarketOf(usdtEth)
+
+		actual, err := NewEngine(WithMaxPathDepth(2), WithPegs(Peg{AssetId: "USDT", PeggedTo: "USD", Ratio: 0.5})).
+			Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Market.PairsById[usdtEth.Id()].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 1000, 0.01)
+	})
+	Convey("doesn't flag pairs that don't rely on a peg", t, func() {
+		mockMarket := mockMarketOf(usdtEth, usdEur)
+
+		actual, err := NewEngine(WithMaxPathDepth(2), WithPegs(Peg{AssetId: "USDT", PeggedTo: "USD"})).
+			Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.PeggedPairs, ShouldNotContainKey, usdEur.Id())
+	})
+	Convey("market paths take precedence over pegs whatever the weighter", t, func() {
+		// USDT is worth 1.32 USD through the market, and 1 USD through the peg
+		mockMarket := mockMarketOf(usdtEth, usdEur, mockRatePair("EUR", "USDT", 1.2))
+		peg := Peg{AssetId: "USDT", PeggedTo: "USD"}
+
+		for _, weighter := range []PathWeighter{VolumeWeighter{}, EqualWeighter{}, ShortestPathWeighter{}, BottleneckWeighter{}} {
+			actual, err := NewEngine(WithMaxPathDepth(3), WithPathWeighter(weighter), WithPegs(peg)).
+				Rebase(context.Background(), "USD", &mockMarket)
+
+			So(err, ShouldBeNil)
+			So(actual.Market.PairsById[usdtEth.Id()].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 2640, 0.1)
+			So(actual.PeggedPairs, ShouldBeEmpty)
+		}
+
+		actual, err := NewEngine(WithLeastSquares(), WithPegs(peg)).Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Market.PairsById[usdtEth.Id()].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 2640, 0.1)
+	})
+	Convey("invalid pegs don't bridge paths", t, func() {
+		usdBtc := mockRatePair("USD", "BTC", 0.00002)
+		invalidPegs := []Peg{
+			{AssetId: "USDT", PeggedTo: "USD", Tolerance: 1},
+			{AssetId: "USDT", PeggedTo: "USD", Tolerance: 1.5},
+			{AssetId: "USDT", PeggedTo: "USD", Ratio: -1},
+		}
+		for _, peg := range invalidPegs {
+			mockMarket := mockMarketOf(usdBtc)
+
+			actual, err := NewEngine(WithMaxPathDepth(2), WithPegs(peg)).Rebase(context.Background(), "USDT", &mockMarket)
+
+			So(err, ShouldBeNil)
+			So(actual.Market.PairsById[usdBtc.Id()].ExchangeMarkets, ShouldResemble, []m.ExchangeMarket{{}})
+			So(actual.PeggedPairs, ShouldBeEmpty)
+		}
+	})
+	Convey("without pegs nothing is flagged", t, func() {
+		mockMarket := mockMarketOf(usdtEth, usdEur)
+
+		actual, err := NewEngine(WithMaxPathDepth(2)).Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.PeggedPairs, ShouldBeEmpty)
+	})
+}
+
+func TestAddPegs(t *testing.T) {
+	usdtEth := mockRatePair("USDT", "ETH", 2000)
+	peg := Peg{AssetId: "USDT", PeggedTo: "USD", Tolerance: 0.01}
+	addPegsWith := func(pegs []Peg, market *m.Market, hopMarket *m.Market, filter m.Filter) map[string]bool {
+		syntheticPairIds := map[string]bool{}
+		addPegs(pegs, market, hopMarket, filter, connectedAssets("USD", hopMarket), syntheticPairIds)
+		return syntheticPairIds
+	}
+	addPegsTo := func(market m.Market, pegs ...Peg) map[string]bool {
+		hopMarket := hopMarket(&market, LiquidityLimits{})
+		return addPegsWith(pegs, &market, hopMarket, m.Filter{})
+	}
+
+	Convey("adds synthetic pairs in both directions quoted within the tolerance", t, func() {
+		market := mockMarketOf(usdtEth)
+		hopMarket := hopMarket(&market, LiquidityLimits{MinCombinedVolume: 1000})
+
+		actual := addPegsWith([]Peg{peg}, &market, hopMarket, m.Filter{})
+
+		So(actual, ShouldHaveLength, 2)
+		forward := market.PairsById[pairIdOf("USD", "USDT")]
+		So(forward.ExchangeMarkets[0].ExchangeId, ShouldEqual, PegExchangeId)
+		So(forward.ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 0.99, 0.0001)
+		So(forward.ExchangeMarkets[0].CurrentAsk, ShouldAlmostEqual, 1.01, 0.0001)
+		So(hopMarket.PairsById, ShouldContainKey, pairIdOf("USDT", "USD"))
+	})
+	Convey("never replaces market pairs", t, func() {
+		usdUsdt := mockRatePair("USD", "USDT", 1.005)
+		market := mockMarketOf(usdtEth, usdUsdt)
+
+		actual := addPegsTo(market, peg)
+
+		So(actual, ShouldResemble, map[string]bool{pairIdOf("USDT", "USD"): true})
+		So(market.PairsById[usdUsdt.Id()], ShouldResemble, usdUsdt)
+	})
+	Convey("broken pegs aren't used", t, func() {
+		for _, pair := range []m.Pair{mockRatePair("USD", "USDT", 0.9), mockRatePair("USDT", "USD", 1.1)} {
+			So(addPegsTo(mockMarketOf(usdtEth, pair), peg), ShouldBeEmpty)
+		}
+	})
+	Convey("fallback pegs are only used without a market path", t, func() {
+		fallbackPeg := Peg{AssetId: "USDT", PeggedTo: "USD", Fallback: true}
+
+		So(addPegsTo(mockMarketOf(usdtEth), fallbackPeg), ShouldHaveLength, 2)
+		connectedMarket := mockMarketOf(usdtEth, mockRatePair("USD", "EUR", 1.1), mockRatePair("USDT", "EUR", 0.9))
+		So(addPegsTo(connectedMarket, fallbackPeg), ShouldBeEmpty)
+	})
+	Convey("invalid pegs aren't used", t, func() {
+		for _, invalidPeg := range []Peg{{AssetId: "USDT", PeggedTo: "USD", Tolerance: 1}, {AssetId: "USDT", PeggedTo: "USD", Ratio: -1}} {
+			So(invalidPeg.Validate(), ShouldNotBeNil)
+			So(invalidPeg.pairs(), ShouldBeEmpty)
+			So(addPegsTo(mockMarketOf(usdtEth), invalidPeg), ShouldBeEmpty)
+		}
+		So(peg.Validate(), ShouldBeNil)
+	})
+	Convey("pegs of filtered assets aren't used", t, func() {
+		market := mockMarketOf(usdtEth)
+		hopMarket := hopMarket(&market, LiquidityLimits{})
+
+		actual := addPegsWith([]Peg{peg}, &market, hopMarket, m.Filter{ExcludeAssets: []string{"USD"}})
+
+		So(actual, ShouldBeEmpty)
+	})
+}
+
+func TestRebaser_Pegs(t *testing.T) {
+	Convey("market data replaces a peg", t, func() {
+		usdtEth := mockRatePair("USDT", "ETH", 2000)
+		mockMarket := mockMarketOf(usdtEth)
+		rebaser, err := NewEngine(WithMaxPathDepth(2), WithPegs(Peg{AssetId: "USDT", PeggedTo: "USD"})).
+			NewRebaser(context.Background(), "USD", &mockMarket)
+		So(err, ShouldBeNil)
+		So(rebaser.PeggedPairs(), ShouldContainKey, usdtEth.Id())
+
+		changedPairs, err := rebaser.AddPair(context.Background(), mockRatePair("USD", "USDT", 0.5))
+
+		So(err, ShouldBeNil)
+		So(symbols(changedPairs), ShouldResemble, []string{"USD/USDT", "USDT/ETH"})
+		So(rebaser.Market().PairsById[usdtEth.Id()].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 1000, 0.01)
+		So(rebaser.PeggedPairs(), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,54 @@
+package rebasing
+
+import (
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+/**
+Price of one unit of every asset in the rebase asset, read from a market rebased in rebaseId.
+A rebased pair's mid is the price of its quote asset in the rebase asset, so an asset is priced by the rebased mids of
+the pairs it's quoted in, weighted by their rebased base volumes. The rebase asset itself is priced at 1.
+Assets that are only traded as base asset, or only in pairs without volume or rate, aren't priced.
+*/
+func AssetPrices(rebasedMarket *m.Market, rebaseId string) map[string]float32 {
+	return assetPricesAt(rebasedMarket, rebaseId, func(pair *m.Pair) float32 {
+		return (pair.BaseVolumeWeightedCurrentBidSum() + pair.BaseVolumeWeightedCurrentAskSum()) / 2
+	})
+}
+
+/**
+Like AssetPrices, but from the rebased bids instead of the mids, the conservative price of holding every asset.
+*/
+func AssetBidPrices(rebasedMarket *m.Market, rebaseId string) map[string]float32 {
+	return assetPricesAt(rebasedMarket, rebaseId, (*m.Pair).BaseVolumeWeightedCurrentBidSum)
+}
+
+/**
+Like AssetPrices, but from the rebased asks instead of the mids, the optimistic price of holding every asset.
+*/
+func AssetAskPrices(rebasedMarket *m.Market, rebaseId string) map[string]float32 {
+	return assetPricesAt(rebasedMarket, rebaseId, (*m.Pair).BaseVolumeWeightedCurrentAskSum)
+}
+
+// prices from the rates of every pair, summed weighted by base volume
+func assetPricesAt(rebasedMarket *m.Market, rebaseId string, weightedRateSum func(pair *m.Pair) float32) map[string]float32 {
+	weightedSums := map[string]float32{}
+	combinedWeights := map[string]float32{}
+	for _, pair := range rebasedMarket.PairsById {
+		weightedSum := weightedRateSum(&pair)
+		volume := pair.CombinedBaseVolume()
+		if weightedSum <= 0 || volume <= 0 {
+			continue
+		}
+		weightedSums[pair.QuoteAssetId] += weightedSum
+		combinedWeights[pair.QuoteAssetId] += volume
+	}
+
+	prices := map[string]float32{rebaseId: 1}
+	for assetId, weightedSum := range weightedSums {
+		if assetId != rebaseId {
+			prices[assetId] = weightedSum / combinedWeights[assetId]
+		}
+	}
+	return prices
+}
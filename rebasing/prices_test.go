@@ -0,0 +1,70 @@
+package rebasing
+
+import (
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestAssetPrices(t *testing.T) {
+	Convey("prices every asset that's quoted somewhere", t, func() {
+		mockMarket := mockChainMarket()
+		rebasedMarket := RebaseMarket("1", 3, &mockMarket)
+
+		actual := AssetPrices(rebasedMarket, "1")
+
+		So(actual, ShouldResemble, map[string]float32{"1": 1, "2": 2, "3": 6, "4": 24})
+	})
+	Convey("weighs the pairs an asset is quoted in by rebased base volume", t, func() {
+		mockPairA := m.Pair{
+			BaseAssetId:  "1",
+			QuoteAssetId: "3",
+			ExchangeMarkets: []m.ExchangeMarket{
+				{
+					CurrentBid: 4,
+					CurrentAsk: 4,
+					BaseVolume: 3,
+				},
+			},
+		}
+		mockPairB := m.Pair{
+			BaseAssetId:  "2",
+			QuoteAssetId: "3",
+			ExchangeMarkets: []m.ExchangeMarket{
+				{
+					CurrentBid: 8,
+					CurrentAsk: 8,
+					BaseVolume: 1,
+				},
+			},
+		}
+		rebasedMarket := m.Market{
+			PairsById: map[string]m.Pair{
+				mockPairA.Id(): mockPairA,
+				mockPairB.Id(): mockPairB,
+			},
+		}
+
+		actual := AssetPrices(&rebasedMarket, "1")
+
+		So(actual, ShouldResemble, map[string]float32{"1": 1, "3": 5})
+	})
+}
+
+func TestAssetBidAndAskPrices(t *testing.T) {
+	Convey("price assets from the rebased bids and asks", t, func() {
+		mockPair := m.Pair{
+			BaseAssetId:  "1",
+			QuoteAssetId: "2",
+			ExchangeMarkets: []m.ExchangeMarket{
+				{CurrentBid: 2, CurrentAsk: 4, BaseVolume: 1},
+				{CurrentBid: 5, CurrentAsk: 7, BaseVolume: 2},
+			},
+		}
+		rebasedMarket := m.Market{PairsById: map[string]m.Pair{mockPair.Id(): mockPair}}
+
+		So(AssetBidPrices(&rebasedMarket, "1"), ShouldResemble, map[string]float32{"1": 1, "2": 4})
+		So(AssetAskPrices(&rebasedMarket, "1"), ShouldResemble, map[string]float32{"1": 1, "2": 6})
+		So(AssetPrices(&rebasedMarket, "1"), ShouldResemble, map[string]float32{"1": 1, "2": 5})
+	})
+}
@@ -1,6 +1,7 @@
 package rebasing
 
 import (
+	"context"
 	"fmt"
 	m "github.com/jochenboesmans/go-rebase/model/market"
 	"sync"
@@ -18,145 +19,302 @@ type rebasePathsType struct {
 	Quote [][]string
 }
 
+/**
+Optional settings for a rebase. The zero value rebases the same way RebaseMarket does.
+*/
+type Options struct {
+	// weighs the rebase paths of each pair, defaults to VolumeWeighter
+	PathWeighter PathWeighter
+	// pairs below these limits aren't used to rebase other pairs
+	LiquidityLimits LiquidityLimits
+	// market data the filter doesn't allow is neither used nor returned
+	Filter m.Filter
+	// limits the paths and time spent on the rebase
+	Budget Budget
+	// rates from outside the market that rebase paths may go through where the market has no pair
+	ReferenceRates ReferenceRates
+	// assumed fixed ratios between assets that rebase paths may go through
+	Pegs []Peg
+}
+
 func RebaseMarket(rebaseId string, maxPathDepth uint8, market *m.Market) *m.Market {
-	// shared data structures
-	rebasedMarket := m.Market{PairsById: map[string]m.Pair{}}
-	rebaseNeighbors := market.RebaseNeighbors()
+	rebasedMarket, _ := RebaseMarketWithOptions(rebaseId, maxPathDepth, market, Options{})
+	return rebasedMarket
+}
 
+/**
+Rebases a market with the given options.
+If the budget runs out, the partially rebased market is returned along with an error wrapping ErrBudgetExceeded.
+*/
+func RebaseMarketWithOptions(rebaseId string, maxPathDepth uint8, market *m.Market, options Options) (*m.Market, error) {
+	return RebaseMarketContext(context.Background(), rebaseId, maxPathDepth, market, options)
+}
+
+/**
+Rebases a market with the given options until the context is done.
+If the context is done first, the partially rebased market is returned along with ctx.Err().
+Pairs that weren't fully rebased by then are left out.
+*/
+func RebaseMarketContext(ctx context.Context, rebaseId string, maxPathDepth uint8, market *m.Market, options Options) (*m.Market, error) {
+	result, err := NewEngine(WithMaxPathDepth(maxPathDepth), WithOptions(options)).Rebase(ctx, rebaseId, market)
+	return result.Market, err
+}
+
+// state shared by all pairs during a single rebase
+type rebaseRun struct {
+	rebaseId        string
+	maxPathDepth    uint8
+	market          *m.Market
+	hopMarket       *m.Market
+	rebaseNeighbors map[string]m.Neighbors
+	weighter        PathWeighter
+	budget          *budgetTracker
+	// ids of the synthetic pairs of reference rates and pegs, which are rebased through but not rebased themselves
+	syntheticPairIds map[string]bool
+	rebasedMarket    *m.Market
+	// symbols of the pegs that rebased pairs rely on, by pair id
+	peggedPairs map[string][]string
+	// symbols of the reference rates that rebased pairs rely on, by pair id
+	referencedPairs map[string][]string
+	mutex           sync.Mutex
+}
+
+func newRebaseRun(ctx context.Context, rebaseId string, maxPathDepth uint8, market *m.Market, options Options) *rebaseRun {
+	weighter := options.PathWeighter
+	if weighter == nil {
+		weighter = VolumeWeighter{}
+	}
+	market, hopMarket, rebaseNeighbors, syntheticPairIds := rebaseGraph(rebaseId, market, options)
+	return &rebaseRun{
+		rebaseId:         rebaseId,
+		maxPathDepth:     maxPathDepth,
+		market:           market,
+		hopMarket:        hopMarket,
+		rebaseNeighbors:  rebaseNeighbors,
+		weighter:         weighter,
+		budget:           newBudgetTracker(ctx, options.Budget),
+		syntheticPairIds: syntheticPairIds,
+		rebasedMarket:    &m.Market{PairsById: map[string]m.Pair{}},
+		peggedPairs:      map[string][]string{},
+		referencedPairs:  map[string][]string{},
+	}
+}
+
+// rebases all pairs, using up to the given number of goroutines
+func (r *rebaseRun) rebase(concurrency int) (*m.Market, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	pairIds := make(chan string)
 	var waitGroup sync.WaitGroup
-	for pairId := range market.PairsById {
+	for i := 0; i < concurrency; i++ {
 		waitGroup.Add(1)
-		rebasePair(pairId, rebaseId, maxPathDepth, market, &rebasedMarket, rebaseNeighbors, &waitGroup)
+		go func() {
+			defer waitGroup.Done()
+			for pairId := range pairIds {
+				r.rebasePair(pairId)
+			}
+		}()
 	}
+	for pairId := range r.market.PairsById {
+		if r.budget.exceeded() {
+			break
+		}
+		if r.syntheticPairIds[pairId] {
+			continue
+		}
+		pairIds <- pairId
+	}
+	close(pairIds)
 	waitGroup.Wait()
-	return &rebasedMarket
+	return r.rebasedMarket, r.budget.error()
+}
+
+/**
+Filtered market, the market of pairs to rebase through and the neighbors connecting them.
+Both markets include the synthetic pairs of reference rates and pegs, whose ids are returned last. Market pairs take
+precedence over reference rates, which take precedence over pegs.
+*/
+func rebaseGraph(rebaseId string, market *m.Market, options Options) (*m.Market, *m.Market, map[string]m.Neighbors, map[string]bool) {
+	filteredMarket := market.Filter(options.Filter)
+	hopMarket := hopMarket(&filteredMarket, options.LiquidityLimits)
+	// decided before any synthetic pair is added, those don't count as market paths
+	connectedAssetIds := connectedAssets(rebaseId, hopMarket)
+	syntheticPairIds := map[string]bool{}
+	addReferenceRates(options.ReferenceRates, &filteredMarket, hopMarket, options.Filter, connectedAssetIds, syntheticPairIds)
+	addPegs(options.Pegs, &filteredMarket, hopMarket, options.Filter, connectedAssetIds, syntheticPairIds)
+	rebaseNeighbors := hopNeighbors(filteredMarket.RebaseNeighbors(), hopMarket)
+	return &filteredMarket, hopMarket, rebaseNeighbors, syntheticPairIds
 }
 
-func rebasePair(pairId string, rebaseId string, maxPathDepth uint8, market *m.Market, rebasedMarket *m.Market, rebaseNeighbors map[string]m.Neighbors, waitGroup *sync.WaitGroup) {
-	// determine all paths from the current pair to pairs based in rebaseId
-	rebasePaths := rebasePathsType{
-		Base:  rebasePaths(BASE, []string{pairId}, rebaseId, maxPathDepth, market, rebaseNeighbors),
-		Quote: rebasePaths(QUOTE, []string{pairId}, rebaseId, maxPathDepth, market, rebaseNeighbors),
+func (r *rebaseRun) rebasePair(pairId string) {
+	rebasePaths := r.pairRebasePaths(pairId)
+
+	// leave out pairs that were interrupted, their paths or rates may be incomplete
+	if r.budget.exceeded() {
+		return
+	}
+
+	r.rebasePairAlong(pairId, rebasePaths)
+}
+
+// all paths from the pair to pairs based in rebaseId
+func (r *rebaseRun) pairRebasePaths(pairId string) rebasePathsType {
+	pairBudget := r.budget.forPair()
+	return rebasePathsType{
+		Base:  r.rebasePaths(BASE, []string{pairId}, pairBudget),
+		Quote: r.rebasePaths(QUOTE, []string{pairId}, pairBudget),
 	}
+}
+
+// rebases the pair's rates along the given paths
+func (r *rebaseRun) rebasePairAlong(pairId string, rebasePaths rebasePathsType) {
+	originalMarketPair := r.market.PairsById[pairId]
+	rebasePaths = r.precedentPaths(rebasePaths)
 
-	originalMarketPair := market.PairsById[pairId]
+	// price of one unit of the pair's base asset in rebaseId, used to value volumes
+	baseAssetRate := r.deeplyRebaseRate(1, rebasePaths)
 
 	// deeply rebase all rates based on the available rebasePaths
 	var newExchangeMarkets []m.ExchangeMarket
 	for _, emd := range originalMarketPair.ExchangeMarkets {
+		if r.budget.exceeded() {
+			return
+		}
+		// price of one unit of the pair's quote asset in rebaseId, as traded on this exchange
+		quoteAssetRate := emd.SpreadAverage() * baseAssetRate
 		newExchangeMarket := m.ExchangeMarket{
-			CurrentBid: deeplyRebaseRate(emd.CurrentBid, rebaseId, rebasePaths, market),
-			CurrentAsk: deeplyRebaseRate(emd.CurrentAsk, rebaseId, rebasePaths, market),
-			BaseVolume: deeplyRebaseRate(emd.BaseVolume, rebaseId, rebasePaths, market),
+			ExchangeId:  emd.ExchangeId,
+			CurrentBid:  r.deeplyRebaseRate(emd.CurrentBid, rebasePaths),
+			CurrentAsk:  r.deeplyRebaseRate(emd.CurrentAsk, rebasePaths),
+			BaseVolume:  rebaseVolume(emd.BaseVolume, baseAssetRate),
+			QuoteVolume: rebaseVolume(emd.QuoteVolume, quoteAssetRate),
 		}
 		newExchangeMarkets = append(newExchangeMarkets, newExchangeMarket)
 	}
 
 	// copy pair data to rebased pair
-	rebasedMarket.PairsById[pairId] = m.Pair{
-		BaseAssetId:     market.PairsById[pairId].BaseAssetId,
-		QuoteAssetId:    market.PairsById[pairId].QuoteAssetId,
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.rebasedMarket.PairsById[pairId] = m.Pair{
+		BaseAssetId:     originalMarketPair.BaseAssetId,
+		QuoteAssetId:    originalMarketPair.QuoteAssetId,
 		ExchangeMarkets: newExchangeMarkets,
 	}
-
-	waitGroup.Done()
+	setSyntheticSymbols(r.peggedPairs, pairId, r.syntheticSymbols(rebasePaths, PegExchangeId))
+	setSyntheticSymbols(r.referencedPairs, pairId, r.syntheticSymbols(rebasePaths, ReferenceExchangeId))
 }
 
-func rebasePaths(direction rebaseDirection, pathAccumulator []string, rebaseId string, maxPathDepth uint8, market *m.Market, rebaseNeighbors map[string]m.Neighbors) [][]string {
-	if len(pathAccumulator) > int(maxPathDepth) {
+func (r *rebaseRun) rebasePaths(direction rebaseDirection, pathAccumulator []string, budget *pairBudgetTracker) [][]string {
+	if len(pathAccumulator) > int(r.maxPathDepth) || budget.exceeded() {
 		return [][]string{}
 	} else {
+		// every path the search goes through counts, not only those that reach rebaseId, which bounds the search itself
+		if !budget.addPath() {
+			return [][]string{}
+		}
 		lastPairId := pathAccumulator[0]
-		lastBaseId := market.PairsById[lastPairId].BaseAssetId
-		if lastBaseId == rebaseId {
+		lastBaseId := r.market.PairsById[lastPairId].BaseAssetId
+		if lastBaseId == r.rebaseId {
 			return [][]string{pathAccumulator}
 		} else {
-			return doRebasePaths(direction, pathAccumulator, rebaseId, maxPathDepth, market, rebaseNeighbors)
+			return r.doRebasePaths(direction, pathAccumulator, budget)
 		}
 	}
 }
 
-func doRebasePaths(direction rebaseDirection, pathAccumulator []string, rebaseId string, maxPathDepth uint8, market *m.Market, rebaseNeighbors map[string]m.Neighbors) [][]string {
+func (r *rebaseRun) doRebasePaths(direction rebaseDirection, pathAccumulator []string, budget *pairBudgetTracker) [][]string {
 	var nextNeighborIds []string
 	if direction == BASE {
-		nextNeighborIds = rebaseNeighbors[pathAccumulator[0]].Base
+		nextNeighborIds = r.rebaseNeighbors[pathAccumulator[0]].Base
 	} else if direction == QUOTE {
-		nextNeighborIds = rebaseNeighbors[pathAccumulator[0]].Quote
+		nextNeighborIds = r.rebaseNeighbors[pathAccumulator[0]].Quote
 	}
 
 	var result [][]string
 	for _, nextNeighborId := range nextNeighborIds {
 		nextPath := append([]string{nextNeighborId}, pathAccumulator...)
-		result = append(result, rebasePaths(direction, nextPath, rebaseId, maxPathDepth, market, rebaseNeighbors)...)
+		result = append(result, r.rebasePaths(direction, nextPath, budget)...)
 	}
 	return result
 }
 
-func deeplyRebaseRate(rate float32, rebaseId string, rebasePaths rebasePathsType, market *m.Market) float32 {
-	combinedVolume := float32(0)
-	volumeWeightedSum := float32(0)
+func (r *rebaseRun) deeplyRebaseRate(rate float32, rebasePaths rebasePathsType) float32 {
+	var rebasedRates []float32
+	var liquidities []PathLiquidity
 
 	for _, baseRebasePath := range rebasePaths.Base {
 		rebasedRateAcc := rate
-		weightedSumAcc := float32(0)
+		liquidity := PathLiquidity{Length: len(baseRebasePath)}
 		for i := len(baseRebasePath) - 2; i >= 0; i-- {
-			pair := market.PairsById[baseRebasePath[i]]
+			pair := r.market.PairsById[baseRebasePath[i]]
 			baseId := pair.BaseAssetId
 			quoteId := pair.QuoteAssetId
-			if rebasedRate, err := shallowlyRebaseRate(rebasedRateAcc, baseId, quoteId, market); err == nil {
+			if rebasedRate, err := shallowlyRebaseRate(rebasedRateAcc, baseId, quoteId, r.hopMarket); err == nil {
 				rebasedRateAcc = rebasedRate
 			}
-			combinedVolume := pair.CombinedBaseVolume()
-			if rebasedCombinedVolume, err := shallowlyRebaseRate(combinedVolume, rebaseId, baseId, market); err == nil {
-				weightedSumAcc += rebasedCombinedVolume
-			}
+			liquidity.HopVolumes = append(liquidity.HopVolumes, rebasedCombinedVolume(pair, r.rebaseId, r.hopMarket))
 		}
-		weight := weightedSumAcc / float32(len(baseRebasePath))
-		combinedVolume += weight
-		volumeWeightedSum += weight * rebasedRateAcc
+		rebasedRates = append(rebasedRates, rebasedRateAcc)
+		liquidities = append(liquidities, liquidity)
 	}
 
 	for _, quoteRebasePath := range rebasePaths.Quote {
 		rebasedRateAcc := rate
-		weightedSumAcc := float32(0)
+		liquidity := PathLiquidity{Length: len(quoteRebasePath)}
 		for i := len(quoteRebasePath) - 1; i >= 0; i-- {
-			pair := market.PairsById[quoteRebasePath[i]]
+			pair := r.market.PairsById[quoteRebasePath[i]]
 			baseId := pair.BaseAssetId
 			quoteId := pair.QuoteAssetId
 			if i == 0 {
-				combinedVolume := pair.CombinedBaseVolume()
-				if rebasedCombinedVolume, err := shallowlyRebaseRate(combinedVolume, rebaseId, baseId, market); err == nil {
-					weightedSumAcc += rebasedCombinedVolume
-				}
+				liquidity.HopVolumes = append(liquidity.HopVolumes, rebasedCombinedVolume(pair, r.rebaseId, r.hopMarket))
 			} else if i == len(quoteRebasePath)-1 {
-				if rebasedRate, err := shallowlyRebaseRate(rebasedRateAcc, quoteId, baseId, market); err == nil {
+				if rebasedRate, err := shallowlyRebaseRate(rebasedRateAcc, quoteId, baseId, r.hopMarket); err == nil {
 					rebasedRateAcc = rebasedRate
 				}
 			} else {
-				pair := market.PairsById[quoteRebasePath[i]]
-				baseId := pair.BaseAssetId
-				quoteId := pair.QuoteAssetId
-				if rebasedRate, err := shallowlyRebaseRate(rebasedRateAcc, quoteId, baseId, market); err == nil {
+				if rebasedRate, err := shallowlyRebaseRate(rebasedRateAcc, quoteId, baseId, r.hopMarket); err == nil {
 					rebasedRateAcc = rebasedRate
 				}
-				combinedVolume := pair.CombinedBaseVolume()
-				if rebasedCombinedVolume, err := shallowlyRebaseRate(combinedVolume, rebaseId, baseId, market); err == nil {
-					weightedSumAcc += rebasedCombinedVolume
-				}
+				liquidity.HopVolumes = append(liquidity.HopVolumes, rebasedCombinedVolume(pair, r.rebaseId, r.hopMarket))
 			}
 		}
-		weight := weightedSumAcc / float32(len(quoteRebasePath))
-		combinedVolume += weight
-		volumeWeightedSum += weight * rebasedRateAcc
+		rebasedRates = append(rebasedRates, rebasedRateAcc)
+		liquidities = append(liquidities, liquidity)
+	}
+
+	combinedWeight := float32(0)
+	weightedSum := float32(0)
+	for i, weight := range r.weighter.Weights(liquidities) {
+		combinedWeight += weight
+		weightedSum += weight * rebasedRates[i]
 	}
 
-	if combinedVolume == 0 {
+	if combinedWeight == 0 {
 		return 0.0
 	} else {
-		return volumeWeightedSum / combinedVolume
+		return weightedSum / combinedWeight
 	}
 }
 
+// combined base volume of a pair in rebaseId, or zero if there's no pair to rebase it with
+func rebasedCombinedVolume(pair m.Pair, rebaseId string, market *m.Market) float32 {
+	if rebasedVolume, err := shallowlyRebaseRate(pair.CombinedBaseVolume(), rebaseId, pair.BaseAssetId, market); err == nil {
+		return rebasedVolume
+	}
+	return 0
+}
+
+/**
+Volumes aren't rates and aren't rebased like them. A volume denominated in some asset is rebased to its notional value:
+the volume multiplied by the price of one unit of that asset in the rebase asset. Base volumes are valued at the price of
+the pair's base asset, quote volumes at the price of the pair's quote asset, so both end up denominated in the rebase asset.
+*/
+func rebaseVolume(volume float32, assetRate float32) float32 {
+	return volume * assetRate
+}
+
 func shallowlyRebaseRate(rate float32, rebaseId string, baseId string, market *m.Market) (float32, error) {
 	if rebaseId == baseId {
 		return rate, nil
@@ -1,11 +1,28 @@
 package rebasing
 
 import (
+	"context"
 	m "github.com/jochenboesmans/go-rebase/model/market"
 	. "github.com/smartystreets/goconvey/convey"
 	"testing"
 )
 
+func mockMarketOf(pairs ...m.Pair) m.Market {
+	market := m.Market{PairsById: map[string]m.Pair{}}
+	for _, pair := range pairs {
+		market.PairsById[pair.Id()] = pair
+	}
+	return market
+}
+
+func mockRatePair(baseId string, quoteId string, rate float32) m.Pair {
+	return m.Pair{
+		BaseAssetId:     baseId,
+		QuoteAssetId:    quoteId,
+		ExchangeMarkets: []m.ExchangeMarket{{CurrentBid: rate, CurrentAsk: rate, BaseVolume: 100}},
+	}
+}
+
 func TestRebasePaths(t *testing.T) {
 	Convey("path is already longer than specified max length", t, func() {
 		mockPairA := m.Pair{
@@ -22,9 +39,9 @@ func TestRebasePaths(t *testing.T) {
 				mockPairB.Id(): mockPairB,
 			},
 		}
-		rebaseNeighbors := mockMarket.RebaseNeighbors()
+		run := newRebaseRun(context.Background(), "1", 1, &mockMarket, Options{})
 
-		actual := rebasePaths(BASE, []string{mockPairA.Id(), mockPairB.Id()}, "1", 1, &mockMarket, rebaseNeighbors)
+		actual := run.rebasePaths(BASE, []string{mockPairA.Id(), mockPairB.Id()}, nil)
 
 		expected := [][]string{}
 
@@ -45,11 +62,11 @@ func TestRebasePaths(t *testing.T) {
 				mockPairB.Id(): mockPairB,
 			},
 		}
-		rebaseNeighbors := mockMarket.RebaseNeighbors()
+		run := newRebaseRun(context.Background(), "1", 2, &mockMarket, Options{})
 
 		rebasePaths := rebasePathsType{
-			Base:  rebasePaths(BASE, []string{mockPairB.Id()}, "1", 2, &mockMarket, rebaseNeighbors),
-			Quote: rebasePaths(QUOTE, []string{mockPairB.Id()}, "1", 2, &mockMarket, rebaseNeighbors),
+			Base:  run.rebasePaths(BASE, []string{mockPairB.Id()}, nil),
+			Quote: run.rebasePaths(QUOTE, []string{mockPairB.Id()}, nil),
 		}
 
 		expectedBase := [][]string{{mockPairA.Id(), mockPairB.Id()}}
@@ -79,11 +96,11 @@ func TestRebasePaths(t *testing.T) {
 				mockPairC.Id(): mockPairC,
 			},
 		}
-		rebaseNeighbors := mockMarket.RebaseNeighbors()
+		run := newRebaseRun(context.Background(), "1", 3, &mockMarket, Options{})
 
 		rebasePaths := rebasePathsType{
-			Base:  rebasePaths(BASE, []string{mockPairC.Id()}, "1", 3, &mockMarket, rebaseNeighbors),
-			Quote: rebasePaths(QUOTE, []string{mockPairC.Id()}, "1", 3, &mockMarket, rebaseNeighbors),
+			Base:  run.rebasePaths(BASE, []string{mockPairC.Id()}, nil),
+			Quote: run.rebasePaths(QUOTE, []string{mockPairC.Id()}, nil),
 		}
 
 		expectedBase := [][]string{{mockPairA.Id(), mockPairB.Id(), mockPairC.Id()}}
@@ -122,11 +139,11 @@ func TestRebasePaths(t *testing.T) {
 				mockPairE.Id(): mockPairE,
 			},
 		}
-		rebaseNeighbors := mockMarket.RebaseNeighbors()
+		run := newRebaseRun(context.Background(), "1", 5, &mockMarket, Options{})
 
 		rebasePaths := rebasePathsType{
-			Base:  rebasePaths(BASE, []string{mockPairE.Id()}, "1", 5, &mockMarket, rebaseNeighbors),
-			Quote: rebasePaths(QUOTE, []string{mockPairE.Id()}, "1", 5, &mockMarket, rebaseNeighbors),
+			Base:  run.rebasePaths(BASE, []string{mockPairE.Id()}, nil),
+			Quote: run.rebasePaths(QUOTE, []string{mockPairE.Id()}, nil),
 		}
 
 		expectedBasePath1 := []string{mockPairA.Id(), mockPairC.Id(), mockPairE.Id()}
@@ -448,3 +465,165 @@ func TestRebaseMarket(t *testing.T) {
 		So(actualMarket, ShouldResemble, &expectedMarket)
 	})
 }
+
+func TestRebaseVolume(t *testing.T) {
+	Convey("base and quote volumes are valued at the price of their own asset", t, func() {
+		mockPairA := m.Pair{
+			BaseAssetId:  "1",
+			QuoteAssetId: "2",
+			ExchangeMarkets: []m.ExchangeMarket{
+				{
+					CurrentBid: 2,
+					CurrentAsk: 2,
+					BaseVolume: 1,
+				},
+			},
+		}
+		// 10 units of "2" traded at 4 "2" per "3" is 2.5 units of "3"
+		mockPairB := m.Pair{
+			BaseAssetId:  "2",
+			QuoteAssetId: "3",
+			ExchangeMarkets: []m.ExchangeMarket{
+				{
+					CurrentBid:  4,
+					CurrentAsk:  4,
+					BaseVolume:  10,
+					QuoteVolume: 2.5,
+				},
+			},
+		}
+		mockMarket := m.Market{
+			PairsById: map[string]m.Pair{
+				mockPairA.Id(): mockPairA,
+				mockPairB.Id(): mockPairB,
+			},
+		}
+
+		actualMarket := RebaseMarket("1", 2, &mockMarket)
+		actual := actualMarket.PairsById[mockPairB.Id()].ExchangeMarkets[0]
+
+		// one unit of "2" is worth 2 units of "1", one unit of "3" is worth 8 units of "1"
+		So(actual.BaseVolume, ShouldEqual, float32(20))
+		So(actual.QuoteVolume, ShouldEqual, float32(20))
+	})
+	Convey("rebased base and quote volumes of the same trades have equal notional values", t, func() {
+		mockPairA := m.Pair{
+			BaseAssetId:  "1",
+			QuoteAssetId: "2",
+			ExchangeMarkets: []m.ExchangeMarket{
+				{
+					CurrentBid: 1.12,
+					CurrentAsk: 1.13,
+					BaseVolume: 100,
+				},
+			},
+		}
+		mockPairB := m.Pair{
+			BaseAssetId:  "2",
+			QuoteAssetId: "3",
+			ExchangeMarkets: []m.ExchangeMarket{
+				{
+					CurrentBid:  1.15,
+					CurrentAsk:  1.16,
+					BaseVolume:  100,
+					QuoteVolume: 100 / 1.155,
+				},
+			},
+		}
+		mockMarket := m.Market{
+			PairsById: map[string]m.Pair{
+				mockPairA.Id(): mockPairA,
+				mockPairB.Id(): mockPairB,
+			},
+		}
+
+		actualMarket := RebaseMarket("1", 2, &mockMarket)
+		actual := actualMarket.PairsById[mockPairB.Id()].ExchangeMarkets[0]
+
+		So(actual.BaseVolume, ShouldAlmostEqual, 112.5, 0.001)
+		So(actual.QuoteVolume, ShouldAlmostEqual, actual.BaseVolume, 0.001)
+	})
+	Convey("volumes of pairs already based in the rebase asset keep their base volume", t, func() {
+		mockPairA := m.Pair{
+			BaseAssetId:  "1",
+			QuoteAssetId: "2",
+			ExchangeMarkets: []m.ExchangeMarket{
+				{
+					CurrentBid:  2,
+					CurrentAsk:  2,
+					BaseVolume:  6,
+					QuoteVolume: 3,
+				},
+			},
+		}
+		mockMarket := m.Market{
+			PairsById: map[string]m.Pair{
+				mockPairA.Id(): mockPairA,
+			},
+		}
+
+		actualMarket := RebaseMarket("1", 2, &mockMarket)
+		actual := actualMarket.PairsById[mockPairA.Id()].ExchangeMarkets[0]
+
+		So(actual.BaseVolume, ShouldEqual, float32(6))
+		So(actual.QuoteVolume, ShouldEqual, float32(6))
+	})
+	Convey("missing quote volumes stay missing", t, func() {
+		So(rebaseVolume(0, 8), ShouldEqual, float32(0))
+	})
+}
+
+func TestRebaseMarketWithFilter(t *testing.T) {
+	mockPairA := m.Pair{
+		BaseAssetId:  "1",
+		QuoteAssetId: "2",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				ExchangeId: "x",
+				CurrentBid: 2,
+				CurrentAsk: 2,
+				BaseVolume: 1,
+			},
+			{
+				ExchangeId: "y",
+				CurrentBid: 4,
+				CurrentAsk: 4,
+				BaseVolume: 1,
+			},
+		},
+	}
+	mockPairB := m.Pair{
+		BaseAssetId:  "2",
+		QuoteAssetId: "3",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				ExchangeId: "x",
+				CurrentBid: 3,
+				CurrentAsk: 3,
+				BaseVolume: 1,
+			},
+		},
+	}
+	mockMarket := m.Market{
+		PairsById: map[string]m.Pair{
+			mockPairA.Id(): mockPairA,
+			mockPairB.Id(): mockPairB,
+		},
+	}
+
+	Convey("excluded exchanges don't feed the rebase paths", t, func() {
+		options := Options{Filter: m.Filter{ExcludeExchanges: []string{"y"}}}
+		actualMarket, _ := RebaseMarketWithOptions("1", 2, &mockMarket, options)
+
+		So(actualMarket.PairsById[mockPairA.Id()].ExchangeMarkets, ShouldHaveLength, 1)
+		So(actualMarket.PairsById[mockPairA.Id()].ExchangeMarkets[0].ExchangeId, ShouldEqual, "x")
+		So(actualMarket.PairsById[mockPairB.Id()].ExchangeMarkets[0].CurrentBid, ShouldEqual, float32(6))
+	})
+	Convey("excluded assets aren't returned and don't feed the rebase paths", t, func() {
+		options := Options{Filter: m.Filter{ExcludeAssets: []string{"1"}}}
+		actualMarket, _ := RebaseMarketWithOptions("1", 2, &mockMarket, options)
+
+		So(actualMarket.PairsById, ShouldNotContainKey, mockPairA.Id())
+		So(actualMarket.PairsById[mockPairB.Id()].ExchangeMarkets[0].CurrentBid, ShouldEqual, float32(0))
+	})
+}
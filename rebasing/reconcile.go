@@ -0,0 +1,157 @@
+package rebasing
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+/**
+Comparison of a pair's own mid with the mid implied by paths through the rebase asset that avoid the pair.
+ImpliedMid is the price of the quote asset in the rebase asset divided by that of the base asset, both priced along
+rebase paths that use neither the pair nor its inverse. DeviationBps is how far the direct mid is off from the implied
+one, in basis points.
+*/
+type Reconciliation struct {
+	PairId       string
+	BaseAssetId  string
+	QuoteAssetId string
+	DirectMid    float32
+	ImpliedMid   float32
+	DeviationBps float32
+}
+
+/**
+Compares every pair's mid with the mid implied by the other pairs, sorted by absolute deviation, largest first.
+Pairs are priced along the same paths a rebase finds, at the engine's path depth, or with an automatic path depth at
+one more than the depth that reaches every pair. Pairs without a mid, or without paths that avoid them, are left out.
+If the budget runs out or the context is done, the pairs compared so far are returned along with the error.
+*/
+func (e *Engine) Reconcile(ctx context.Context, rebaseId string, market *m.Market) ([]Reconciliation, error) {
+	depth := e.maxPathDepth
+	if e.autoDepth != nil {
+		depth = e.reconcileDepth(rebaseId, market)
+	}
+	run := newRebaseRun(ctx, rebaseId, depth, market, e.options)
+	run.budget = e.budgetTracker(ctx)
+
+	// the same paths a rebase finds for every pair, leading from the rebase asset to the pair's base asset
+	basePaths := map[string][][]string{}
+	for pairId := range run.market.PairsById {
+		if run.budget.exceeded() {
+			break
+		}
+		basePaths[pairId] = run.rebasePaths(BASE, []string{pairId}, run.budget.forPair())
+	}
+
+	// ids of the hops quoted in every asset
+	quotedPairIds := map[string][]string{}
+	for pairId, pair := range run.hopMarket.PairsById {
+		quotedPairIds[pair.QuoteAssetId] = append(quotedPairIds[pair.QuoteAssetId], pairId)
+	}
+
+	reconciliations := []Reconciliation{}
+	for pairId, pair := range run.market.PairsById {
+		if run.budget.exceeded() {
+			break
+		}
+		if _, ok := basePaths[pairId]; !ok || run.syntheticPairIds[pairId] {
+			continue
+		}
+		directMid := pair.BaseVolumeWeightedSpreadAverage()
+		if directMid <= 0 {
+			continue
+		}
+		avoided := map[string]bool{pairId: true, pairIdOf(pair.QuoteAssetId, pair.BaseAssetId): true}
+		baseRate := run.avoidingAssetRate(pair.BaseAssetId, basePaths[pairId], avoided)
+		quoteRate := run.avoidingQuotedAssetRate(quotedPairIds[pair.QuoteAssetId], basePaths, avoided)
+		if baseRate <= 0 || quoteRate <= 0 {
+			continue
+		}
+		impliedMid := quoteRate / baseRate
+		reconciliations = append(reconciliations, Reconciliation{
+			PairId:       pairId,
+			BaseAssetId:  pair.BaseAssetId,
+			QuoteAssetId: pair.QuoteAssetId,
+			DirectMid:    directMid,
+			ImpliedMid:   impliedMid,
+			DeviationBps: (directMid/impliedMid - 1) * 10000,
+		})
+	}
+
+	sort.Slice(reconciliations, func(i, j int) bool {
+		deviationI := math.Abs(float64(reconciliations[i].DeviationBps))
+		deviationJ := math.Abs(float64(reconciliations[j].DeviationBps))
+		if deviationI != deviationJ {
+			return deviationI > deviationJ
+		}
+		return reconciliations[i].PairId < reconciliations[j].PairId
+	})
+	return reconciliations, run.budget.error()
+}
+
+// one more than the depth that reaches every pair, so the quote asset of every pair is reached by other pairs too
+func (e *Engine) reconcileDepth(rebaseId string, market *m.Market) uint8 {
+	maxDepth := e.MaxPathDepth()
+	filteredMarket, _, rebaseNeighbors, syntheticPairIds := rebaseGraph(rebaseId, market, e.options)
+	depth := reachDepth(rebaseId, filteredMarket, rebaseNeighbors, syntheticPairIds) + 1
+	if depth > maxDepth {
+		depth = maxDepth
+	}
+	return depth
+}
+
+// price of an asset in the rebase asset along the base paths of a pair based in it, without the avoided pairs
+func (r *rebaseRun) avoidingAssetRate(assetId string, basePaths [][]string, avoided map[string]bool) float32 {
+	if assetId == r.rebaseId {
+		return 1
+	}
+	var paths [][]string
+	for _, path := range basePaths {
+		if !pathUses(path[:len(path)-1], avoided) {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return 0
+	}
+	return r.deeplyRebaseRate(1, r.precedentPaths(rebasePathsType{Base: paths}))
+}
+
+/**
+Price of an asset in the rebase asset from the given hops quoted in it, without the avoided ones. Every hop is priced
+at its mid times the price of its base asset, and weighted by its base volume in the rebase asset like AssetPrices does.
+*/
+func (r *rebaseRun) avoidingQuotedAssetRate(quotedPairIds []string, basePaths map[string][][]string, avoided map[string]bool) float32 {
+	weightedSum := float32(0)
+	combinedWeight := float32(0)
+	for _, pairId := range quotedPairIds {
+		if avoided[pairId] {
+			continue
+		}
+		pair := r.hopMarket.PairsById[pairId]
+		baseRate := r.avoidingAssetRate(pair.BaseAssetId, basePaths[pairId], avoided)
+		mid := pair.BaseVolumeWeightedSpreadAverage()
+		weight := pair.CombinedBaseVolume() * baseRate
+		if baseRate <= 0 || mid <= 0 || weight <= 0 {
+			continue
+		}
+		weightedSum += weight * mid * baseRate
+		combinedWeight += weight
+	}
+	if combinedWeight == 0 {
+		return 0
+	}
+	return weightedSum / combinedWeight
+}
+
+func pathUses(path []string, pairIds map[string]bool) bool {
+	for _, pairId := range path {
+		if pairIds[pairId] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package rebasing
+
+import (
+	"context"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func mockReconcileMarket() m.Market {
+	return mockMarketOf(
+		mockRatePair("USD", "EUR", 1.1),
+		mockRatePair("EUR", "GBP", 1.2),
+		mockRatePair("USD", "GBP", 1.35),
+	)
+}
+
+func TestEngine_Reconcile(t *testing.T) {
+	usdGbp := mockRatePair("USD", "GBP", 1.35)
+	eurGbp := mockRatePair("EUR", "GBP", 1.2)
+
+	Convey("compares direct mids with the mids implied by other paths, largest deviation first", t, func() {
+		mockMarket := mockReconcileMarket()
+
+		actual, err := NewEngine(WithMaxPathDepth(3)).Reconcile(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldHaveLength, 2)
+		So(actual[0].PairId, ShouldEqual, usdGbp.Id())
+		So(actual[0].BaseAssetId, ShouldEqual, "USD")
+		So(actual[0].QuoteAssetId, ShouldEqual, "GBP")
+		So(actual[0].DirectMid, ShouldAlmostEqual, 1.35, 0.0001)
+		So(actual[0].ImpliedMid, ShouldAlmostEqual, 1.32, 0.0001)
+		So(actual[0].DeviationBps, ShouldAlmostEqual, (1.35/1.32-1)*10000, 0.1)
+		So(actual[1].PairId, ShouldEqual, eurGbp.Id())
+		So(actual[1].ImpliedMid, ShouldAlmostEqual, 1.35/1.1, 0.0001)
+		So(actual[1].DeviationBps, ShouldAlmostEqual, (1.2/(1.35/1.1)-1)*10000, 0.1)
+	})
+	Convey("chooses a path depth that reaches the quote assets of all pairs", t, func() {
+		mockMarket := mockReconcileMarket()
+
+		actual, err := NewEngine().Reconcile(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldHaveLength, 2)
+	})
+	Convey("leaves out pairs without paths that avoid them", t, func() {
+		mockMarket := mockMarketOf(mockRatePair("USD", "EUR", 1.1), eurGbp)
+
+		actual, err := NewEngine(WithMaxPathDepth(3)).Reconcile(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldBeEmpty)
+	})
+	Convey("doesn't count a pair's inverse as another path", t, func() {
+		mockMarket := mockMarketOf(usdGbp, mockRatePair("GBP", "USD", 0.7))
+
+		actual, err := NewEngine(WithMaxPathDepth(3)).Reconcile(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldBeEmpty)
+	})
+	Convey("stops when the context is done", t, func() {
+		mockMarket := mockReconcileMarket()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := NewEngine(WithMaxPathDepth(3)).Reconcile(ctx, "USD", &mockMarket)
+
+		So(err, ShouldEqual, context.Canceled)
+	})
+	Convey("stops comparing pairs when the context is done while comparing", t, func() {
+		mockMarket := mockReconcileMarket()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		actual, err := NewEngine(WithMaxPathDepth(3), WithPathWeighter(cancellingWeighter{cancel})).
+			Reconcile(ctx, "USD", &mockMarket)
+
+		So(err, ShouldEqual, context.Canceled)
+		So(len(actual), ShouldBeLessThanOrEqualTo, 1)
+	})
+}
+
+// weighter that cancels a context the first time paths are weighed
+type cancellingWeighter struct {
+	cancel context.CancelFunc
+}
+
+func (w cancellingWeighter) Weights(paths []PathLiquidity) []float32 {
+	w.cancel()
+	return VolumeWeighter{}.Weights(paths)
+}
@@ -0,0 +1,90 @@
+package rebasing
+
+import (
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+// exchange id of the exchange markets of the synthetic pairs reference rates insert
+const ReferenceExchangeId = "reference"
+
+// volume a reference rate counts with when weighing paths through reference rates against each other, unless the table
+// has one of its own
+const DefaultReferenceRateVolume = float32(1)
+
+/**
+Rate from an auxiliary source, like a central bank's daily fix, rather than from the market.
+Rate is the price of one unit of QuoteAssetId in BaseAssetId, like the mid of a pair.
+*/
+type ReferenceRate struct {
+	BaseAssetId  string
+	QuoteAssetId string
+	Rate         float32
+}
+
+/**
+Table of reference rates that rebase paths can convert through where the market has no pair.
+Precedence is fixed: a pair of the market always wins over a reference rate for the same base and quote asset, and a
+reference rate wins over a peg. Paths through reference rates are only used for pairs that no path through market pairs
+alone reaches, and paths through pegs only for pairs that no path avoiding pegs reaches.
+*/
+type ReferenceRates struct {
+	Rates []ReferenceRate
+	// only use reference rates of assets that no market path connects to the rebase asset
+	Fallback bool
+	// volume every rate counts with when weighing paths through reference rates against each other, in its base asset,
+	// defaults to DefaultReferenceRateVolume
+	Volume float32
+}
+
+func (r ReferenceRates) volume() float32 {
+	if r.Volume <= 0 {
+		return DefaultReferenceRateVolume
+	}
+	return r.Volume
+}
+
+// synthetic pairs of a reference rate in both directions
+func (r ReferenceRates) pairs(rate ReferenceRate) []m.Pair {
+	return []m.Pair{
+		{
+			BaseAssetId:  rate.BaseAssetId,
+			QuoteAssetId: rate.QuoteAssetId,
+			ExchangeMarkets: []m.ExchangeMarket{{
+				ExchangeId: ReferenceExchangeId,
+				CurrentBid: rate.Rate,
+				CurrentAsk: rate.Rate,
+				BaseVolume: r.volume(),
+			}},
+		},
+		{
+			BaseAssetId:  rate.QuoteAssetId,
+			QuoteAssetId: rate.BaseAssetId,
+			ExchangeMarkets: []m.ExchangeMarket{{
+				ExchangeId: ReferenceExchangeId,
+				CurrentBid: 1 / rate.Rate,
+				CurrentAsk: 1 / rate.Rate,
+				BaseVolume: r.volume() * rate.Rate,
+			}},
+		},
+	}
+}
+
+/**
+Adds the synthetic pairs of all usable reference rates to the market, the hop market and syntheticPairIds.
+Rates that aren't positive or of assets the filter doesn't allow are left out, and with Fallback, so are rates of
+assets that market paths already connect to the rebase asset.
+*/
+func addReferenceRates(referenceRates ReferenceRates, market *m.Market, hopMarket *m.Market, filter m.Filter, connectedAssetIds map[string]bool, syntheticPairIds map[string]bool) {
+	for _, rate := range referenceRates.Rates {
+		if rate.Rate <= 0 || rate.BaseAssetId == rate.QuoteAssetId {
+			continue
+		}
+		if !filter.AllowsAsset(rate.BaseAssetId) || !filter.AllowsAsset(rate.QuoteAssetId) {
+			continue
+		}
+		if referenceRates.Fallback && connectedAssetIds[rate.BaseAssetId] && connectedAssetIds[rate.QuoteAssetId] {
+			continue
+		}
+		addSyntheticPairs(referenceRates.pairs(rate), market, hopMarket, syntheticPairIds)
+	}
+}
@@ -0,0 +1,168 @@
+package rebasing
+
+import (
+	"context"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestEngine_RebaseWithReferenceRates(t *testing.T) {
+	eurGbp := mockRatePair("EUR", "GBP", 1.2)
+	usdEur := mockRatePair("USD", "EUR", 1.1)
+	usdEurFix := ReferenceRates{Rates: []ReferenceRate{{BaseAssetId: "USD", QuoteAssetId: "EUR", Rate: 1.1}}}
+
+	Convey("converts through reference rates where the market has no pair", t, func() {
+		mockMarket := mockMarketOf(eurGbp)
+
+		actual, err := NewEngine(WithMaxPathDepth(2), WithReferenceRates(usdEurFix)).
+			Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Market.PairsById, ShouldHaveLength, 1)
+		So(actual.Market.PairsById[eurGbp.Id()].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 1.32, 0.0001)
+		So(actual.ReferencedPairs, ShouldResemble, map[string][]string{eurGbp.Id(): {"USD/EUR"}})
+		So(actual.PeggedPairs, ShouldBeEmpty)
+	})
+	Convey("market pairs take precedence over reference rates", t, func() {
+		mockMarket := mockMarketOf(eurGbp, usdEur)
+		referenceRates := ReferenceRates{Rates: []ReferenceRate{{BaseAssetId: "USD", QuoteAssetId: "EUR", Rate: 2}}}
+
+		actual, err := NewEngine(WithMaxPathDepth(2), WithReferenceRates(referenceRates)).
+			Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Market.PairsById[eurGbp.Id()].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 1.32, 0.0001)
+		So(actual.ReferencedPairs, ShouldBeEmpty)
+	})
+	Convey("market paths take precedence over reference rates whatever the weighter", t, func() {
+		gbpBtc := mockRatePair("GBP", "BTC", 30000)
+		mockMarket := mockMarketOf(usdEur, eurGbp, gbpBtc)
+		// a stale fix that prices GBP at 2 USD instead of the market's 1.32
+		referenceRates := ReferenceRates{Rates: []ReferenceRate{{BaseAssetId: "USD", QuoteAssetId: "GBP", Rate: 2}}}
+
+		for _, weighter := range []PathWeighter{VolumeWeighter{}, EqualWeighter{}, ShortestPathWeighter{}, BottleneckWeighter{}} {
+			actual, err := NewEngine(WithMaxPathDepth(3), WithPathWeighter(weighter), WithReferenceRates(referenceRates)).
+				Rebase(context.Background(), "USD", &mockMarket)
+
+			So(err, ShouldBeNil)
+			So(actual.Market.PairsById[gbpBtc.Id()].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 39600, 1)
+			So(actual.ReferencedPairs, ShouldBeEmpty)
+		}
+
+		actual, err := NewEngine(WithLeastSquares(), WithReferenceRates(referenceRates)).
+			Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Market.PairsById[gbpBtc.Id()].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 39600, 1)
+	})
+	Convey("paths through reference rates take precedence over paths through pegs", t, func() {
+		usdtEth := mockRatePair("USDT", "ETH", 2000)
+		eurUsdt := mockRatePair("EUR", "USDT", 1.2)
+		mockMarket := mockMarketOf(usdtEth, eurUsdt)
+		// USDT is worth 1.32 USD through the reference rate, and 1 USD through the peg
+		referenceRates := ReferenceRates{Rates: []ReferenceRate{{BaseAssetId: "USD", QuoteAssetId: "EUR", Rate: 1.1}}}
+
+		for _, weighter := range []PathWeighter{VolumeWeighter{}, EqualWeighter{}, ShortestPathWeighter{}} {
+			actual, err := NewEngine(
+				WithMaxPathDepth(3),
+				WithPathWeighter(weighter),
+				WithReferenceRates(referenceRates),
+				WithPegs(Peg{AssetId: "USDT", PeggedTo: "USD"}),
+			).Rebase(context.Background(), "USD", &mockMarket)
+
+			So(err, ShouldBeNil)
+			So(actual.Market.PairsById[usdtEth.Id()].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 2640, 0.1)
+			So(actual.ReferencedPairs, ShouldResemble, map[string][]string{
+				usdtEth.Id(): {"USD/EUR"},
+				eurUsdt.Id(): {"USD/EUR"},
+			})
+			So(actual.PeggedPairs, ShouldBeEmpty)
+		}
+	})
+	Convey("reference rates take precedence over pegs", t, func() {
+		usdtEth := mockRatePair("USDT", "ETH", 2000)
+		mockMarket := mockMarketOf(usdtEth)
+		referenceRates := ReferenceRates{Rates: []ReferenceRate{{BaseAssetId: "USD", QuoteAssetId: "USDT", Rate: 0.5}}}
+
+		actual, err := NewEngine(
+			WithMaxPathDepth(2),
+			WithReferenceRates(referenceRates),
+			WithPegs(Peg{AssetId: "USDT", PeggedTo: "USD"}),
+		).Rebase(context.Background(), "USD", &mockMarket)
+
+		So(err, ShouldBeNil)
+		So(actual.Market.PairsById[usdtEth.Id()].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 1000, 0.01)
+		So(actual.ReferencedPairs, ShouldResemble, map[string][]string{usdtEth.Id(): {"USD/USDT"}})
+		So(actual.PeggedPairs, ShouldBeEmpty)
+	})
+}
+
+func TestAddReferenceRates(t *testing.T) {
+	eurGbp := mockRatePair("EUR", "GBP", 1.2)
+	rate := ReferenceRate{BaseAssetId: "USD", QuoteAssetId: "EUR", Rate: 1.25}
+	addReferenceRatesTo := func(market m.Market, referenceRates ReferenceRates, filter m.Filter) (m.Market, map[string]bool) {
+		hopMarket := hopMarket(&market, LiquidityLimits{})
+		syntheticPairIds := map[string]bool{}
+		addReferenceRates(referenceRates, &market, hopMarket, filter, connectedAssets("USD", hopMarket), syntheticPairIds)
+		return market, syntheticPairIds
+	}
+
+	Convey("adds synthetic pairs in both directions at the rate", t, func() {
+		market, actual := addReferenceRatesTo(mockMarketOf(eurGbp), ReferenceRates{Rates: []ReferenceRate{rate}}, m.Filter{})
+
+		So(actual, ShouldHaveLength, 2)
+		forward := market.PairsById[pairIdOf("USD", "EUR")].ExchangeMarkets[0]
+		So(forward, ShouldResemble, m.ExchangeMarket{
+			ExchangeId: ReferenceExchangeId,
+			CurrentBid: 1.25,
+			CurrentAsk: 1.25,
+			BaseVolume: DefaultReferenceRateVolume,
+		})
+		inverse := market.PairsById[pairIdOf("EUR", "USD")].ExchangeMarkets[0]
+		So(inverse.CurrentBid, ShouldAlmostEqual, 0.8, 0.0001)
+		So(inverse.BaseVolume, ShouldAlmostEqual, 1.25, 0.0001)
+	})
+	Convey("uses the table's volume", t, func() {
+		market, _ := addReferenceRatesTo(mockMarketOf(eurGbp), ReferenceRates{Rates: []ReferenceRate{rate}, Volume: 10}, m.Filter{})
+
+		So(market.PairsById[pairIdOf("USD", "EUR")].ExchangeMarkets[0].BaseVolume, ShouldEqual, 10)
+	})
+	Convey("leaves out invalid rates and rates of filtered assets", t, func() {
+		invalidRates := ReferenceRates{Rates: []ReferenceRate{
+			{BaseAssetId: "USD", QuoteAssetId: "EUR"},
+			{BaseAssetId: "USD", QuoteAssetId: "USD", Rate: 1},
+		}}
+		_, actual := addReferenceRatesTo(mockMarketOf(eurGbp), invalidRates, m.Filter{})
+		So(actual, ShouldBeEmpty)
+
+		_, actual = addReferenceRatesTo(mockMarketOf(eurGbp), ReferenceRates{Rates: []ReferenceRate{rate}}, m.Filter{ExcludeAssets: []string{"EUR"}})
+		So(actual, ShouldBeEmpty)
+	})
+	Convey("fallback rates are only used without a market path", t, func() {
+		fallbackRates := ReferenceRates{Rates: []ReferenceRate{rate}, Fallback: true}
+
+		_, actual := addReferenceRatesTo(mockMarketOf(eurGbp), fallbackRates, m.Filter{})
+		So(actual, ShouldHaveLength, 2)
+		_, actual = addReferenceRatesTo(mockMarketOf(eurGbp, mockRatePair("USD", "GBP", 1.5)), fallbackRates, m.Filter{})
+		So(actual, ShouldBeEmpty)
+	})
+}
+
+func TestRebaser_ReferenceRates(t *testing.T) {
+	Convey("market data replaces a reference rate", t, func() {
+		eurGbp := mockRatePair("EUR", "GBP", 1.2)
+		mockMarket := mockMarketOf(eurGbp)
+		referenceRates := ReferenceRates{Rates: []ReferenceRate{{BaseAssetId: "USD", QuoteAssetId: "EUR", Rate: 1.1}}}
+		rebaser, err := NewEngine(WithMaxPathDepth(2), WithReferenceRates(referenceRates)).
+			NewRebaser(context.Background(), "USD", &mockMarket)
+		So(err, ShouldBeNil)
+		So(rebaser.ReferencedPairs(), ShouldContainKey, eurGbp.Id())
+
+		_, err = rebaser.AddPair(context.Background(), mockRatePair("USD", "EUR", 1))
+
+		So(err, ShouldBeNil)
+		So(rebaser.Market().PairsById[eurGbp.Id()].ExchangeMarkets[0].CurrentBid, ShouldAlmostEqual, 1.2, 0.0001)
+		So(rebaser.ReferencedPairs(), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,171 @@
+package rebasing
+
+import (
+	"sort"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+/**
+Adds pairs that aren't traded, like those of pegs and reference rates, to the market and the hop market so rebase paths
+can go through them, and records their ids. Synthetic pairs never replace pairs that are already there.
+*/
+func addSyntheticPairs(pairs []m.Pair, market *m.Market, hopMarket *m.Market, syntheticPairIds map[string]bool) {
+	for _, pair := range pairs {
+		pairId := pair.Id()
+		if _, ok := market.PairsById[pairId]; ok {
+			continue
+		}
+		market.PairsById[pairId] = pair
+		hopMarket.PairsById[pairId] = pair
+		syntheticPairIds[pairId] = true
+	}
+}
+
+// assets that pairs of the market connect to the asset, in either direction
+func connectedAssets(assetId string, market *m.Market) map[string]bool {
+	neighborAssetIds := map[string][]string{}
+	for _, pair := range market.PairsById {
+		neighborAssetIds[pair.BaseAssetId] = append(neighborAssetIds[pair.BaseAssetId], pair.QuoteAssetId)
+		neighborAssetIds[pair.QuoteAssetId] = append(neighborAssetIds[pair.QuoteAssetId], pair.BaseAssetId)
+	}
+
+	connectedAssetIds := map[string]bool{assetId: true}
+	queue := []string{assetId}
+	for len(queue) > 0 {
+		for _, neighborAssetId := range neighborAssetIds[queue[0]] {
+			if !connectedAssetIds[neighborAssetId] {
+				connectedAssetIds[neighborAssetId] = true
+				queue = append(queue, neighborAssetId)
+			}
+		}
+		queue = queue[1:]
+	}
+	return connectedAssetIds
+}
+
+// symbols of the synthetic pairs of the given exchange that the paths go through, sorted, or nil if they go through none
+func (r *rebaseRun) syntheticSymbols(rebasePaths rebasePathsType, exchangeId string) []string {
+	if len(r.syntheticPairIds) == 0 {
+		return nil
+	}
+	symbols := map[string]bool{}
+	for _, paths := range [][][]string{rebasePaths.Base, rebasePaths.Quote} {
+		for _, path := range paths {
+			for _, hopId := range path {
+				if !r.syntheticPairIds[hopId] {
+					continue
+				}
+				if pair := r.market.PairsById[hopId]; pair.ExchangeMarkets[0].ExchangeId == exchangeId {
+					symbols[pair.Symbol()] = true
+				}
+			}
+		}
+	}
+
+	var result []string
+	for symbol := range symbols {
+		result = append(result, symbol)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// records the symbols of the synthetic pairs a rebased pair relies on, or that it relies on none
+func setSyntheticSymbols(symbolsByPairId map[string][]string, pairId string, symbols []string) {
+	if symbols != nil {
+		symbolsByPairId[pairId] = symbols
+	} else {
+		delete(symbolsByPairId, pairId)
+	}
+}
+
+// rank of the paths through synthetic pairs of each exchange, paths through market pairs alone rank first at 0
+var syntheticPrecedence = map[string]int{ReferenceExchangeId: 1, PegExchangeId: 2}
+
+// rank of the synthetic pair of the lowest precedence that the path goes through, 0 if it goes through none
+func (r *rebaseRun) pathRank(path []string) int {
+	rank := 0
+	for _, pairId := range path {
+		if !r.syntheticPairIds[pairId] {
+			continue
+		}
+		if pairRank := syntheticPrecedence[r.market.PairsById[pairId].ExchangeMarkets[0].ExchangeId]; pairRank > rank {
+			rank = pairRank
+		}
+	}
+	return rank
+}
+
+/**
+Only the paths of the best rank among the given ones, so paths through synthetic pairs are only used for pairs that no
+path of a higher precedence reaches, whatever the weighter.
+*/
+func (r *rebaseRun) precedentPaths(rebasePaths rebasePathsType) rebasePathsType {
+	if len(r.syntheticPairIds) == 0 {
+		return rebasePaths
+	}
+	bestRank := -1
+	for _, paths := range [][][]string{rebasePaths.Base, rebasePaths.Quote} {
+		for _, path := range paths {
+			if rank := r.pathRank(path); bestRank < 0 || rank < bestRank {
+				bestRank = rank
+			}
+		}
+	}
+	return rebasePathsType{
+		Base:  r.pathsOfRank(rebasePaths.Base, bestRank),
+		Quote: r.pathsOfRank(rebasePaths.Quote, bestRank),
+	}
+}
+
+func (r *rebaseRun) pathsOfRank(paths [][]string, rank int) [][]string {
+	var result [][]string
+	for _, path := range paths {
+		if r.pathRank(path) == rank {
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
+/**
+Hop market without the synthetic pairs that pairs of a higher precedence make unnecessary: those between two assets that
+pairs of a higher precedence already connect to the rebase asset.
+*/
+func precedentHopMarket(rebaseId string, hopMarket *m.Market, syntheticPairIds map[string]bool) *m.Market {
+	if len(syntheticPairIds) == 0 {
+		return hopMarket
+	}
+	rankOf := func(pairId string, pair m.Pair) int {
+		if !syntheticPairIds[pairId] {
+			return 0
+		}
+		return syntheticPrecedence[pair.ExchangeMarkets[0].ExchangeId]
+	}
+	maxRank := 0
+	for _, rank := range syntheticPrecedence {
+		if rank > maxRank {
+			maxRank = rank
+		}
+	}
+
+	result := m.Market{PairsById: map[string]m.Pair{}}
+	for pairId, pair := range hopMarket.PairsById {
+		if rankOf(pairId, pair) == 0 {
+			result.PairsById[pairId] = pair
+		}
+	}
+	for rank := 1; rank <= maxRank; rank++ {
+		connectedAssetIds := connectedAssets(rebaseId, &result)
+		for pairId, pair := range hopMarket.PairsById {
+			if rankOf(pairId, pair) != rank {
+				continue
+			}
+			if !connectedAssetIds[pair.BaseAssetId] || !connectedAssetIds[pair.QuoteAssetId] {
+				result.PairsById[pairId] = pair
+			}
+		}
+	}
+	return &result
+}
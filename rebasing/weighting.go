@@ -0,0 +1,142 @@
+package rebasing
+
+import (
+	"fmt"
+	"math"
+)
+
+// decay used by the "lengthDecay" weighting when it's chosen by name
+const DefaultPathWeightDecay = float32(0.5)
+
+/**
+Liquidity along a single rebase path.
+HopVolumes holds the combined base volume of every hop the path rebases through, denominated in the rebase asset.
+Hops whose volume can't be rebased have a volume of zero.
+Length is the number of pairs in the path, including the pair being rebased.
+*/
+type PathLiquidity struct {
+	HopVolumes []float32
+	Length     int
+}
+
+/**
+Decides how much each rebase path contributes to a pair's rebased rates.
+Weights returns one weight per path, in the same order as the given paths.
+*/
+type PathWeighter interface {
+	Weights(paths []PathLiquidity) []float32
+}
+
+/**
+Weighs a path by the sum of its hop volumes divided by its length.
+This is the default weighting.
+*/
+type VolumeWeighter struct{}
+
+func (VolumeWeighter) Weights(paths []PathLiquidity) []float32 {
+	weights := make([]float32, len(paths))
+	for i, path := range paths {
+		weights[i] = hopVolumeSum(path) / float32(path.Length)
+	}
+	return weights
+}
+
+/**
+Weighs a path by the volume of its least liquid hop, so one deep pair can't carry an otherwise illiquid path.
+*/
+type BottleneckWeighter struct{}
+
+func (BottleneckWeighter) Weights(paths []PathLiquidity) []float32 {
+	weights := make([]float32, len(paths))
+	for i, path := range paths {
+		weights[i] = hopVolumeMin(path)
+	}
+	return weights
+}
+
+/**
+Weighs a path like VolumeWeighter, multiplied by Decay for every pair the path has beyond the rebased pair itself.
+*/
+type LengthDecayWeighter struct {
+	Decay float32
+}
+
+func (w LengthDecayWeighter) Weights(paths []PathLiquidity) []float32 {
+	weights := VolumeWeighter{}.Weights(paths)
+	for i, path := range paths {
+		weights[i] *= float32(math.Pow(float64(w.Decay), float64(path.Length-1)))
+	}
+	return weights
+}
+
+/**
+Weighs all paths equally, regardless of their liquidity.
+*/
+type EqualWeighter struct{}
+
+func (EqualWeighter) Weights(paths []PathLiquidity) []float32 {
+	weights := make([]float32, len(paths))
+	for i := range paths {
+		weights[i] = 1
+	}
+	return weights
+}
+
+/**
+Weighs the shortest paths equally and ignores all longer paths.
+*/
+type ShortestPathWeighter struct{}
+
+func (ShortestPathWeighter) Weights(paths []PathLiquidity) []float32 {
+	weights := make([]float32, len(paths))
+	shortestLength := 0
+	for _, path := range paths {
+		if shortestLength == 0 || path.Length < shortestLength {
+			shortestLength = path.Length
+		}
+	}
+	for i, path := range paths {
+		if path.Length == shortestLength {
+			weights[i] = 1
+		}
+	}
+	return weights
+}
+
+func PathWeighterByName(name string) (PathWeighter, error) {
+	switch name {
+	case "", "volume":
+		return VolumeWeighter{}, nil
+	case "bottleneck":
+		return BottleneckWeighter{}, nil
+	case "lengthDecay":
+		return LengthDecayWeighter{Decay: DefaultPathWeightDecay}, nil
+	case "equal":
+		return EqualWeighter{}, nil
+	case "shortest":
+		return ShortestPathWeighter{}, nil
+	default:
+		return nil, fmt.Errorf(`unknown path weighting "%s"`, name)
+	}
+}
+
+func hopVolumeSum(path PathLiquidity) float32 {
+	sum := float32(0)
+	for _, volume := range path.HopVolumes {
+		sum += volume
+	}
+	return sum
+}
+
+func hopVolumeMin(path PathLiquidity) float32 {
+	if len(path.HopVolumes) == 0 {
+		return 0
+	}
+	min := path.HopVolumes[0]
+	for _, volume := range path.HopVolumes[1:] {
+		if volume < min {
+			min = volume
+		}
+	}
+	return min
+}
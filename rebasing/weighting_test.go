@@ -0,0 +1,146 @@
+package rebasing
+
+import (
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+var mockPathLiquidities = []PathLiquidity{
+	{
+		HopVolumes: []float32{10},
+		Length:     2,
+	},
+	{
+		HopVolumes: []float32{2, 1000},
+		Length:     3,
+	},
+}
+
+func TestPathWeighters(t *testing.T) {
+	Convey("volume weighter divides summed hop volumes by path length", t, func() {
+		actual := VolumeWeighter{}.Weights(mockPathLiquidities)
+
+		So(actual, ShouldResemble, []float32{5, 334})
+	})
+	Convey("bottleneck weighter uses the least liquid hop", t, func() {
+		actual := BottleneckWeighter{}.Weights(mockPathLiquidities)
+
+		So(actual, ShouldResemble, []float32{10, 2})
+	})
+	Convey("bottleneck weighter gives paths without hops no weight", t, func() {
+		actual := BottleneckWeighter{}.Weights([]PathLiquidity{{Length: 1}})
+
+		So(actual, ShouldResemble, []float32{0})
+	})
+	Convey("length decay weighter decays volume weights per extra pair", t, func() {
+		actual := LengthDecayWeighter{Decay: 0.5}.Weights(mockPathLiquidities)
+
+		So(actual, ShouldResemble, []float32{2.5, 83.5})
+	})
+	Convey("equal weighter ignores liquidity", t, func() {
+		actual := EqualWeighter{}.Weights(mockPathLiquidities)
+
+		So(actual, ShouldResemble, []float32{1, 1})
+	})
+	Convey("shortest path weighter ignores longer paths", t, func() {
+		actual := ShortestPathWeighter{}.Weights(mockPathLiquidities)
+
+		So(actual, ShouldResemble, []float32{1, 0})
+	})
+}
+
+func TestPathWeighterByName(t *testing.T) {
+	Convey("empty name defaults to volume weighting", t, func() {
+		actual, err := PathWeighterByName("")
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, VolumeWeighter{})
+	})
+	Convey("length decay uses the default decay", t, func() {
+		actual, err := PathWeighterByName("lengthDecay")
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, LengthDecayWeighter{Decay: DefaultPathWeightDecay})
+	})
+	Convey("unknown name", t, func() {
+		actual, err := PathWeighterByName("foo")
+
+		So(actual, ShouldBeNil)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestRebaseMarketWithPathWeighter(t *testing.T) {
+	// "4" is priced at 10 by a direct pair and at 12 by a longer path through a deep but unrelated pair
+	mockPairA := m.Pair{
+		BaseAssetId:  "1",
+		QuoteAssetId: "4",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 10,
+				CurrentAsk: 10,
+				BaseVolume: 10,
+			},
+		},
+	}
+	mockPairB := m.Pair{
+		BaseAssetId:  "1",
+		QuoteAssetId: "2",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 2,
+				CurrentAsk: 2,
+				BaseVolume: 1000,
+			},
+		},
+	}
+	mockPairC := m.Pair{
+		BaseAssetId:  "2",
+		QuoteAssetId: "4",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 6,
+				CurrentAsk: 6,
+				BaseVolume: 1,
+			},
+		},
+	}
+	mockPairD := m.Pair{
+		BaseAssetId:  "4",
+		QuoteAssetId: "5",
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: 1,
+				CurrentAsk: 1,
+				BaseVolume: 1,
+			},
+		},
+	}
+	mockMarket := m.Market{
+		PairsById: map[string]m.Pair{
+			mockPairA.Id(): mockPairA,
+			mockPairB.Id(): mockPairB,
+			mockPairC.Id(): mockPairC,
+			mockPairD.Id(): mockPairD,
+		},
+	}
+	rebasedBid := func(weighter PathWeighter) float32 {
+		rebasedMarket, _ := RebaseMarketWithOptions("1", 3, &mockMarket, Options{PathWeighter: weighter})
+		return rebasedMarket.PairsById[mockPairD.Id()].ExchangeMarkets[0].CurrentBid
+	}
+
+	Convey("no path weighter defaults to volume weighting", t, func() {
+		So(rebasedBid(nil), ShouldEqual, rebasedBid(VolumeWeighter{}))
+		So(rebasedBid(nil), ShouldAlmostEqual, 4058.0/339.0, 0.0001)
+	})
+	Convey("bottleneck weighting trusts the long path less", t, func() {
+		So(rebasedBid(BottleneckWeighter{}), ShouldAlmostEqual, 124.0/12.0, 0.0001)
+	})
+	Convey("equal weighting averages the paths", t, func() {
+		So(rebasedBid(EqualWeighter{}), ShouldAlmostEqual, 11, 0.0001)
+	})
+	Convey("shortest path weighting only uses the direct pair", t, func() {
+		So(rebasedBid(ShortestPathWeighter{}), ShouldAlmostEqual, 10, 0.0001)
+	})
+}
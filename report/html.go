@@ -0,0 +1,381 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	"github.com/jochenboesmans/go-rebase/rebasing"
+)
+
+// number of the largest direct-vs-implied deviations a report lists
+const reportedDeviations = 20
+
+// layout of the embedded graph, in pixels
+const (
+	graphColumnWidth = 160
+	graphRowHeight   = 48
+	graphMargin      = 40
+	graphNodeRadius  = 18
+)
+
+/**
+Outcome of a rebase run along with the analyses a report shows, all in the asset ids of the rebased market. Pairs are
+shown by their symbols in Symbols where it has them, e.g. the original symbols of pairs merged by an asset registry.
+*/
+type Report struct {
+	// defaults to "Rebase report"
+	Title         string
+	GeneratedAt   time.Time
+	RebaseAssetId string
+	Result        *rebasing.Result
+	// set when a budget ran out and the report is based on partial results
+	PartialReason   string
+	Connectivity    m.Connectivity
+	Reconciliations []rebasing.Reconciliation
+	// graph of assets, drawn with its nodes in columns by their distance in Connectivity, unreachable assets last
+	Graph m.Graph
+	// symbols to show pairs by, by pair id, optional
+	Symbols map[string][]string
+}
+
+type priceRow struct {
+	AssetId string
+	Price   string
+	// fewest hops to price the asset, empty for assets only the basket prices
+	Distance string
+}
+
+// rebased pair along with the metrics that tell how far its rebased rates can be trusted
+type pairRow struct {
+	Symbol string
+	Mid    string
+	// relative spread of the rebased rates
+	Spread    string
+	Exchanges int
+	// combined base volume in the rebase asset
+	Volume       string
+	PathLength   string
+	DeviationBps string
+	Residual     string
+	// symbols of the pegs and reference rates that the rebased rates rely on
+	ReliesOn string
+}
+
+type deviationRow struct {
+	Symbol       string
+	DirectMid    string
+	ImpliedMid   string
+	DeviationBps string
+}
+
+type svgNode struct {
+	Id    string
+	X     int
+	Y     int
+	Color string
+}
+
+type svgEdge struct {
+	X1 float64
+	Y1 float64
+	X2 float64
+	Y2 float64
+}
+
+type graphView struct {
+	Width  int
+	Height int
+	Radius int
+	Nodes  []svgNode
+	Edges  []svgEdge
+}
+
+type view struct {
+	Title             string
+	GeneratedAt       string
+	RebaseAssetId     string
+	PathDepth         uint8
+	PartialReason     string
+	Prices            []priceRow
+	Pairs             []pairRow
+	UnreachableAssets []string
+	UnreachablePairs  []string
+	Suggestions       []string
+	Deviations        []deviationRow
+	Graph             graphView
+}
+
+/**
+Writes a report as a single HTML page without external assets: rebased prices, per-pair metrics, unreachable assets,
+the largest direct-vs-implied deviations and a drawing of the market graph.
+*/
+func WriteHTML(w io.Writer, r Report) error {
+	return htmlTemplate.Execute(w, r.view())
+}
+
+func (r Report) view() view {
+	title := r.Title
+	if title == "" {
+		title = "Rebase report"
+	}
+	v := view{
+		Title:             title,
+		GeneratedAt:       r.GeneratedAt.UTC().Format(time.RFC3339),
+		RebaseAssetId:     r.RebaseAssetId,
+		PartialReason:     r.PartialReason,
+		UnreachableAssets: r.Connectivity.UnreachableAssetIds,
+		Graph:             r.graphView(),
+	}
+	if r.Result != nil {
+		v.PathDepth = r.Result.PathDepth
+		v.Prices = r.priceRows()
+		v.Pairs = r.pairRows()
+	}
+	symbols := map[string]string{}
+	for _, edge := range r.Graph.Edges {
+		pair := m.Pair{BaseAssetId: edge.Source, QuoteAssetId: edge.Target}
+		symbols[edge.PairId] = pair.Symbol()
+	}
+	for _, pairId := range r.Connectivity.UnreachablePairIds {
+		if symbol, ok := r.Symbols[pairId]; ok {
+			v.UnreachablePairs = append(v.UnreachablePairs, symbol...)
+		} else if symbol, ok := symbols[pairId]; ok {
+			v.UnreachablePairs = append(v.UnreachablePairs, symbol)
+		} else {
+			v.UnreachablePairs = append(v.UnreachablePairs, pairId)
+		}
+	}
+	sort.Strings(v.UnreachablePairs)
+	for _, suggestion := range r.Connectivity.Suggestions {
+		pair := m.Pair{BaseAssetId: suggestion.BaseAssetId, QuoteAssetId: suggestion.QuoteAssetId}
+		v.Suggestions = append(v.Suggestions, pair.Symbol())
+	}
+	for i, reconciliation := range r.Reconciliations {
+		if i == reportedDeviations {
+			break
+		}
+		pair := m.Pair{BaseAssetId: reconciliation.BaseAssetId, QuoteAssetId: reconciliation.QuoteAssetId}
+		v.Deviations = append(v.Deviations, deviationRow{
+			Symbol:       r.symbol(reconciliation.PairId, pair),
+			DirectMid:    formatNumber(reconciliation.DirectMid),
+			ImpliedMid:   formatNumber(reconciliation.ImpliedMid),
+			DeviationBps: strconv.FormatFloat(float64(reconciliation.DeviationBps), 'f', 1, 32),
+		})
+	}
+	return v
+}
+
+// symbols the pair is shown by, its own unless Symbols has others
+func (r Report) symbol(pairId string, pair m.Pair) string {
+	if symbols, ok := r.Symbols[pairId]; ok {
+		return strings.Join(symbols, ", ")
+	}
+	return pair.Symbol()
+}
+
+// prices of all priced assets, the fitted prices with least squares and the basket's assets' prices with a basket
+func (r Report) priceRows() []priceRow {
+	prices := r.Result.Prices
+	if prices == nil {
+		prices = rebasing.AssetPrices(r.Result.Market, r.RebaseAssetId)
+		for assetId, price := range r.Result.BasketPrices {
+			if _, ok := prices[assetId]; !ok {
+				prices[assetId] = price
+			}
+		}
+	}
+	rows := []priceRow{}
+	for assetId, price := range prices {
+		row := priceRow{AssetId: assetId, Price: formatNumber(price)}
+		if distance, ok := r.Connectivity.AssetDistances[assetId]; ok {
+			row.Distance = strconv.Itoa(distance)
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].AssetId < rows[j].AssetId
+	})
+	return rows
+}
+
+func (r Report) pairRows() []pairRow {
+	deviations := map[string]float32{}
+	for _, reconciliation := range r.Reconciliations {
+		deviations[reconciliation.PairId] = reconciliation.DeviationBps
+	}
+	unreachable := map[string]bool{}
+	for _, pairId := range r.Connectivity.UnreachablePairIds {
+		unreachable[pairId] = true
+	}
+
+	rows := []pairRow{}
+	for pairId, pair := range r.Result.Market.PairsById {
+		// listed as unreachable instead, with rates of zero
+		if unreachable[pairId] {
+			continue
+		}
+		row := pairRow{
+			Symbol:    r.symbol(pairId, pair),
+			Mid:       formatNumber(pair.BaseVolumeWeightedSpreadAverage()),
+			Spread:    formatNumber(pair.BaseVolumeWeightedRelativeSpread()),
+			Exchanges: len(pair.ExchangeMarkets),
+			Volume:    formatNumber(pair.CombinedBaseVolume()),
+		}
+		if distance, ok := r.Connectivity.PairDistances[pairId]; ok {
+			row.PathLength = strconv.Itoa(distance)
+		}
+		if deviation, ok := deviations[pairId]; ok {
+			row.DeviationBps = strconv.FormatFloat(float64(deviation), 'f', 1, 32)
+		}
+		if residual, ok := r.Result.Residuals[pairId]; ok {
+			row.Residual = formatNumber(residual)
+		}
+		reliesOn := append(append([]string{}, r.Result.PeggedPairs[pairId]...), r.Result.ReferencedPairs[pairId]...)
+		row.ReliesOn = strings.Join(reliesOn, ", ")
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Symbol < rows[j].Symbol
+	})
+	return rows
+}
+
+// nodes in columns by their distance from the rebase asset, unreachable ones in the last column, by component
+func (r Report) graphView() graphView {
+	columns := map[int][]m.GraphNode{}
+	lastColumn := 0
+	for _, distance := range r.Connectivity.AssetDistances {
+		if distance+1 > lastColumn {
+			lastColumn = distance + 1
+		}
+	}
+	for _, node := range r.Graph.Nodes {
+		column := lastColumn
+		if distance, ok := r.Connectivity.AssetDistances[node.Id]; ok {
+			column = distance
+		}
+		columns[column] = append(columns[column], node)
+	}
+
+	v := graphView{Radius: graphNodeRadius}
+	positions := map[string]svgNode{}
+	rows, usedColumns := 0, 0
+	for column := 0; column <= lastColumn; column++ {
+		nodes := columns[column]
+		if len(nodes) > 0 {
+			usedColumns = column + 1
+		}
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return nodes[i].Component < nodes[j].Component
+		})
+		for row, node := range nodes {
+			svg := svgNode{
+				Id:    node.Id,
+				X:     graphMargin + column*graphColumnWidth,
+				Y:     graphMargin + row*graphRowHeight,
+				Color: m.ComponentColor(node.Component),
+			}
+			positions[node.Id] = svg
+			v.Nodes = append(v.Nodes, svg)
+		}
+		if len(nodes) > rows {
+			rows = len(nodes)
+		}
+	}
+	for _, edge := range r.Graph.Edges {
+		source, sourceOk := positions[edge.Source]
+		target, targetOk := positions[edge.Target]
+		if !sourceOk || !targetOk || edge.Source == edge.Target {
+			continue
+		}
+		// from edge to edge of both nodes, so the arrowhead shows
+		dx, dy := float64(target.X-source.X), float64(target.Y-source.Y)
+		length := math.Hypot(dx, dy)
+		offsetX, offsetY := dx/length*graphNodeRadius, dy/length*graphNodeRadius
+		v.Edges = append(v.Edges, svgEdge{
+			X1: float64(source.X) + offsetX,
+			Y1: float64(source.Y) + offsetY,
+			X2: float64(target.X) - offsetX,
+			Y2: float64(target.Y) - offsetY,
+		})
+	}
+	v.Width = 2 * graphMargin
+	if usedColumns > 0 {
+		v.Width += (usedColumns - 1) * graphColumnWidth
+	}
+	v.Height = 2 * graphMargin
+	if rows > 0 {
+		v.Height += (rows - 1) * graphRowHeight
+	}
+	return v
+}
+
+func formatNumber(number float32) string {
+	return strconv.FormatFloat(float64(number), 'g', 6, 32)
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+  h1 { margin-bottom: 0.2em; }
+  .meta { color: #666; margin-top: 0; }
+  .partial { background: #fff3cd; border: 1px solid #e0c36c; padding: 0.6em 1em; }
+  table { border-collapse: collapse; margin: 1em 0 2em; }
+  th, td { border-bottom: 1px solid #ddd; padding: 0.3em 0.8em; text-align: right; }
+  th:first-child, td:first-child, td.text { text-align: left; }
+  th { background: #f4f4f4; }
+  .graph { overflow: auto; border: 1px solid #ddd; margin: 1em 0 2em; }
+  .graph text { font-size: 11px; text-anchor: middle; dominant-baseline: central; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">Rebased in {{.RebaseAssetId}}{{if .PathDepth}} at a path depth of {{.PathDepth}}{{end}}, generated {{.GeneratedAt}}</p>
+{{if .PartialReason}}<p class="partial">Partial results: {{.PartialReason}}</p>{{end}}
+
+<h2>Prices</h2>
+<table>
+<tr><th>Asset</th><th>Price in {{.RebaseAssetId}}</th><th>Hops</th></tr>
+{{range .Prices}}<tr><td>{{.AssetId}}</td><td>{{.Price}}</td><td>{{.Distance}}</td></tr>
+{{end}}</table>
+
+<h2>Pairs</h2>
+<table>
+<tr><th>Pair</th><th>Mid</th><th>Relative spread</th><th>Exchanges</th><th>Volume in {{.RebaseAssetId}}</th><th>Path length</th><th>Deviation (bps)</th><th>Residual</th><th>Relies on</th></tr>
+{{range .Pairs}}<tr><td>{{.Symbol}}</td><td>{{.Mid}}</td><td>{{.Spread}}</td><td>{{.Exchanges}}</td><td>{{.Volume}}</td><td>{{.PathLength}}</td><td>{{.DeviationBps}}</td><td>{{.Residual}}</td><td class="text">{{.ReliesOn}}</td></tr>
+{{end}}</table>
+
+<h2>Unreachable assets</h2>
+{{if .UnreachableAssets}}<p>Assets: {{range $i, $a := .UnreachableAssets}}{{if $i}}, {{end}}{{$a}}{{end}}</p>
+<p>Pairs: {{range $i, $p := .UnreachablePairs}}{{if $i}}, {{end}}{{$p}}{{end}}</p>
+{{if .Suggestions}}<p>Pairs that would reach them, most volume first: {{range $i, $s := .Suggestions}}{{if $i}}, {{end}}{{$s}}{{end}}</p>{{end}}
+{{else}}<p>Every asset is reachable.</p>
+{{end}}
+<h2>Largest direct-vs-implied deviations</h2>
+{{if .Deviations}}<table>
+<tr><th>Pair</th><th>Direct mid</th><th>Implied mid</th><th>Deviation (bps)</th></tr>
+{{range .Deviations}}<tr><td>{{.Symbol}}</td><td>{{.DirectMid}}</td><td>{{.ImpliedMid}}</td><td>{{.DeviationBps}}</td></tr>
+{{end}}</table>
+{{else}}<p>No pair has paths that avoid it.</p>
+{{end}}
+<h2>Graph</h2>
+<div class="graph">
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Graph.Width}}" height="{{.Graph.Height}}">
+<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="#888"/></marker></defs>
+{{range .Graph.Edges}}<line x1="{{printf "%.1f" .X1}}" y1="{{printf "%.1f" .Y1}}" x2="{{printf "%.1f" .X2}}" y2="{{printf "%.1f" .Y2}}" stroke="#888" marker-end="url(#arrow)"/>
+{{end}}{{range .Graph.Nodes}}<circle cx="{{.X}}" cy="{{.Y}}" r="{{$.Graph.Radius}}" fill="{{.Color}}" stroke="#555"/><text x="{{.X}}" y="{{.Y}}">{{.Id}}</text>
+{{end}}</svg>
+</div>
+</body>
+</html>
+`))
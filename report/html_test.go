@@ -0,0 +1,99 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	"github.com/jochenboesmans/go-rebase/rebasing"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func mockRatePair(baseAssetId string, quoteAssetId string, rate float32) m.Pair {
+	return m.Pair{
+		BaseAssetId:     baseAssetId,
+		QuoteAssetId:    quoteAssetId,
+		ExchangeMarkets: []m.ExchangeMarket{{CurrentBid: rate, CurrentAsk: rate, BaseVolume: 100}},
+	}
+}
+
+func mockReport() Report {
+	usdEur := mockRatePair("USD", "EUR", 1.1)
+	eurGbp := mockRatePair("EUR", "GBP", 1.32)
+	btcEth := mockRatePair("BTC", "ETH", 0)
+	market := m.Market{PairsById: map[string]m.Pair{usdEur.Id(): usdEur, eurGbp.Id(): eurGbp, btcEth.Id(): btcEth}}
+	return Report{
+		GeneratedAt:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		RebaseAssetId: "USD",
+		Result: &rebasing.Result{
+			Market:      &market,
+			PathDepth:   2,
+			PeggedPairs: map[string][]string{eurGbp.Id(): {"EUR/EURT"}},
+		},
+		Connectivity: market.Connectivity("USD", 2),
+		Reconciliations: []rebasing.Reconciliation{
+			{PairId: eurGbp.Id(), BaseAssetId: "EUR", QuoteAssetId: "GBP", DirectMid: 1.2, ImpliedMid: 1.19, DeviationBps: 84.03},
+		},
+		Graph: market.Graph(m.GraphOptions{}),
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	Convey("writes prices, pair metrics, unreachable assets, deviations and the graph", t, func() {
+		var actual bytes.Buffer
+
+		err := WriteHTML(&actual, mockReport())
+
+		So(err, ShouldBeNil)
+		html := actual.String()
+		So(html, ShouldStartWith, "<!DOCTYPE html>")
+		So(html, ShouldContainSubstring, "<title>Rebase report</title>")
+		So(html, ShouldContainSubstring, "generated 2020-01-02T03:04:05Z")
+		So(html, ShouldContainSubstring, "<tr><td>GBP</td><td>1.32</td><td>2</td></tr>")
+		So(html, ShouldContainSubstring, `<tr><td>EUR/GBP</td><td>1.32</td><td>0</td><td>1</td><td>100</td><td>2</td><td>84.0</td><td></td><td class="text">EUR/EURT</td></tr>`)
+		So(html, ShouldNotContainSubstring, "<tr><td>BTC/ETH</td>")
+		So(html, ShouldContainSubstring, "<p>Assets: BTC, ETH</p>")
+		So(html, ShouldContainSubstring, "<p>Pairs: BTC/ETH</p>")
+		So(html, ShouldContainSubstring, "<tr><td>EUR/GBP</td><td>1.2</td><td>1.19</td><td>84.0</td></tr>")
+		So(strings.Count(html, "<circle "), ShouldEqual, 5)
+		So(strings.Count(html, "<line "), ShouldEqual, 3)
+	})
+	Convey("shows pairs by the symbols given for them", t, func() {
+		report := mockReport()
+		eurGbp := m.Pair{BaseAssetId: "EUR", QuoteAssetId: "GBP"}
+		btcEth := m.Pair{BaseAssetId: "BTC", QuoteAssetId: "ETH"}
+		report.Symbols = map[string][]string{eurGbp.Id(): {"EUR/GBP", "eur/XGBP"}, btcEth.Id(): {"XBT/ETH"}}
+		var actual bytes.Buffer
+
+		err := WriteHTML(&actual, report)
+
+		So(err, ShouldBeNil)
+		html := actual.String()
+		So(html, ShouldContainSubstring, "<tr><td>EUR/GBP, eur/XGBP</td><td>1.32</td>")
+		So(html, ShouldContainSubstring, "<p>Pairs: XBT/ETH</p>")
+		So(html, ShouldContainSubstring, "<tr><td>EUR/GBP, eur/XGBP</td><td>1.2</td><td>1.19</td><td>84.0</td></tr>")
+	})
+	Convey("flags partial reports and escapes what it shows", t, func() {
+		report := mockReport()
+		report.Title = "<b>nightly</b>"
+		report.PartialReason = "rebase budget exceeded"
+		var actual bytes.Buffer
+
+		err := WriteHTML(&actual, report)
+
+		So(err, ShouldBeNil)
+		So(actual.String(), ShouldContainSubstring, "&lt;b&gt;nightly&lt;/b&gt;")
+		So(actual.String(), ShouldContainSubstring, "Partial results: rebase budget exceeded")
+	})
+	Convey("has no external assets", t, func() {
+		var actual bytes.Buffer
+
+		err := WriteHTML(&actual, mockReport())
+
+		So(err, ShouldBeNil)
+		So(actual.String(), ShouldNotContainSubstring, "src=")
+		So(actual.String(), ShouldNotContainSubstring, "<link")
+	})
+}
@@ -0,0 +1,4 @@
+// Package rebasepb holds the protobuf messages and gRPC client and server of the rebase service, generated from rebase.proto.
+package rebasepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative rebase.proto
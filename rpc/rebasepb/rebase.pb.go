@@ -0,0 +1,1686 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: rebase.proto
+
+package rebasepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Exchange-specific market data.
+type ExchangeMarket struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// optional, identifies the exchange the market data comes from
+	ExchangeId string  `protobuf:"bytes,1,opt,name=exchange_id,json=exchangeId,proto3" json:"exchange_id,omitempty"`
+	CurrentBid float32 `protobuf:"fixed32,2,opt,name=current_bid,json=currentBid,proto3" json:"current_bid,omitempty"`
+	CurrentAsk float32 `protobuf:"fixed32,3,opt,name=current_ask,json=currentAsk,proto3" json:"current_ask,omitempty"`
+	BaseVolume float32 `protobuf:"fixed32,4,opt,name=base_volume,json=baseVolume,proto3" json:"base_volume,omitempty"`
+	// optional, zero when the exchange doesn't report volume in the quote asset
+	QuoteVolume   float32 `protobuf:"fixed32,5,opt,name=quote_volume,json=quoteVolume,proto3" json:"quote_volume,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExchangeMarket) Reset() {
+	*x = ExchangeMarket{}
+	mi := &file_rebase_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExchangeMarket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExchangeMarket) ProtoMessage() {}
+
+func (x *ExchangeMarket) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExchangeMarket.ProtoReflect.Descriptor instead.
+func (*ExchangeMarket) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ExchangeMarket) GetExchangeId() string {
+	if x != nil {
+		return x.ExchangeId
+	}
+	return ""
+}
+
+func (x *ExchangeMarket) GetCurrentBid() float32 {
+	if x != nil {
+		return x.CurrentBid
+	}
+	return 0
+}
+
+func (x *ExchangeMarket) GetCurrentAsk() float32 {
+	if x != nil {
+		return x.CurrentAsk
+	}
+	return 0
+}
+
+func (x *ExchangeMarket) GetBaseVolume() float32 {
+	if x != nil {
+		return x.BaseVolume
+	}
+	return 0
+}
+
+func (x *ExchangeMarket) GetQuoteVolume() float32 {
+	if x != nil {
+		return x.QuoteVolume
+	}
+	return 0
+}
+
+// Market pair containing data from one or many exchanges.
+type Pair struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	BaseAssetId     string                 `protobuf:"bytes,1,opt,name=base_asset_id,json=baseAssetId,proto3" json:"base_asset_id,omitempty"`
+	QuoteAssetId    string                 `protobuf:"bytes,2,opt,name=quote_asset_id,json=quoteAssetId,proto3" json:"quote_asset_id,omitempty"`
+	ExchangeMarkets []*ExchangeMarket      `protobuf:"bytes,3,rep,name=exchange_markets,json=exchangeMarkets,proto3" json:"exchange_markets,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Pair) Reset() {
+	*x = Pair{}
+	mi := &file_rebase_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pair) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pair) ProtoMessage() {}
+
+func (x *Pair) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pair.ProtoReflect.Descriptor instead.
+func (*Pair) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Pair) GetBaseAssetId() string {
+	if x != nil {
+		return x.BaseAssetId
+	}
+	return ""
+}
+
+func (x *Pair) GetQuoteAssetId() string {
+	if x != nil {
+		return x.QuoteAssetId
+	}
+	return ""
+}
+
+func (x *Pair) GetExchangeMarkets() []*ExchangeMarket {
+	if x != nil {
+		return x.ExchangeMarkets
+	}
+	return nil
+}
+
+// Restricts which market data is used and returned. Empty allow-lists allow everything, deny-lists take precedence.
+type Filter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IncludeAssets []string               `protobuf:"bytes,1,rep,name=include_assets,json=includeAssets,proto3" json:"include_assets,omitempty"`
+	ExcludeAssets []string               `protobuf:"bytes,2,rep,name=exclude_assets,json=excludeAssets,proto3" json:"exclude_assets,omitempty"`
+	// pairs are matched by their BASE/QUOTE symbol
+	IncludePairs     []string `protobuf:"bytes,3,rep,name=include_pairs,json=includePairs,proto3" json:"include_pairs,omitempty"`
+	ExcludePairs     []string `protobuf:"bytes,4,rep,name=exclude_pairs,json=excludePairs,proto3" json:"exclude_pairs,omitempty"`
+	IncludeExchanges []string `protobuf:"bytes,5,rep,name=include_exchanges,json=includeExchanges,proto3" json:"include_exchanges,omitempty"`
+	ExcludeExchanges []string `protobuf:"bytes,6,rep,name=exclude_exchanges,json=excludeExchanges,proto3" json:"exclude_exchanges,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Filter) Reset() {
+	*x = Filter{}
+	mi := &file_rebase_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Filter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Filter) ProtoMessage() {}
+
+func (x *Filter) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Filter.ProtoReflect.Descriptor instead.
+func (*Filter) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Filter) GetIncludeAssets() []string {
+	if x != nil {
+		return x.IncludeAssets
+	}
+	return nil
+}
+
+func (x *Filter) GetExcludeAssets() []string {
+	if x != nil {
+		return x.ExcludeAssets
+	}
+	return nil
+}
+
+func (x *Filter) GetIncludePairs() []string {
+	if x != nil {
+		return x.IncludePairs
+	}
+	return nil
+}
+
+func (x *Filter) GetExcludePairs() []string {
+	if x != nil {
+		return x.ExcludePairs
+	}
+	return nil
+}
+
+func (x *Filter) GetIncludeExchanges() []string {
+	if x != nil {
+		return x.IncludeExchanges
+	}
+	return nil
+}
+
+func (x *Filter) GetExcludeExchanges() []string {
+	if x != nil {
+		return x.ExcludeExchanges
+	}
+	return nil
+}
+
+// Tunes the automatic choice of the maximum path length. Zero values use the defaults.
+type AutoPathLength struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tolerance     float32                `protobuf:"fixed32,1,opt,name=tolerance,proto3" json:"tolerance,omitempty"`
+	MaxPathLength uint32                 `protobuf:"varint,2,opt,name=max_path_length,json=maxPathLength,proto3" json:"max_path_length,omitempty"`
+	TimeBudgetMs  uint32                 `protobuf:"varint,3,opt,name=time_budget_ms,json=timeBudgetMs,proto3" json:"time_budget_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AutoPathLength) Reset() {
+	*x = AutoPathLength{}
+	mi := &file_rebase_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AutoPathLength) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AutoPathLength) ProtoMessage() {}
+
+func (x *AutoPathLength) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AutoPathLength.ProtoReflect.Descriptor instead.
+func (*AutoPathLength) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AutoPathLength) GetTolerance() float32 {
+	if x != nil {
+		return x.Tolerance
+	}
+	return 0
+}
+
+func (x *AutoPathLength) GetMaxPathLength() uint32 {
+	if x != nil {
+		return x.MaxPathLength
+	}
+	return 0
+}
+
+func (x *AutoPathLength) GetTimeBudgetMs() uint32 {
+	if x != nil {
+		return x.TimeBudgetMs
+	}
+	return 0
+}
+
+// Limits the work a rebase may do. Zero values use the defaults.
+type Budget struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	MaxPathsPerPair uint32                 `protobuf:"varint,1,opt,name=max_paths_per_pair,json=maxPathsPerPair,proto3" json:"max_paths_per_pair,omitempty"`
+	MaxPaths        uint32                 `protobuf:"varint,2,opt,name=max_paths,json=maxPaths,proto3" json:"max_paths,omitempty"`
+	TimeBudgetMs    uint32                 `protobuf:"varint,3,opt,name=time_budget_ms,json=timeBudgetMs,proto3" json:"time_budget_ms,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Budget) Reset() {
+	*x = Budget{}
+	mi := &file_rebase_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Budget) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Budget) ProtoMessage() {}
+
+func (x *Budget) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Budget.ProtoReflect.Descriptor instead.
+func (*Budget) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Budget) GetMaxPathsPerPair() uint32 {
+	if x != nil {
+		return x.MaxPathsPerPair
+	}
+	return 0
+}
+
+func (x *Budget) GetMaxPaths() uint32 {
+	if x != nil {
+		return x.MaxPaths
+	}
+	return 0
+}
+
+func (x *Budget) GetTimeBudgetMs() uint32 {
+	if x != nil {
+		return x.TimeBudgetMs
+	}
+	return 0
+}
+
+// Asset ids that name the same asset. When set, asset ids are also compared regardless of case and surrounding
+// whitespace, and the response keeps the original symbols.
+type Assets struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// aliases by canonical asset id
+	Aliases map[string]*AssetIds `protobuf:"bytes,1,rep,name=aliases,proto3" json:"aliases,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// groups of asset ids treated as one, named after the first
+	Equivalent    []*AssetIds `protobuf:"bytes,2,rep,name=equivalent,proto3" json:"equivalent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Assets) Reset() {
+	*x = Assets{}
+	mi := &file_rebase_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Assets) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Assets) ProtoMessage() {}
+
+func (x *Assets) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Assets.ProtoReflect.Descriptor instead.
+func (*Assets) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Assets) GetAliases() map[string]*AssetIds {
+	if x != nil {
+		return x.Aliases
+	}
+	return nil
+}
+
+func (x *Assets) GetEquivalent() []*AssetIds {
+	if x != nil {
+		return x.Equivalent
+	}
+	return nil
+}
+
+type AssetIds struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AssetIds      []string               `protobuf:"bytes,1,rep,name=asset_ids,json=assetIds,proto3" json:"asset_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssetIds) Reset() {
+	*x = AssetIds{}
+	mi := &file_rebase_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssetIds) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssetIds) ProtoMessage() {}
+
+func (x *AssetIds) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssetIds.ProtoReflect.Descriptor instead.
+func (*AssetIds) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AssetIds) GetAssetIds() []string {
+	if x != nil {
+		return x.AssetIds
+	}
+	return nil
+}
+
+// Rate from outside the market, like a central bank's daily fix.
+type ReferenceRate struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	BaseAssetId  string                 `protobuf:"bytes,1,opt,name=base_asset_id,json=baseAssetId,proto3" json:"base_asset_id,omitempty"`
+	QuoteAssetId string                 `protobuf:"bytes,2,opt,name=quote_asset_id,json=quoteAssetId,proto3" json:"quote_asset_id,omitempty"`
+	// price of one unit of quote_asset_id in base_asset_id, like the mid of a pair
+	Rate          float32 `protobuf:"fixed32,3,opt,name=rate,proto3" json:"rate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReferenceRate) Reset() {
+	*x = ReferenceRate{}
+	mi := &file_rebase_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReferenceRate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReferenceRate) ProtoMessage() {}
+
+func (x *ReferenceRate) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReferenceRate.ProtoReflect.Descriptor instead.
+func (*ReferenceRate) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ReferenceRate) GetBaseAssetId() string {
+	if x != nil {
+		return x.BaseAssetId
+	}
+	return ""
+}
+
+func (x *ReferenceRate) GetQuoteAssetId() string {
+	if x != nil {
+		return x.QuoteAssetId
+	}
+	return ""
+}
+
+func (x *ReferenceRate) GetRate() float32 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+// Rates that rebase paths may go through where the market has no pair. Pairs of the market take precedence over
+// reference rates, which take precedence over pegs.
+type ReferenceRates struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Rates []*ReferenceRate       `protobuf:"bytes,1,rep,name=rates,proto3" json:"rates,omitempty"`
+	// only use rates of assets that no market path connects to the rebase asset
+	Fallback bool `protobuf:"varint,2,opt,name=fallback,proto3" json:"fallback,omitempty"`
+	// volume every rate counts with when weighing paths, in its base asset
+	Volume        float32 `protobuf:"fixed32,3,opt,name=volume,proto3" json:"volume,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReferenceRates) Reset() {
+	*x = ReferenceRates{}
+	mi := &file_rebase_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReferenceRates) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReferenceRates) ProtoMessage() {}
+
+func (x *ReferenceRates) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReferenceRates.ProtoReflect.Descriptor instead.
+func (*ReferenceRates) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReferenceRates) GetRates() []*ReferenceRate {
+	if x != nil {
+		return x.Rates
+	}
+	return nil
+}
+
+func (x *ReferenceRates) GetFallback() bool {
+	if x != nil {
+		return x.Fallback
+	}
+	return false
+}
+
+func (x *ReferenceRates) GetVolume() float32 {
+	if x != nil {
+		return x.Volume
+	}
+	return 0
+}
+
+// Assumption that an asset trades at a fixed ratio to another. Zero values use the defaults.
+type Peg struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	AssetId  string                 `protobuf:"bytes,1,opt,name=asset_id,json=assetId,proto3" json:"asset_id,omitempty"`
+	PeggedTo string                 `protobuf:"bytes,2,opt,name=pegged_to,json=peggedTo,proto3" json:"pegged_to,omitempty"`
+	// price of one unit of asset_id in pegged_to, defaults to 1
+	Ratio float32 `protobuf:"fixed32,3,opt,name=ratio,proto3" json:"ratio,omitempty"`
+	// relative band around the ratio, a pair of both assets trading outside of it breaks the peg
+	Tolerance float32 `protobuf:"fixed32,4,opt,name=tolerance,proto3" json:"tolerance,omitempty"`
+	// only use the peg if no market path connects the pegged asset to the rebase asset
+	Fallback bool `protobuf:"varint,5,opt,name=fallback,proto3" json:"fallback,omitempty"`
+	// volume the peg counts with when weighing paths, in pegged_to
+	Volume        float32 `protobuf:"fixed32,6,opt,name=volume,proto3" json:"volume,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Peg) Reset() {
+	*x = Peg{}
+	mi := &file_rebase_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Peg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Peg) ProtoMessage() {}
+
+func (x *Peg) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Peg.ProtoReflect.Descriptor instead.
+func (*Peg) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Peg) GetAssetId() string {
+	if x != nil {
+		return x.AssetId
+	}
+	return ""
+}
+
+func (x *Peg) GetPeggedTo() string {
+	if x != nil {
+		return x.PeggedTo
+	}
+	return ""
+}
+
+func (x *Peg) GetRatio() float32 {
+	if x != nil {
+		return x.Ratio
+	}
+	return 0
+}
+
+func (x *Peg) GetTolerance() float32 {
+	if x != nil {
+		return x.Tolerance
+	}
+	return 0
+}
+
+func (x *Peg) GetFallback() bool {
+	if x != nil {
+		return x.Fallback
+	}
+	return false
+}
+
+func (x *Peg) GetVolume() float32 {
+	if x != nil {
+		return x.Volume
+	}
+	return 0
+}
+
+// Basket of assets to rebase in. Without weights, all assets weigh the same.
+type Basket struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Assets []*BasketAsset         `protobuf:"bytes,1,rep,name=assets,proto3" json:"assets,omitempty"`
+	// prices the basket as the weighted geometric mean of its assets, instead of holding amounts of them
+	Geometric     bool `protobuf:"varint,2,opt,name=geometric,proto3" json:"geometric,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Basket) Reset() {
+	*x = Basket{}
+	mi := &file_rebase_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Basket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Basket) ProtoMessage() {}
+
+func (x *Basket) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Basket.ProtoReflect.Descriptor instead.
+func (*Basket) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Basket) GetAssets() []*BasketAsset {
+	if x != nil {
+		return x.Assets
+	}
+	return nil
+}
+
+func (x *Basket) GetGeometric() bool {
+	if x != nil {
+		return x.Geometric
+	}
+	return false
+}
+
+type BasketAsset struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AssetId       string                 `protobuf:"bytes,1,opt,name=asset_id,json=assetId,proto3" json:"asset_id,omitempty"`
+	Weight        float32                `protobuf:"fixed32,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BasketAsset) Reset() {
+	*x = BasketAsset{}
+	mi := &file_rebase_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BasketAsset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BasketAsset) ProtoMessage() {}
+
+func (x *BasketAsset) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BasketAsset.ProtoReflect.Descriptor instead.
+func (*BasketAsset) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *BasketAsset) GetAssetId() string {
+	if x != nil {
+		return x.AssetId
+	}
+	return ""
+}
+
+func (x *BasketAsset) GetWeight() float32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+type RebaseRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// with a basket, the id of the basket
+	RebaseAssetId string `protobuf:"bytes,1,opt,name=rebase_asset_id,json=rebaseAssetId,proto3" json:"rebase_asset_id,omitempty"`
+	// chosen automatically when zero
+	MaxPathLength     uint32          `protobuf:"varint,2,opt,name=max_path_length,json=maxPathLength,proto3" json:"max_path_length,omitempty"`
+	AutoPathLength    *AutoPathLength `protobuf:"bytes,3,opt,name=auto_path_length,json=autoPathLength,proto3" json:"auto_path_length,omitempty"`
+	PathWeighting     string          `protobuf:"bytes,4,opt,name=path_weighting,json=pathWeighting,proto3" json:"path_weighting,omitempty"`
+	MinCombinedVolume float32         `protobuf:"fixed32,5,opt,name=min_combined_volume,json=minCombinedVolume,proto3" json:"min_combined_volume,omitempty"`
+	MinExchanges      uint32          `protobuf:"varint,6,opt,name=min_exchanges,json=minExchanges,proto3" json:"min_exchanges,omitempty"`
+	MaxRelativeSpread float32         `protobuf:"fixed32,7,opt,name=max_relative_spread,json=maxRelativeSpread,proto3" json:"max_relative_spread,omitempty"`
+	Filter            *Filter         `protobuf:"bytes,8,opt,name=filter,proto3" json:"filter,omitempty"`
+	Budget            *Budget         `protobuf:"bytes,9,opt,name=budget,proto3" json:"budget,omitempty"`
+	Market            []*Pair         `protobuf:"bytes,10,rep,name=market,proto3" json:"market,omitempty"`
+	// asset ids are taken as they are when unset
+	Assets         *Assets         `protobuf:"bytes,11,opt,name=assets,proto3" json:"assets,omitempty"`
+	Pegs           []*Peg          `protobuf:"bytes,12,rep,name=pegs,proto3" json:"pegs,omitempty"`
+	ReferenceRates *ReferenceRates `protobuf:"bytes,13,opt,name=reference_rates,json=referenceRates,proto3" json:"reference_rates,omitempty"`
+	// rebases in a basket of assets named rebase_asset_id instead of a single asset
+	Basket *Basket `protobuf:"bytes,14,opt,name=basket,proto3" json:"basket,omitempty"`
+	// "paths", the default, or "leastSquares" to fit one consistent price per asset to all pairs at once
+	Engine        string `protobuf:"bytes,15,opt,name=engine,proto3" json:"engine,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RebaseRequest) Reset() {
+	*x = RebaseRequest{}
+	mi := &file_rebase_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RebaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RebaseRequest) ProtoMessage() {}
+
+func (x *RebaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RebaseRequest.ProtoReflect.Descriptor instead.
+func (*RebaseRequest) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RebaseRequest) GetRebaseAssetId() string {
+	if x != nil {
+		return x.RebaseAssetId
+	}
+	return ""
+}
+
+func (x *RebaseRequest) GetMaxPathLength() uint32 {
+	if x != nil {
+		return x.MaxPathLength
+	}
+	return 0
+}
+
+func (x *RebaseRequest) GetAutoPathLength() *AutoPathLength {
+	if x != nil {
+		return x.AutoPathLength
+	}
+	return nil
+}
+
+func (x *RebaseRequest) GetPathWeighting() string {
+	if x != nil {
+		return x.PathWeighting
+	}
+	return ""
+}
+
+func (x *RebaseRequest) GetMinCombinedVolume() float32 {
+	if x != nil {
+		return x.MinCombinedVolume
+	}
+	return 0
+}
+
+func (x *RebaseRequest) GetMinExchanges() uint32 {
+	if x != nil {
+		return x.MinExchanges
+	}
+	return 0
+}
+
+func (x *RebaseRequest) GetMaxRelativeSpread() float32 {
+	if x != nil {
+		return x.MaxRelativeSpread
+	}
+	return 0
+}
+
+func (x *RebaseRequest) GetFilter() *Filter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *RebaseRequest) GetBudget() *Budget {
+	if x != nil {
+		return x.Budget
+	}
+	return nil
+}
+
+func (x *RebaseRequest) GetMarket() []*Pair {
+	if x != nil {
+		return x.Market
+	}
+	return nil
+}
+
+func (x *RebaseRequest) GetAssets() *Assets {
+	if x != nil {
+		return x.Assets
+	}
+	return nil
+}
+
+func (x *RebaseRequest) GetPegs() []*Peg {
+	if x != nil {
+		return x.Pegs
+	}
+	return nil
+}
+
+func (x *RebaseRequest) GetReferenceRates() *ReferenceRates {
+	if x != nil {
+		return x.ReferenceRates
+	}
+	return nil
+}
+
+func (x *RebaseRequest) GetBasket() *Basket {
+	if x != nil {
+		return x.Basket
+	}
+	return nil
+}
+
+func (x *RebaseRequest) GetEngine() string {
+	if x != nil {
+		return x.Engine
+	}
+	return ""
+}
+
+type RebaseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RebaseAssetId string                 `protobuf:"bytes,1,opt,name=rebase_asset_id,json=rebaseAssetId,proto3" json:"rebase_asset_id,omitempty"`
+	PathLength    uint32                 `protobuf:"varint,2,opt,name=path_length,json=pathLength,proto3" json:"path_length,omitempty"`
+	// set when a budget ran out and the market is only partially rebased
+	Partial       bool    `protobuf:"varint,3,opt,name=partial,proto3" json:"partial,omitempty"`
+	PartialReason string  `protobuf:"bytes,4,opt,name=partial_reason,json=partialReason,proto3" json:"partial_reason,omitempty"`
+	Market        []*Pair `protobuf:"bytes,5,rep,name=market,proto3" json:"market,omitempty"`
+	// symbols of the pairs whose rebased rates rely on a peg
+	Pegged []string `protobuf:"bytes,6,rep,name=pegged,proto3" json:"pegged,omitempty"`
+	// symbols of the pairs whose rebased rates rely on a reference rate
+	Referenced []string `protobuf:"bytes,7,rep,name=referenced,proto3" json:"referenced,omitempty"`
+	// with a basket, the price of one unit of each of its assets in the basket
+	BasketPrices map[string]float32 `protobuf:"bytes,8,rep,name=basket_prices,json=basketPrices,proto3" json:"basket_prices,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed32,2,opt,name=value"`
+	// with least squares, the log of every fitted pair's mid minus the log of the mid the fitted prices imply, by symbol
+	Residuals map[string]float32 `protobuf:"bytes,9,rep,name=residuals,proto3" json:"residuals,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed32,2,opt,name=value"`
+	// in updates of Subscribe, symbols of the pairs removed from the live market since the previous update
+	Removed       []string `protobuf:"bytes,10,rep,name=removed,proto3" json:"removed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RebaseResponse) Reset() {
+	*x = RebaseResponse{}
+	mi := &file_rebase_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RebaseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RebaseResponse) ProtoMessage() {}
+
+func (x *RebaseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RebaseResponse.ProtoReflect.Descriptor instead.
+func (*RebaseResponse) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RebaseResponse) GetRebaseAssetId() string {
+	if x != nil {
+		return x.RebaseAssetId
+	}
+	return ""
+}
+
+func (x *RebaseResponse) GetPathLength() uint32 {
+	if x != nil {
+		return x.PathLength
+	}
+	return 0
+}
+
+func (x *RebaseResponse) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+func (x *RebaseResponse) GetPartialReason() string {
+	if x != nil {
+		return x.PartialReason
+	}
+	return ""
+}
+
+func (x *RebaseResponse) GetMarket() []*Pair {
+	if x != nil {
+		return x.Market
+	}
+	return nil
+}
+
+func (x *RebaseResponse) GetPegged() []string {
+	if x != nil {
+		return x.Pegged
+	}
+	return nil
+}
+
+func (x *RebaseResponse) GetReferenced() []string {
+	if x != nil {
+		return x.Referenced
+	}
+	return nil
+}
+
+func (x *RebaseResponse) GetBasketPrices() map[string]float32 {
+	if x != nil {
+		return x.BasketPrices
+	}
+	return nil
+}
+
+func (x *RebaseResponse) GetResiduals() map[string]float32 {
+	if x != nil {
+		return x.Residuals
+	}
+	return nil
+}
+
+func (x *RebaseResponse) GetRemoved() []string {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+type ConvertRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Rebase      *RebaseRequest         `protobuf:"bytes,1,opt,name=rebase,proto3" json:"rebase,omitempty"`
+	FromAssetId string                 `protobuf:"bytes,2,opt,name=from_asset_id,json=fromAssetId,proto3" json:"from_asset_id,omitempty"`
+	// defaults to the rebase asset
+	ToAssetId     string  `protobuf:"bytes,3,opt,name=to_asset_id,json=toAssetId,proto3" json:"to_asset_id,omitempty"`
+	Amount        float64 `protobuf:"fixed64,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertRequest) Reset() {
+	*x = ConvertRequest{}
+	mi := &file_rebase_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertRequest) ProtoMessage() {}
+
+func (x *ConvertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertRequest.ProtoReflect.Descriptor instead.
+func (*ConvertRequest) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ConvertRequest) GetRebase() *RebaseRequest {
+	if x != nil {
+		return x.Rebase
+	}
+	return nil
+}
+
+func (x *ConvertRequest) GetFromAssetId() string {
+	if x != nil {
+		return x.FromAssetId
+	}
+	return ""
+}
+
+func (x *ConvertRequest) GetToAssetId() string {
+	if x != nil {
+		return x.ToAssetId
+	}
+	return ""
+}
+
+func (x *ConvertRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+type ConvertResponse struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	FromAssetId string                 `protobuf:"bytes,1,opt,name=from_asset_id,json=fromAssetId,proto3" json:"from_asset_id,omitempty"`
+	ToAssetId   string                 `protobuf:"bytes,2,opt,name=to_asset_id,json=toAssetId,proto3" json:"to_asset_id,omitempty"`
+	Amount      float64                `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	// amount of to_asset_id that amount of from_asset_id is worth
+	ConvertedAmount float64 `protobuf:"fixed64,4,opt,name=converted_amount,json=convertedAmount,proto3" json:"converted_amount,omitempty"`
+	PathLength      uint32  `protobuf:"varint,5,opt,name=path_length,json=pathLength,proto3" json:"path_length,omitempty"`
+	Partial         bool    `protobuf:"varint,6,opt,name=partial,proto3" json:"partial,omitempty"`
+	PartialReason   string  `protobuf:"bytes,7,opt,name=partial_reason,json=partialReason,proto3" json:"partial_reason,omitempty"`
+	// set when either price relies on a peg
+	Pegged bool `protobuf:"varint,8,opt,name=pegged,proto3" json:"pegged,omitempty"`
+	// set when either price relies on a reference rate
+	Referenced    bool `protobuf:"varint,9,opt,name=referenced,proto3" json:"referenced,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertResponse) Reset() {
+	*x = ConvertResponse{}
+	mi := &file_rebase_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertResponse) ProtoMessage() {}
+
+func (x *ConvertResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertResponse.ProtoReflect.Descriptor instead.
+func (*ConvertResponse) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ConvertResponse) GetFromAssetId() string {
+	if x != nil {
+		return x.FromAssetId
+	}
+	return ""
+}
+
+func (x *ConvertResponse) GetToAssetId() string {
+	if x != nil {
+		return x.ToAssetId
+	}
+	return ""
+}
+
+func (x *ConvertResponse) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ConvertResponse) GetConvertedAmount() float64 {
+	if x != nil {
+		return x.ConvertedAmount
+	}
+	return 0
+}
+
+func (x *ConvertResponse) GetPathLength() uint32 {
+	if x != nil {
+		return x.PathLength
+	}
+	return 0
+}
+
+func (x *ConvertResponse) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+func (x *ConvertResponse) GetPartialReason() string {
+	if x != nil {
+		return x.PartialReason
+	}
+	return ""
+}
+
+func (x *ConvertResponse) GetPegged() bool {
+	if x != nil {
+		return x.Pegged
+	}
+	return false
+}
+
+func (x *ConvertResponse) GetReferenced() bool {
+	if x != nil {
+		return x.Referenced
+	}
+	return false
+}
+
+type ReconcileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RebaseAssetId string                 `protobuf:"bytes,1,opt,name=rebase_asset_id,json=rebaseAssetId,proto3" json:"rebase_asset_id,omitempty"`
+	// largest absolute deviation first
+	Pairs []*ReconciledPair `protobuf:"bytes,2,rep,name=pairs,proto3" json:"pairs,omitempty"`
+	// set when a budget ran out and only some of the pairs were compared
+	Partial       bool   `protobuf:"varint,3,opt,name=partial,proto3" json:"partial,omitempty"`
+	PartialReason string `protobuf:"bytes,4,opt,name=partial_reason,json=partialReason,proto3" json:"partial_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReconcileResponse) Reset() {
+	*x = ReconcileResponse{}
+	mi := &file_rebase_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReconcileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReconcileResponse) ProtoMessage() {}
+
+func (x *ReconcileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReconcileResponse.ProtoReflect.Descriptor instead.
+func (*ReconcileResponse) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ReconcileResponse) GetRebaseAssetId() string {
+	if x != nil {
+		return x.RebaseAssetId
+	}
+	return ""
+}
+
+func (x *ReconcileResponse) GetPairs() []*ReconciledPair {
+	if x != nil {
+		return x.Pairs
+	}
+	return nil
+}
+
+func (x *ReconcileResponse) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+func (x *ReconcileResponse) GetPartialReason() string {
+	if x != nil {
+		return x.PartialReason
+	}
+	return ""
+}
+
+// Pair's direct mid compared with the mid implied by paths through the rebase asset that avoid it.
+type ReconciledPair struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	DirectMid     float32                `protobuf:"fixed32,2,opt,name=direct_mid,json=directMid,proto3" json:"direct_mid,omitempty"`
+	ImpliedMid    float32                `protobuf:"fixed32,3,opt,name=implied_mid,json=impliedMid,proto3" json:"implied_mid,omitempty"`
+	DeviationBps  float32                `protobuf:"fixed32,4,opt,name=deviation_bps,json=deviationBps,proto3" json:"deviation_bps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReconciledPair) Reset() {
+	*x = ReconciledPair{}
+	mi := &file_rebase_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReconciledPair) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReconciledPair) ProtoMessage() {}
+
+func (x *ReconciledPair) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReconciledPair.ProtoReflect.Descriptor instead.
+func (*ReconciledPair) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ReconciledPair) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *ReconciledPair) GetDirectMid() float32 {
+	if x != nil {
+		return x.DirectMid
+	}
+	return 0
+}
+
+func (x *ReconciledPair) GetImpliedMid() float32 {
+	if x != nil {
+		return x.ImpliedMid
+	}
+	return 0
+}
+
+func (x *ReconciledPair) GetDeviationBps() float32 {
+	if x != nil {
+		return x.DeviationBps
+	}
+	return 0
+}
+
+type SubscribeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// only pairs with either asset are sent, all pairs when empty
+	Assets []string `protobuf:"bytes,1,rep,name=assets,proto3" json:"assets,omitempty"`
+	// minimum time between updates, changes to the same pair within it are combined
+	ThrottleMs    uint32 `protobuf:"varint,2,opt,name=throttle_ms,json=throttleMs,proto3" json:"throttle_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_rebase_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rebase_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_rebase_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SubscribeRequest) GetAssets() []string {
+	if x != nil {
+		return x.Assets
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetThrottleMs() uint32 {
+	if x != nil {
+		return x.ThrottleMs
+	}
+	return 0
+}
+
+var File_rebase_proto protoreflect.FileDescriptor
+
+const file_rebase_proto_rawDesc = "" +
+	"\n" +
+	"\frebase.proto\x12\vgorebase.v1\"\xb7\x01\n" +
+	"\x0eExchangeMarket\x12\x1f\n" +
+	"\vexchange_id\x18\x01 \x01(\tR\n" +
+	"exchangeId\x12\x1f\n" +
+	"\vcurrent_bid\x18\x02 \x01(\x02R\n" +
+	"currentBid\x12\x1f\n" +
+	"\vcurrent_ask\x18\x03 \x01(\x02R\n" +
+	"currentAsk\x12\x1f\n" +
+	"\vbase_volume\x18\x04 \x01(\x02R\n" +
+	"baseVolume\x12!\n" +
+	"\fquote_volume\x18\x05 \x01(\x02R\vquoteVolume\"\x98\x01\n" +
+	"\x04Pair\x12\"\n" +
+	"\rbase_asset_id\x18\x01 \x01(\tR\vbaseAssetId\x12$\n" +
+	"\x0equote_asset_id\x18\x02 \x01(\tR\fquoteAssetId\x12F\n" +
+	"\x10exchange_markets\x18\x03 \x03(\v2\x1b.gorebase.v1.ExchangeMarketR\x0fexchangeMarkets\"\xfa\x01\n" +
+	"\x06Filter\x12%\n" +
+	"\x0einclude_assets\x18\x01 \x03(\tR\rincludeAssets\x12%\n" +
+	"\x0eexclude_assets\x18\x02 \x03(\tR\rexcludeAssets\x12#\n" +
+	"\rinclude_pairs\x18\x03 \x03(\tR\fincludePairs\x12#\n" +
+	"\rexclude_pairs\x18\x04 \x03(\tR\fexcludePairs\x12+\n" +
+	"\x11include_exchanges\x18\x05 \x03(\tR\x10includeExchanges\x12+\n" +
+	"\x11exclude_exchanges\x18\x06 \x03(\tR\x10excludeExchanges\"|\n" +
+	"\x0eAutoPathLength\x12\x1c\n" +
+	"\ttolerance\x18\x01 \x01(\x02R\ttolerance\x12&\n" +
+	"\x0fmax_path_length\x18\x02 \x01(\rR\rmaxPathLength\x12$\n" +
+	"\x0etime_budget_ms\x18\x03 \x01(\rR\ftimeBudgetMs\"x\n" +
+	"\x06Budget\x12+\n" +
+	"\x12max_paths_per_pair\x18\x01 \x01(\rR\x0fmaxPathsPerPair\x12\x1b\n" +
+	"\tmax_paths\x18\x02 \x01(\rR\bmaxPaths\x12$\n" +
+	"\x0etime_budget_ms\x18\x03 \x01(\rR\ftimeBudgetMs\"\xce\x01\n" +
+	"\x06Assets\x12:\n" +
+	"\aaliases\x18\x01 \x03(\v2 .gorebase.v1.Assets.AliasesEntryR\aaliases\x125\n" +
+	"\n" +
+	"equivalent\x18\x02 \x03(\v2\x15.gorebase.v1.AssetIdsR\n" +
+	"equivalent\x1aQ\n" +
+	"\fAliasesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12+\n" +
+	"\x05value\x18\x02 \x01(\v2\x15.gorebase.v1.AssetIdsR\x05value:\x028\x01\"'\n" +
+	"\bAssetIds\x12\x1b\n" +
+	"\tasset_ids\x18\x01 \x03(\tR\bassetIds\"m\n" +
+	"\rReferenceRate\x12\"\n" +
+	"\rbase_asset_id\x18\x01 \x01(\tR\vbaseAssetId\x12$\n" +
+	"\x0equote_asset_id\x18\x02 \x01(\tR\fquoteAssetId\x12\x12\n" +
+	"\x04rate\x18\x03 \x01(\x02R\x04rate\"v\n" +
+	"\x0eReferenceRates\x120\n" +
+	"\x05rates\x18\x01 \x03(\v2\x1a.gorebase.v1.ReferenceRateR\x05rates\x12\x1a\n" +
+	"\bfallback\x18\x02 \x01(\bR\bfallback\x12\x16\n" +
+	"\x06volume\x18\x03 \x01(\x02R\x06volume\"\xa5\x01\n" +
+	"\x03Peg\x12\x19\n" +
+	"\basset_id\x18\x01 \x01(\tR\aassetId\x12\x1b\n" +
+	"\tpegged_to\x18\x02 \x01(\tR\bpeggedTo\x12\x14\n" +
+	"\x05ratio\x18\x03 \x01(\x02R\x05ratio\x12\x1c\n" +
+	"\ttolerance\x18\x04 \x01(\x02R\ttolerance\x12\x1a\n" +
+	"\bfallback\x18\x05 \x01(\bR\bfallback\x12\x16\n" +
+	"\x06volume\x18\x06 \x01(\x02R\x06volume\"X\n" +
+	"\x06Basket\x120\n" +
+	"\x06assets\x18\x01 \x03(\v2\x18.gorebase.v1.BasketAssetR\x06assets\x12\x1c\n" +
+	"\tgeometric\x18\x02 \x01(\bR\tgeometric\"@\n" +
+	"\vBasketAsset\x12\x19\n" +
+	"\basset_id\x18\x01 \x01(\tR\aassetId\x12\x16\n" +
+	"\x06weight\x18\x02 \x01(\x02R\x06weight\"\xb5\x05\n" +
+	"\rRebaseRequest\x12&\n" +
+	"\x0frebase_asset_id\x18\x01 \x01(\tR\rrebaseAssetId\x12&\n" +
+	"\x0fmax_path_length\x18\x02 \x01(\rR\rmaxPathLength\x12E\n" +
+	"\x10auto_path_length\x18\x03 \x01(\v2\x1b.gorebase.v1.AutoPathLengthR\x0eautoPathLength\x12%\n" +
+	"\x0epath_weighting\x18\x04 \x01(\tR\rpathWeighting\x12.\n" +
+	"\x13min_combined_volume\x18\x05 \x01(\x02R\x11minCombinedVolume\x12#\n" +
+	"\rmin_exchanges\x18\x06 \x01(\rR\fminExchanges\x12.\n" +
+	"\x13max_relative_spread\x18\a \x01(\x02R\x11maxRelativeSpread\x12+\n" +
+	"\x06filter\x18\b \x01(\v2\x13.gorebase.v1.FilterR\x06filter\x12+\n" +
+	"\x06budget\x18\t \x01(\v2\x13.gorebase.v1.BudgetR\x06budget\x12)\n" +
+	"\x06market\x18\n" +
+	" \x03(\v2\x11.gorebase.v1.PairR\x06market\x12+\n" +
+	"\x06assets\x18\v \x01(\v2\x13.gorebase.v1.AssetsR\x06assets\x12$\n" +
+	"\x04pegs\x18\f \x03(\v2\x10.gorebase.v1.PegR\x04pegs\x12D\n" +
+	"\x0freference_rates\x18\r \x01(\v2\x1b.gorebase.v1.ReferenceRatesR\x0ereferenceRates\x12+\n" +
+	"\x06basket\x18\x0e \x01(\v2\x13.gorebase.v1.BasketR\x06basket\x12\x16\n" +
+	"\x06engine\x18\x0f \x01(\tR\x06engine\"\xb4\x04\n" +
+	"\x0eRebaseResponse\x12&\n" +
+	"\x0frebase_asset_id\x18\x01 \x01(\tR\rrebaseAssetId\x12\x1f\n" +
+	"\vpath_length\x18\x02 \x01(\rR\n" +
+	"pathLength\x12\x18\n" +
+	"\apartial\x18\x03 \x01(\bR\apartial\x12%\n" +
+	"\x0epartial_reason\x18\x04 \x01(\tR\rpartialReason\x12)\n" +
+	"\x06market\x18\x05 \x03(\v2\x11.gorebase.v1.PairR\x06market\x12\x16\n" +
+	"\x06pegged\x18\x06 \x03(\tR\x06pegged\x12\x1e\n" +
+	"\n" +
+	"referenced\x18\a \x03(\tR\n" +
+	"referenced\x12R\n" +
+	"\rbasket_prices\x18\b \x03(\v2-.gorebase.v1.RebaseResponse.BasketPricesEntryR\fbasketPrices\x12H\n" +
+	"\tresiduals\x18\t \x03(\v2*.gorebase.v1.RebaseResponse.ResidualsEntryR\tresiduals\x12\x18\n" +
+	"\aremoved\x18\n" +
+	" \x03(\tR\aremoved\x1a?\n" +
+	"\x11BasketPricesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x02R\x05value:\x028\x01\x1a<\n" +
+	"\x0eResidualsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x02R\x05value:\x028\x01\"\xa0\x01\n" +
+	"\x0eConvertRequest\x122\n" +
+	"\x06rebase\x18\x01 \x01(\v2\x1a.gorebase.v1.RebaseRequestR\x06rebase\x12\"\n" +
+	"\rfrom_asset_id\x18\x02 \x01(\tR\vfromAssetId\x12\x1e\n" +
+	"\vto_asset_id\x18\x03 \x01(\tR\ttoAssetId\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x01R\x06amount\"\xb2\x02\n" +
+	"\x0fConvertResponse\x12\"\n" +
+	"\rfrom_asset_id\x18\x01 \x01(\tR\vfromAssetId\x12\x1e\n" +
+	"\vto_asset_id\x18\x02 \x01(\tR\ttoAssetId\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x01R\x06amount\x12)\n" +
+	"\x10converted_amount\x18\x04 \x01(\x01R\x0fconvertedAmount\x12\x1f\n" +
+	"\vpath_length\x18\x05 \x01(\rR\n" +
+	"pathLength\x12\x18\n" +
+	"\apartial\x18\x06 \x01(\bR\apartial\x12%\n" +
+	"\x0epartial_reason\x18\a \x01(\tR\rpartialReason\x12\x16\n" +
+	"\x06pegged\x18\b \x01(\bR\x06pegged\x12\x1e\n" +
+	"\n" +
+	"referenced\x18\t \x01(\bR\n" +
+	"referenced\"\xaf\x01\n" +
+	"\x11ReconcileResponse\x12&\n" +
+	"\x0frebase_asset_id\x18\x01 \x01(\tR\rrebaseAssetId\x121\n" +
+	"\x05pairs\x18\x02 \x03(\v2\x1b.gorebase.v1.ReconciledPairR\x05pairs\x12\x18\n" +
+	"\apartial\x18\x03 \x01(\bR\apartial\x12%\n" +
+	"\x0epartial_reason\x18\x04 \x01(\tR\rpartialReason\"\x8d\x01\n" +
+	"\x0eReconciledPair\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x1d\n" +
+	"\n" +
+	"direct_mid\x18\x02 \x01(\x02R\tdirectMid\x12\x1f\n" +
+	"\vimplied_mid\x18\x03 \x01(\x02R\n" +
+	"impliedMid\x12#\n" +
+	"\rdeviation_bps\x18\x04 \x01(\x02R\fdeviationBps\"K\n" +
+	"\x10SubscribeRequest\x12\x16\n" +
+	"\x06assets\x18\x01 \x03(\tR\x06assets\x12\x1f\n" +
+	"\vthrottle_ms\x18\x02 \x01(\rR\n" +
+	"throttleMs2\xac\x02\n" +
+	"\rRebaseService\x12A\n" +
+	"\x06Rebase\x12\x1a.gorebase.v1.RebaseRequest\x1a\x1b.gorebase.v1.RebaseResponse\x12D\n" +
+	"\aConvert\x12\x1b.gorebase.v1.ConvertRequest\x1a\x1c.gorebase.v1.ConvertResponse\x12G\n" +
+	"\tReconcile\x12\x1a.gorebase.v1.RebaseRequest\x1a\x1e.gorebase.v1.ReconcileResponse\x12I\n" +
+	"\tSubscribe\x12\x1d.gorebase.v1.SubscribeRequest\x1a\x1b.gorebase.v1.RebaseResponse0\x01B2Z0github.com/jochenboesmans/go-rebase/rpc/rebasepbb\x06proto3"
+
+var (
+	file_rebase_proto_rawDescOnce sync.Once
+	file_rebase_proto_rawDescData []byte
+)
+
+func file_rebase_proto_rawDescGZIP() []byte {
+	file_rebase_proto_rawDescOnce.Do(func() {
+		file_rebase_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_rebase_proto_rawDesc), len(file_rebase_proto_rawDesc)))
+	})
+	return file_rebase_proto_rawDescData
+}
+
+var file_rebase_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_rebase_proto_goTypes = []any{
+	(*ExchangeMarket)(nil),    // 0: gorebase.v1.ExchangeMarket
+	(*Pair)(nil),              // 1: gorebase.v1.Pair
+	(*Filter)(nil),            // 2: gorebase.v1.Filter
+	(*AutoPathLength)(nil),    // 3: gorebase.v1.AutoPathLength
+	(*Budget)(nil),            // 4: gorebase.v1.Budget
+	(*Assets)(nil),            // 5: gorebase.v1.Assets
+	(*AssetIds)(nil),          // 6: gorebase.v1.AssetIds
+	(*ReferenceRate)(nil),     // 7: gorebase.v1.ReferenceRate
+	(*ReferenceRates)(nil),    // 8: gorebase.v1.ReferenceRates
+	(*Peg)(nil),               // 9: gorebase.v1.Peg
+	(*Basket)(nil),            // 10: gorebase.v1.Basket
+	(*BasketAsset)(nil),       // 11: gorebase.v1.BasketAsset
+	(*RebaseRequest)(nil),     // 12: gorebase.v1.RebaseRequest
+	(*RebaseResponse)(nil),    // 13: gorebase.v1.RebaseResponse
+	(*ConvertRequest)(nil),    // 14: gorebase.v1.ConvertRequest
+	(*ConvertResponse)(nil),   // 15: gorebase.v1.ConvertResponse
+	(*ReconcileResponse)(nil), // 16: gorebase.v1.ReconcileResponse
+	(*ReconciledPair)(nil),    // 17: gorebase.v1.ReconciledPair
+	(*SubscribeRequest)(nil),  // 18: gorebase.v1.SubscribeRequest
+	nil,                       // 19: gorebase.v1.Assets.AliasesEntry
+	nil,                       // 20: gorebase.v1.RebaseResponse.BasketPricesEntry
+	nil,                       // 21: gorebase.v1.RebaseResponse.ResidualsEntry
+}
+var file_rebase_proto_depIdxs = []int32{
+	0,  // 0: gorebase.v1.Pair.exchange_markets:type_name -> gorebase.v1.ExchangeMarket
+	19, // 1: gorebase.v1.Assets.aliases:type_name -> gorebase.v1.Assets.AliasesEntry
+	6,  // 2: gorebase.v1.Assets.equivalent:type_name -> gorebase.v1.AssetIds
+	7,  // 3: gorebase.v1.ReferenceRates.rates:type_name -> gorebase.v1.ReferenceRate
+	11, // 4: gorebase.v1.Basket.assets:type_name -> gorebase.v1.BasketAsset
+	3,  // 5: gorebase.v1.RebaseRequest.auto_path_length:type_name -> gorebase.v1.AutoPathLength
+	2,  // 6: gorebase.v1.RebaseRequest.filter:type_name -> gorebase.v1.Filter
+	4,  // 7: gorebase.v1.RebaseRequest.budget:type_name -> gorebase.v1.Budget
+	1,  // 8: gorebase.v1.RebaseRequest.market:type_name -> gorebase.v1.Pair
+	5,  // 9: gorebase.v1.RebaseRequest.assets:type_name -> gorebase.v1.Assets
+	9,  // 10: gorebase.v1.RebaseRequest.pegs:type_name -> gorebase.v1.Peg
+	8,  // 11: gorebase.v1.RebaseRequest.reference_rates:type_name -> gorebase.v1.ReferenceRates
+	10, // 12: gorebase.v1.RebaseRequest.basket:type_name -> gorebase.v1.Basket
+	1,  // 13: gorebase.v1.RebaseResponse.market:type_name -> gorebase.v1.Pair
+	20, // 14: gorebase.v1.RebaseResponse.basket_prices:type_name -> gorebase.v1.RebaseResponse.BasketPricesEntry
+	21, // 15: gorebase.v1.RebaseResponse.residuals:type_name -> gorebase.v1.RebaseResponse.ResidualsEntry
+	12, // 16: gorebase.v1.ConvertRequest.rebase:type_name -> gorebase.v1.RebaseRequest
+	17, // 17: gorebase.v1.ReconcileResponse.pairs:type_name -> gorebase.v1.ReconciledPair
+	6,  // 18: gorebase.v1.Assets.AliasesEntry.value:type_name -> gorebase.v1.AssetIds
+	12, // 19: gorebase.v1.RebaseService.Rebase:input_type -> gorebase.v1.RebaseRequest
+	14, // 20: gorebase.v1.RebaseService.Convert:input_type -> gorebase.v1.ConvertRequest
+	12, // 21: gorebase.v1.RebaseService.Reconcile:input_type -> gorebase.v1.RebaseRequest
+	18, // 22: gorebase.v1.RebaseService.Subscribe:input_type -> gorebase.v1.SubscribeRequest
+	13, // 23: gorebase.v1.RebaseService.Rebase:output_type -> gorebase.v1.RebaseResponse
+	15, // 24: gorebase.v1.RebaseService.Convert:output_type -> gorebase.v1.ConvertResponse
+	16, // 25: gorebase.v1.RebaseService.Reconcile:output_type -> gorebase.v1.ReconcileResponse
+	13, // 26: gorebase.v1.RebaseService.Subscribe:output_type -> gorebase.v1.RebaseResponse
+	23, // [23:27] is the sub-list for method output_type
+	19, // [19:23] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
+}
+
+func init() { file_rebase_proto_init() }
+func file_rebase_proto_init() {
+	if File_rebase_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_rebase_proto_rawDesc), len(file_rebase_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   22,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_rebase_proto_goTypes,
+		DependencyIndexes: file_rebase_proto_depIdxs,
+		MessageInfos:      file_rebase_proto_msgTypes,
+	}.Build()
+	File_rebase_proto = out.File
+	file_rebase_proto_goTypes = nil
+	file_rebase_proto_depIdxs = nil
+}
@@ -0,0 +1,253 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: rebase.proto
+
+package rebasepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RebaseService_Rebase_FullMethodName    = "/gorebase.v1.RebaseService/Rebase"
+	RebaseService_Convert_FullMethodName   = "/gorebase.v1.RebaseService/Convert"
+	RebaseService_Reconcile_FullMethodName = "/gorebase.v1.RebaseService/Reconcile"
+	RebaseService_Subscribe_FullMethodName = "/gorebase.v1.RebaseService/Subscribe"
+)
+
+// RebaseServiceClient is the client API for RebaseService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Rebases markets in a rebase asset, converts amounts between assets, reconciles pairs with each other and streams
+// rebased changes of a live market.
+type RebaseServiceClient interface {
+	// Rebases the market of the request.
+	Rebase(ctx context.Context, in *RebaseRequest, opts ...grpc.CallOption) (*RebaseResponse, error)
+	// Converts an amount of one asset into another at the prices of the request's rebased market.
+	Convert(ctx context.Context, in *ConvertRequest, opts ...grpc.CallOption) (*ConvertResponse, error)
+	// Compares the mid of every pair of the request's market with the mid that the other pairs imply.
+	Reconcile(ctx context.Context, in *RebaseRequest, opts ...grpc.CallOption) (*ReconcileResponse, error)
+	// Streams the server's live rebased market, first whole and then its changes.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RebaseResponse], error)
+}
+
+type rebaseServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRebaseServiceClient(cc grpc.ClientConnInterface) RebaseServiceClient {
+	return &rebaseServiceClient{cc}
+}
+
+func (c *rebaseServiceClient) Rebase(ctx context.Context, in *RebaseRequest, opts ...grpc.CallOption) (*RebaseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RebaseResponse)
+	err := c.cc.Invoke(ctx, RebaseService_Rebase_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rebaseServiceClient) Convert(ctx context.Context, in *ConvertRequest, opts ...grpc.CallOption) (*ConvertResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConvertResponse)
+	err := c.cc.Invoke(ctx, RebaseService_Convert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rebaseServiceClient) Reconcile(ctx context.Context, in *RebaseRequest, opts ...grpc.CallOption) (*ReconcileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReconcileResponse)
+	err := c.cc.Invoke(ctx, RebaseService_Reconcile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rebaseServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RebaseResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RebaseService_ServiceDesc.Streams[0], RebaseService_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, RebaseResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RebaseService_SubscribeClient = grpc.ServerStreamingClient[RebaseResponse]
+
+// RebaseServiceServer is the server API for RebaseService service.
+// All implementations must embed UnimplementedRebaseServiceServer
+// for forward compatibility.
+//
+// Rebases markets in a rebase asset, converts amounts between assets, reconciles pairs with each other and streams
+// rebased changes of a live market.
+type RebaseServiceServer interface {
+	// Rebases the market of the request.
+	Rebase(context.Context, *RebaseRequest) (*RebaseResponse, error)
+	// Converts an amount of one asset into another at the prices of the request's rebased market.
+	Convert(context.Context, *ConvertRequest) (*ConvertResponse, error)
+	// Compares the mid of every pair of the request's market with the mid that the other pairs imply.
+	Reconcile(context.Context, *RebaseRequest) (*ReconcileResponse, error)
+	// Streams the server's live rebased market, first whole and then its changes.
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[RebaseResponse]) error
+	mustEmbedUnimplementedRebaseServiceServer()
+}
+
+// UnimplementedRebaseServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRebaseServiceServer struct{}
+
+func (UnimplementedRebaseServiceServer) Rebase(context.Context, *RebaseRequest) (*RebaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rebase not implemented")
+}
+func (UnimplementedRebaseServiceServer) Convert(context.Context, *ConvertRequest) (*ConvertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Convert not implemented")
+}
+func (UnimplementedRebaseServiceServer) Reconcile(context.Context, *RebaseRequest) (*ReconcileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reconcile not implemented")
+}
+func (UnimplementedRebaseServiceServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[RebaseResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedRebaseServiceServer) mustEmbedUnimplementedRebaseServiceServer() {}
+func (UnimplementedRebaseServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeRebaseServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RebaseServiceServer will
+// result in compilation errors.
+type UnsafeRebaseServiceServer interface {
+	mustEmbedUnimplementedRebaseServiceServer()
+}
+
+func RegisterRebaseServiceServer(s grpc.ServiceRegistrar, srv RebaseServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRebaseServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RebaseService_ServiceDesc, srv)
+}
+
+func _RebaseService_Rebase_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RebaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RebaseServiceServer).Rebase(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RebaseService_Rebase_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RebaseServiceServer).Rebase(ctx, req.(*RebaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RebaseService_Convert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RebaseServiceServer).Convert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RebaseService_Convert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RebaseServiceServer).Convert(ctx, req.(*ConvertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RebaseService_Reconcile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RebaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RebaseServiceServer).Reconcile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RebaseService_Reconcile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RebaseServiceServer).Reconcile(ctx, req.(*RebaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RebaseService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RebaseServiceServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, RebaseResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RebaseService_SubscribeServer = grpc.ServerStreamingServer[RebaseResponse]
+
+// RebaseService_ServiceDesc is the grpc.ServiceDesc for RebaseService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RebaseService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gorebase.v1.RebaseService",
+	HandlerType: (*RebaseServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Rebase",
+			Handler:    _RebaseService_Rebase_Handler,
+		},
+		{
+			MethodName: "Convert",
+			Handler:    _RebaseService_Convert_Handler,
+		},
+		{
+			MethodName: "Reconcile",
+			Handler:    _RebaseService_Reconcile_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _RebaseService_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rebase.proto",
+}
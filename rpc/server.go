@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jochenboesmans/go-rebase/api"
+	"github.com/jochenboesmans/go-rebase/rpc/rebasepb"
+	"github.com/jochenboesmans/go-rebase/streaming"
+	"github.com/jochenboesmans/go-rebase/wire"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/**
+gRPC implementation of the rebase service. Rebase, Convert and Reconcile handle requests like the Lambda does,
+Subscribe streams the live market of a streaming server.
+*/
+type Server struct {
+	rebasepb.UnimplementedRebaseServiceServer
+	// nil when there's no live market to subscribe to
+	live *streaming.Server
+}
+
+func NewServer(live *streaming.Server) *Server {
+	return &Server{live: live}
+}
+
+// registers the rebase service on a new gRPC server
+func NewGRPCServer(live *streaming.Server, opts ...grpc.ServerOption) *grpc.Server {
+	grpcServer := grpc.NewServer(opts...)
+	rebasepb.RegisterRebaseServiceServer(grpcServer, NewServer(live))
+	return grpcServer
+}
+
+func (s *Server) Rebase(ctx context.Context, request *rebasepb.RebaseRequest) (*rebasepb.RebaseResponse, error) {
+	input, err := wire.InputFromProto(request)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	output, err := api.Rebase(ctx, input)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return wire.OutputToProto(output), nil
+}
+
+func (s *Server) Convert(ctx context.Context, request *rebasepb.ConvertRequest) (*rebasepb.ConvertResponse, error) {
+	input, err := wire.ConvertInputFromProto(request)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	output, err := api.Convert(ctx, input)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return wire.ConvertOutputToProto(output), nil
+}
+
+func (s *Server) Reconcile(ctx context.Context, request *rebasepb.RebaseRequest) (*rebasepb.ReconcileResponse, error) {
+	input, err := wire.InputFromProto(request)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	output, err := api.Reconcile(ctx, input)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return wire.ReconcileOutputToProto(output), nil
+}
+
+// cancelled and timed out requests keep their own codes, any other error is down to the request
+func statusError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+func (s *Server) Subscribe(request *rebasepb.SubscribeRequest, stream grpc.ServerStreamingServer[rebasepb.RebaseResponse]) error {
+	if s.live == nil {
+		return status.Error(codes.Unavailable, "the server has no live market")
+	}
+	options := streaming.SubscriptionOptions{
+		Assets:   request.GetAssets(),
+		Throttle: time.Duration(request.GetThrottleMs()) * time.Millisecond,
+	}
+	err := s.live.Subscribe(stream.Context(), options, func(update streaming.Update) error {
+		return stream.Send(wire.UpdateToProto(update))
+	})
+	// the subscriber leaving isn't an error
+	if stream.Context().Err() != nil {
+		return nil
+	}
+	return err
+}
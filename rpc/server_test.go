@@ -0,0 +1,182 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	"github.com/jochenboesmans/go-rebase/rebasing"
+	"github.com/jochenboesmans/go-rebase/rpc/rebasepb"
+	"github.com/jochenboesmans/go-rebase/streaming"
+	"github.com/jochenboesmans/go-rebase/wire"
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+)
+
+func mockRatePair(baseId string, quoteId string, rate float32) *rebasepb.Pair {
+	return &rebasepb.Pair{
+		BaseAssetId:  baseId,
+		QuoteAssetId: quoteId,
+		ExchangeMarkets: []*rebasepb.ExchangeMarket{
+			{
+				CurrentBid: rate,
+				CurrentAsk: rate,
+				BaseVolume: 1,
+			},
+		},
+	}
+}
+
+// client of a gRPC server that's only reachable in memory, and a function to stop both
+func mockClient(live *streaming.Server) (rebasepb.RebaseServiceClient, func()) {
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := NewGRPCServer(live)
+	go grpcServer.Serve(listener)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	So(err, ShouldBeNil)
+	return rebasepb.NewRebaseServiceClient(conn), func() {
+		conn.Close()
+		grpcServer.Stop()
+	}
+}
+
+func TestServer_Rebase(t *testing.T) {
+	Convey("rebases the market of the request", t, func() {
+		client, stop := mockClient(nil)
+		defer stop()
+
+		actual, err := client.Rebase(context.Background(), &rebasepb.RebaseRequest{
+			RebaseAssetId: "1",
+			MaxPathLength: 3,
+			Market:        []*rebasepb.Pair{mockRatePair("1", "2", 2), mockRatePair("2", "3", 3)},
+		})
+
+		So(err, ShouldBeNil)
+		So(actual.GetRebaseAssetId(), ShouldEqual, "1")
+		So(actual.GetPathLength(), ShouldEqual, 3)
+		So(actual.GetMarket(), ShouldHaveLength, 2)
+		for _, pair := range actual.GetMarket() {
+			if pair.GetBaseAssetId() == "2" {
+				So(pair.GetExchangeMarkets()[0].GetCurrentBid(), ShouldEqual, 6)
+			}
+		}
+	})
+	Convey("invalid requests", t, func() {
+		client, stop := mockClient(nil)
+		defer stop()
+
+		_, err := client.Rebase(context.Background(), &rebasepb.RebaseRequest{PathWeighting: "foo"})
+
+		So(status.Code(err), ShouldEqual, codes.InvalidArgument)
+		_, err = client.Rebase(context.Background(), &rebasepb.RebaseRequest{RebaseAssetId: "1", MaxPathLength: 256})
+		So(status.Code(err), ShouldEqual, codes.InvalidArgument)
+	})
+	Convey("cancelled and timed out requests", t, func() {
+		So(status.Code(statusError(fmt.Errorf("rebasing: %w", context.Canceled))), ShouldEqual, codes.Canceled)
+		So(status.Code(statusError(context.DeadlineExceeded)), ShouldEqual, codes.DeadlineExceeded)
+	})
+}
+
+func TestServer_Convert(t *testing.T) {
+	Convey("converts at the prices of the rebased market", t, func() {
+		client, stop := mockClient(nil)
+		defer stop()
+
+		actual, err := client.Convert(context.Background(), &rebasepb.ConvertRequest{
+			Rebase: &rebasepb.RebaseRequest{
+				RebaseAssetId: "1",
+				MaxPathLength: 3,
+				Market:        []*rebasepb.Pair{mockRatePair("1", "2", 2), mockRatePair("2", "3", 3)},
+			},
+			FromAssetId: "3",
+			Amount:      2,
+		})
+
+		So(err, ShouldBeNil)
+		So(actual.GetToAssetId(), ShouldEqual, "1")
+		So(actual.GetConvertedAmount(), ShouldEqual, 12)
+	})
+}
+
+func TestServer_Reconcile(t *testing.T) {
+	Convey("compares direct mids with the mids implied by the other pairs", t, func() {
+		client, stop := mockClient(nil)
+		defer stop()
+
+		actual, err := client.Reconcile(context.Background(), &rebasepb.RebaseRequest{
+			RebaseAssetId: "1",
+			MaxPathLength: 3,
+			Market:        []*rebasepb.Pair{mockRatePair("1", "2", 2), mockRatePair("2", "3", 3), mockRatePair("1", "3", 5)},
+		})
+
+		So(err, ShouldBeNil)
+		So(actual.GetRebaseAssetId(), ShouldEqual, "1")
+		So(actual.GetPairs(), ShouldHaveLength, 2)
+		So(actual.GetPairs()[0].GetSymbol(), ShouldEqual, "2/3")
+		So(actual.GetPairs()[0].GetImpliedMid(), ShouldAlmostEqual, 2.5, 0.0001)
+	})
+}
+
+func TestServer_Subscribe(t *testing.T) {
+	Convey("streams the live market and then its changes", t, func() {
+		mockPair := m.Pair{
+			BaseAssetId:  "1",
+			QuoteAssetId: "2",
+			ExchangeMarkets: []m.ExchangeMarket{
+				{
+					CurrentBid: 2,
+					CurrentAsk: 2,
+					BaseVolume: 1,
+				},
+			},
+		}
+		mockMarket := m.Market{PairsById: map[string]m.Pair{mockPair.Id(): mockPair}}
+		live, err := streaming.NewServer(context.Background(), rebasing.NewEngine(rebasing.WithMaxPathDepth(3)), "1", &mockMarket)
+		So(err, ShouldBeNil)
+		client, stop := mockClient(live)
+		defer stop()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stream, err := client.Subscribe(ctx, &rebasepb.SubscribeRequest{})
+		So(err, ShouldBeNil)
+		initialUpdate, err := stream.Recv()
+		So(err, ShouldBeNil)
+		So(initialUpdate.GetMarket(), ShouldHaveLength, 1)
+
+		_, err = live.Upsert(context.Background(), wire.PairsFromProto([]*rebasepb.Pair{mockRatePair("2", "3", 3)}))
+		So(err, ShouldBeNil)
+		update, err := stream.Recv()
+		So(err, ShouldBeNil)
+		So(update.GetMarket(), ShouldHaveLength, 1)
+		So(update.GetMarket()[0].GetExchangeMarkets()[0].GetCurrentBid(), ShouldEqual, 6)
+
+		_, err = live.Remove(context.Background(), wire.PairsFromProto(update.GetMarket())[0].Id())
+		So(err, ShouldBeNil)
+		update, err = stream.Recv()
+		So(err, ShouldBeNil)
+		So(update.GetRemoved(), ShouldResemble, []string{"2/3"})
+	})
+	Convey("without a live market", t, func() {
+		client, stop := mockClient(nil)
+		defer stop()
+
+		stream, err := client.Subscribe(context.Background(), &rebasepb.SubscribeRequest{})
+		So(err, ShouldBeNil)
+		_, err = stream.Recv()
+
+		So(status.Code(err), ShouldEqual, codes.Unavailable)
+	})
+}
@@ -0,0 +1,43 @@
+package streaming
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// streams updates as Server-Sent Events with the event type "update"
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming isn't supported", http.StatusInternalServerError)
+		return
+	}
+	options, err := subscriptionOptionsFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	_ = s.Subscribe(r.Context(), options, func(update Update) error {
+		if err := writeEvent(w, update); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+}
+
+func writeEvent(w http.ResponseWriter, update Update) error {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: update\ndata: %s\n\n", data)
+	return err
+}
@@ -0,0 +1,72 @@
+package streaming
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// reads the next update event from a Server-Sent Events stream
+func readEvent(reader *bufio.Reader) Update {
+	var update Update
+	for {
+		line, err := reader.ReadString('\n')
+		So(err, ShouldBeNil)
+		if strings.HasPrefix(line, "data: ") {
+			So(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &update), ShouldBeNil)
+			return update
+		}
+	}
+}
+
+func TestServer_Events(t *testing.T) {
+	Convey("subscribers get the rebased market and then its filtered changes", t, func() {
+		server := mockServer()
+		httpServer := httptest.NewServer(server)
+		defer httpServer.Close()
+
+		response, err := http.Get(httpServer.URL + "/events?assets=3")
+		So(err, ShouldBeNil)
+		defer response.Body.Close()
+		So(response.Header.Get("Content-Type"), ShouldEqual, "text/event-stream")
+		reader := bufio.NewReader(response.Body)
+
+		initialUpdate := readEvent(reader)
+		So(initialUpdate.RebaseAssetId, ShouldEqual, "1")
+		So(initialUpdate.Market, ShouldHaveLength, 1)
+		So(initialUpdate.Market[0].Symbol(), ShouldEqual, "2/3")
+
+		_, err = server.Upsert(context.Background(), []m.Pair{mockRatePair("4", "5", 1), mockRatePair("3", "4", 4)})
+		So(err, ShouldBeNil)
+
+		update := readEvent(reader)
+		So(update.Market, ShouldHaveLength, 1)
+		So(update.Market[0].Symbol(), ShouldEqual, "3/4")
+		So(update.Market[0].ExchangeMarkets[0].CurrentBid, ShouldEqual, 24)
+	})
+	Convey("subscribers get the symbols of removed pairs", t, func() {
+		server := mockServer()
+		httpServer := httptest.NewServer(server)
+		defer httpServer.Close()
+
+		response, err := http.Get(httpServer.URL + "/events?assets=3")
+		So(err, ShouldBeNil)
+		defer response.Body.Close()
+		reader := bufio.NewReader(response.Body)
+		readEvent(reader)
+
+		mockPairB := mockRatePair("2", "3", 3)
+		_, err = server.Remove(context.Background(), mockPairB.Id())
+		So(err, ShouldBeNil)
+
+		update := readEvent(reader)
+		So(update.Market, ShouldBeEmpty)
+		So(update.Removed, ShouldResemble, []string{"2/3"})
+	})
+}
@@ -0,0 +1,243 @@
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	"github.com/jochenboesmans/go-rebase/rebasing"
+)
+
+/**
+Rebased pairs pushed to subscribers, in the same shape as the output of a rebase request.
+The first update a subscriber gets holds the whole rebased market, later ones only the pairs that changed.
+*/
+type Update struct {
+	RebaseAssetId string   `json:"rebaseAssetId"`
+	PathLength    uint8    `json:"pathLength"`
+	Market        []m.Pair `json:"market"`
+	// symbols of the pairs removed from the market since the previous update, sorted
+	Removed []string `json:"removed,omitempty"`
+}
+
+// largest body of posted pair updates that is read, in bytes
+const maxPairsBodyBytes = 10 << 20
+
+/**
+Outcome of posting pair updates.
+*/
+type UpdateResult struct {
+	ChangedPairs int `json:"changedPairs"`
+	RemovedPairs int `json:"removedPairs,omitempty"`
+	// set when a budget ran out and not every affected pair was rebased
+	Partial       bool   `json:"partial,omitempty"`
+	PartialReason string `json:"partialReason,omitempty"`
+}
+
+/**
+HTTP server that holds a live market, accepts pair updates and pushes rebased changes to subscribers.
+
+	POST   /pairs                          upserts a JSON array of pairs, of at most 10 MiB
+	DELETE /pairs?baseId=...&quoteId=...   removes a pair
+	GET    /market                         returns the whole rebased market
+	GET    /events                         streams updates as Server-Sent Events
+	GET    /ws                             streams updates over a WebSocket
+
+Subscribers can pass assets=A,B to only get pairs with either asset, and throttleMs to get at most one update per
+interval. Updates to the same pair within an interval are combined. Removed pairs are sent by symbol in Removed.
+Posted updates run under the server's context rather than the request's, as they change the market every subscriber
+sees.
+*/
+type Server struct {
+	// bounds the updates posted over HTTP, which go on when the client that posted them leaves
+	ctx      context.Context
+	rebaseId string
+	// guards the rebaser, which isn't safe for concurrent use
+	mutex       sync.Mutex
+	rebaser     *rebasing.Rebaser
+	subscribers *subscribers
+	mux         *http.ServeMux
+}
+
+/**
+Rebases the market and serves it. The context bounds the initial rebase and every update posted over HTTP.
+*/
+func NewServer(ctx context.Context, engine *rebasing.Engine, rebaseId string, market *m.Market) (*Server, error) {
+	rebaser, err := engine.NewRebaser(ctx, rebaseId, market)
+	if err != nil && !isPartial(err) {
+		return nil, err
+	}
+	server := Server{
+		ctx:         ctx,
+		rebaseId:    rebaseId,
+		rebaser:     rebaser,
+		subscribers: newSubscribers(),
+		mux:         http.NewServeMux(),
+	}
+	server.mux.HandleFunc("/pairs", server.handlePairs)
+	server.mux.HandleFunc("/market", server.handleMarket)
+	server.mux.HandleFunc("/events", server.handleEvents)
+	server.mux.HandleFunc("/ws", server.handleWebSocket)
+	return &server, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// upserts the pairs, pushes the rebased changes to subscribers and returns how many rebased pairs changed
+func (s *Server) Upsert(ctx context.Context, pairs []m.Pair) (UpdateResult, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	changedPairsById := map[string]m.Pair{}
+	var err error
+	for _, pair := range pairs {
+		var changedPairs []m.Pair
+		changedPairs, err = s.rebaser.AddPair(ctx, pair)
+		for _, changedPair := range changedPairs {
+			changedPairsById[changedPair.Id()] = changedPair
+		}
+		if err != nil {
+			break
+		}
+	}
+	return s.publish(changedPairsById, nil, err)
+}
+
+// removes the pair, pushes the rebased changes to subscribers and returns how many rebased pairs changed, fails with
+// rebasing.ErrUnknownPair if the market has no such pair
+func (s *Server) Remove(ctx context.Context, pairId string) (UpdateResult, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	removedPair, changedPairs, err := s.rebaser.RemovePair(ctx, pairId)
+	// an unknown pair leaves the market as it was
+	if removedPair.Id() != pairId {
+		return UpdateResult{}, err
+	}
+	changedPairsById := map[string]m.Pair{}
+	for _, changedPair := range changedPairs {
+		changedPairsById[changedPair.Id()] = changedPair
+	}
+	return s.publish(changedPairsById, map[string]m.Pair{pairId: removedPair}, err)
+}
+
+// copy of the current rebased market
+func (s *Server) Market() *m.Market {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.rebaser.Market()
+}
+
+func (s *Server) publish(changedPairsById map[string]m.Pair, removedPairsById map[string]m.Pair, err error) (UpdateResult, error) {
+	// the changes are part of the live market by now, whatever cut the update short
+	s.subscribers.publish(changedPairsById, removedPairsById)
+	// running out of budget still leaves useful changes
+	if err != nil && !isPartial(err) {
+		return UpdateResult{}, err
+	}
+	result := UpdateResult{ChangedPairs: len(changedPairsById), RemovedPairs: len(removedPairsById)}
+	if err != nil {
+		result.Partial = true
+		result.PartialReason = err.Error()
+	}
+	return result, nil
+}
+
+func (s *Server) update(changes changes) Update {
+	update := Update{
+		RebaseAssetId: s.rebaseId,
+		PathLength:    s.rebaser.PathDepth(),
+		Market:        []m.Pair{},
+		Removed:       changes.removed,
+	}
+	update.Market = append(update.Market, changes.pairs...)
+	return update
+}
+
+func (s *Server) handlePairs(w http.ResponseWriter, r *http.Request) {
+	var result UpdateResult
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		var pairs []m.Pair
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPairsBodyBytes)).Decode(&pairs); err != nil {
+			http.Error(w, fmt.Sprintf("invalid pairs: %s", err), http.StatusBadRequest)
+			return
+		}
+		result, err = s.Upsert(s.ctx, pairs)
+	case http.MethodDelete:
+		pair := m.Pair{
+			BaseAssetId:  r.URL.Query().Get("baseId"),
+			QuoteAssetId: r.URL.Query().Get("quoteId"),
+		}
+		result, err = s.Remove(s.ctx, pair.Id())
+		if errors.Is(err, rebasing.ErrUnknownPair) {
+			http.Error(w, fmt.Sprintf(`no pair "%s" in market`, pair.Symbol()), http.StatusNotFound)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, result)
+}
+
+func (s *Server) handleMarket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, s.update(changes{pairs: sortedPairs(s.Market().PairsById)}))
+}
+
+/**
+Sends the current rebased market, restricted to the pairs the options allow, and then its changes until the context is
+done or send fails. Returns the error that ended the subscription.
+*/
+func (s *Server) Subscribe(ctx context.Context, options SubscriptionOptions, send func(Update) error) error {
+	sub, initialPairs := s.subscribe(options)
+	defer s.subscribers.remove(sub)
+
+	next := changes{pairs: initialPairs}
+	for {
+		if err := send(s.update(next)); err != nil {
+			return err
+		}
+		var ok bool
+		if next, ok = sub.next(ctx); !ok {
+			return ctx.Err()
+		}
+	}
+}
+
+// registers a subscriber and returns the current rebased market as its first update
+func (s *Server) subscribe(options SubscriptionOptions) (*subscriber, []m.Pair) {
+	// no changes can be published between taking the market and subscribing
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	sub := s.subscribers.add(options)
+	return sub, sub.sent(s.rebaser.Market().PairsById)
+}
+
+func isPartial(err error) bool {
+	return errors.Is(err, rebasing.ErrBudgetExceeded) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,146 @@
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	"github.com/jochenboesmans/go-rebase/rebasing"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func mockRatePair(baseId string, quoteId string, rate float32) m.Pair {
+	return m.Pair{
+		BaseAssetId:  baseId,
+		QuoteAssetId: quoteId,
+		ExchangeMarkets: []m.ExchangeMarket{
+			{
+				CurrentBid: rate,
+				CurrentAsk: rate,
+				BaseVolume: 1,
+			},
+		},
+	}
+}
+
+// server rebasing in "1" with the pairs 1/2 and 2/3
+func mockServer() *Server {
+	mockPairA := mockRatePair("1", "2", 2)
+	mockPairB := mockRatePair("2", "3", 3)
+	mockMarket := m.Market{
+		PairsById: map[string]m.Pair{
+			mockPairA.Id(): mockPairA,
+			mockPairB.Id(): mockPairB,
+		},
+	}
+	server, err := NewServer(context.Background(), rebasing.NewEngine(rebasing.WithMaxPathDepth(3)), "1", &mockMarket)
+	So(err, ShouldBeNil)
+	return server
+}
+
+func TestServer_Upsert(t *testing.T) {
+	Convey("rebases upserted pairs and reports how many rebased pairs changed", t, func() {
+		server := mockServer()
+
+		actual, err := server.Upsert(context.Background(), []m.Pair{mockRatePair("3", "4", 4), mockRatePair("1", "2", 5)})
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, UpdateResult{ChangedPairs: 3})
+		mockPairC := mockRatePair("3", "4", 4)
+		rebasedPair := server.Market().PairsById[mockPairC.Id()]
+		So(rebasedPair.ExchangeMarkets[0].CurrentBid, ShouldEqual, 60)
+	})
+	Convey("a removed pair is gone from the rebased market", t, func() {
+		server := mockServer()
+		mockPairB := mockRatePair("2", "3", 3)
+
+		actual, err := server.Remove(context.Background(), mockPairB.Id())
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, UpdateResult{RemovedPairs: 1})
+		So(server.Market().PairsById, ShouldHaveLength, 1)
+	})
+	Convey("removing a pair that isn't in the market fails with ErrUnknownPair", t, func() {
+		server := mockServer()
+		mockPairB := mockRatePair("2", "3", 3)
+		_, err := server.Remove(context.Background(), mockPairB.Id())
+		So(err, ShouldBeNil)
+
+		_, err = server.Remove(context.Background(), mockPairB.Id())
+
+		So(errors.Is(err, rebasing.ErrUnknownPair), ShouldBeTrue)
+		So(server.Market().PairsById, ShouldHaveLength, 1)
+	})
+}
+
+func TestServer_HTTP(t *testing.T) {
+	Convey("pairs can be posted and deleted, and the rebased market fetched", t, func() {
+		httpServer := httptest.NewServer(mockServer())
+		defer httpServer.Close()
+
+		response, err := http.Post(httpServer.URL+"/pairs", "application/json", strings.NewReader(`[{
+			"baseAssetId": "3",
+			"quoteAssetId": "4",
+			"exchangeMarkets": [{"currentBid": 4, "currentAsk": 4, "baseVolume": 1}]
+		}]`))
+		So(err, ShouldBeNil)
+		So(response.StatusCode, ShouldEqual, http.StatusOK)
+		var result UpdateResult
+		So(json.NewDecoder(response.Body).Decode(&result), ShouldBeNil)
+		response.Body.Close()
+		So(result.ChangedPairs, ShouldEqual, 1)
+
+		request, _ := http.NewRequest(http.MethodDelete, httpServer.URL+"/pairs?baseId=1&quoteId=2", nil)
+		response, err = http.DefaultClient.Do(request)
+		So(err, ShouldBeNil)
+		So(response.StatusCode, ShouldEqual, http.StatusOK)
+		response.Body.Close()
+
+		response, err = http.Get(httpServer.URL + "/market")
+		So(err, ShouldBeNil)
+		var update Update
+		So(json.NewDecoder(response.Body).Decode(&update), ShouldBeNil)
+		response.Body.Close()
+		So(update.RebaseAssetId, ShouldEqual, "1")
+		So(update.PathLength, ShouldEqual, 3)
+		So(update.Market, ShouldHaveLength, 2)
+	})
+	Convey("posted pairs are rebased and published even when the client leaves", t, func() {
+		server := mockServer()
+		sub := server.subscribers.add(SubscriptionOptions{})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		body := `[{"baseAssetId": "3", "quoteAssetId": "4", "exchangeMarkets": [{"currentBid": 4, "currentAsk": 4, "baseVolume": 1}]}]`
+		request := httptest.NewRequest(http.MethodPost, "/pairs", strings.NewReader(body)).WithContext(ctx)
+		recorder := httptest.NewRecorder()
+
+		server.ServeHTTP(recorder, request)
+
+		So(recorder.Code, ShouldEqual, http.StatusOK)
+		actual, ok := sub.next(context.Background())
+		So(ok, ShouldBeTrue)
+		So(actual.pairs, ShouldHaveLength, 1)
+		So(actual.pairs[0].Symbol(), ShouldEqual, "3/4")
+	})
+	Convey("invalid requests", t, func() {
+		httpServer := httptest.NewServer(mockServer())
+		defer httpServer.Close()
+
+		response, _ := http.Post(httpServer.URL+"/pairs", "application/json", strings.NewReader(`{`))
+		So(response.StatusCode, ShouldEqual, http.StatusBadRequest)
+		tooLarge := strings.Repeat(" ", maxPairsBodyBytes) + "[]"
+		response, _ = http.Post(httpServer.URL+"/pairs", "application/json", strings.NewReader(tooLarge))
+		So(response.StatusCode, ShouldEqual, http.StatusBadRequest)
+		request, _ := http.NewRequest(http.MethodDelete, httpServer.URL+"/pairs?baseId=1&quoteId=9", nil)
+		response, _ = http.DefaultClient.Do(request)
+		So(response.StatusCode, ShouldEqual, http.StatusNotFound)
+		response, _ = http.Get(httpServer.URL + "/pairs")
+		So(response.StatusCode, ShouldEqual, http.StatusMethodNotAllowed)
+		response, _ = http.Get(httpServer.URL + "/events?throttleMs=foo")
+		So(response.StatusCode, ShouldEqual, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,214 @@
+package streaming
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+/**
+What a subscriber wants to get. Without assets, every pair is sent. A zero throttle sends every change right away.
+*/
+type SubscriptionOptions struct {
+	// only pairs with either asset are sent
+	Assets []string
+	// minimum time between updates, changes to the same pair within it are combined
+	Throttle time.Duration
+}
+
+func subscriptionOptionsFromQuery(query url.Values) (SubscriptionOptions, error) {
+	options := SubscriptionOptions{}
+	if assets := query.Get("assets"); assets != "" {
+		for _, asset := range strings.Split(assets, ",") {
+			options.Assets = append(options.Assets, strings.TrimSpace(asset))
+		}
+	}
+	if throttleMs := query.Get("throttleMs"); throttleMs != "" {
+		ms, err := strconv.Atoi(throttleMs)
+		if err != nil || ms < 0 {
+			return SubscriptionOptions{}, fmt.Errorf(`invalid throttleMs "%s"`, throttleMs)
+		}
+		options.Throttle = time.Duration(ms) * time.Millisecond
+	}
+	return options, nil
+}
+
+type subscribers struct {
+	mutex       sync.Mutex
+	subscribers map[*subscriber]bool
+}
+
+func newSubscribers() *subscribers {
+	return &subscribers{subscribers: map[*subscriber]bool{}}
+}
+
+func (s *subscribers) add(options SubscriptionOptions) *subscriber {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	sub := &subscriber{
+		throttle: options.Throttle,
+		pending:  map[string]m.Pair{},
+		removed:  map[string]m.Pair{},
+		notify:   make(chan struct{}, 1),
+	}
+	if len(options.Assets) > 0 {
+		sub.assets = map[string]bool{}
+		for _, asset := range options.Assets {
+			sub.assets[asset] = true
+		}
+	}
+	s.subscribers[sub] = true
+	return sub
+}
+
+func (s *subscribers) remove(sub *subscriber) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.subscribers, sub)
+}
+
+func (s *subscribers) publish(changedPairsById map[string]m.Pair, removedPairsById map[string]m.Pair) {
+	if len(changedPairsById) == 0 && len(removedPairsById) == 0 {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for sub := range s.subscribers {
+		sub.queue(changedPairsById, removedPairsById)
+	}
+}
+
+// changes a subscriber takes at once
+type changes struct {
+	// sorted by symbol
+	pairs []m.Pair
+	// symbols of the removed pairs, sorted
+	removed []string
+}
+
+/**
+Pending changes for one subscriber. Publishing never waits for a subscriber: changes are queued and combined per pair
+until the subscriber's connection takes them. A pair that's removed after it changed is only sent as removed, and the
+other way around.
+*/
+type subscriber struct {
+	// nil allows every asset
+	assets   map[string]bool
+	throttle time.Duration
+	mutex    sync.Mutex
+	pending  map[string]m.Pair
+	removed  map[string]m.Pair
+	notify   chan struct{}
+	lastSent time.Time
+}
+
+func (s *subscriber) allows(pair m.Pair) bool {
+	return s.assets == nil || s.assets[pair.BaseAssetId] || s.assets[pair.QuoteAssetId]
+}
+
+func (s *subscriber) queue(changedPairsById map[string]m.Pair, removedPairsById map[string]m.Pair) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	queued := false
+	for pairId, pair := range changedPairsById {
+		if s.allows(pair) {
+			s.pending[pairId] = pair
+			delete(s.removed, pairId)
+			queued = true
+		}
+	}
+	for pairId, pair := range removedPairsById {
+		if s.allows(pair) {
+			s.removed[pairId] = pair
+			delete(s.pending, pairId)
+			queued = true
+		}
+	}
+	if queued {
+		select {
+		case s.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+/**
+Waits for pending changes, no sooner than the throttle interval after the previous ones, and takes them.
+Returns false once the context is done.
+*/
+func (s *subscriber) next(ctx context.Context) (changes, bool) {
+	for {
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return changes{}, false
+		}
+		if wait := s.throttle - time.Since(s.lastSent); wait > 0 {
+			if !sleep(ctx, wait) {
+				return changes{}, false
+			}
+		}
+
+		// changes queued while waiting may already have been taken along with earlier ones
+		if taken := s.take(); len(taken.pairs) > 0 || len(taken.removed) > 0 {
+			return taken, true
+		}
+	}
+}
+
+func (s *subscriber) take() changes {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	taken := changes{pairs: sortedPairs(s.pending)}
+	for _, pair := range sortedPairs(s.removed) {
+		taken.removed = append(taken.removed, pair.Symbol())
+	}
+	s.pending = map[string]m.Pair{}
+	s.removed = map[string]m.Pair{}
+	s.lastSent = time.Now()
+	return taken
+}
+
+// marks the initial market as sent, so throttling starts with it
+func (s *subscriber) sent(pairsById map[string]m.Pair) []m.Pair {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	pairs := []m.Pair{}
+	for _, pair := range sortedPairs(pairsById) {
+		if s.allows(pair) {
+			pairs = append(pairs, pair)
+		}
+	}
+	s.lastSent = time.Now()
+	return pairs
+}
+
+// returns false if the context is done first
+func sleep(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func sortedPairs(pairsById map[string]m.Pair) []m.Pair {
+	pairs := []m.Pair{}
+	for _, pair := range pairsById {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Symbol() < pairs[j].Symbol()
+	})
+	return pairs
+}
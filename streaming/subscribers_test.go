@@ -0,0 +1,94 @@
+package streaming
+
+import (
+	"context"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionOptionsFromQuery(t *testing.T) {
+	Convey("assets and throttle", t, func() {
+		actual, err := subscriptionOptionsFromQuery(url.Values{"assets": {"1, 2"}, "throttleMs": {"250"}})
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, SubscriptionOptions{
+			Assets:   []string{"1", "2"},
+			Throttle: 250 * time.Millisecond,
+		})
+	})
+	Convey("no options", t, func() {
+		actual, err := subscriptionOptionsFromQuery(url.Values{})
+
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, SubscriptionOptions{})
+	})
+	Convey("invalid throttle", t, func() {
+		_, err := subscriptionOptionsFromQuery(url.Values{"throttleMs": {"-1"}})
+
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestSubscriber(t *testing.T) {
+	mockPairA := mockRatePair("1", "2", 2)
+	mockPairB := mockRatePair("3", "4", 4)
+
+	Convey("only gets pairs with the subscribed assets", t, func() {
+		sub := newSubscribers().add(SubscriptionOptions{Assets: []string{"2"}})
+
+		sub.queue(map[string]m.Pair{mockPairA.Id(): mockPairA}, map[string]m.Pair{mockPairB.Id(): mockPairB})
+		actual, ok := sub.next(context.Background())
+
+		So(ok, ShouldBeTrue)
+		So(actual.pairs, ShouldResemble, []m.Pair{mockPairA})
+		So(actual.removed, ShouldBeEmpty)
+	})
+	Convey("changes to the same pair within the throttle interval are combined", t, func() {
+		sub := newSubscribers().add(SubscriptionOptions{Throttle: 50 * time.Millisecond})
+		sub.sent(map[string]m.Pair{})
+		updatedPairA := mockRatePair("1", "2", 3)
+
+		sub.queue(map[string]m.Pair{mockPairA.Id(): mockPairA}, nil)
+		sub.queue(map[string]m.Pair{mockPairA.Id(): updatedPairA, mockPairB.Id(): mockPairB}, nil)
+		start := time.Now()
+		actual, ok := sub.next(context.Background())
+
+		So(ok, ShouldBeTrue)
+		So(time.Since(start), ShouldBeGreaterThan, 25*time.Millisecond)
+		So(actual.pairs, ShouldResemble, []m.Pair{updatedPairA, mockPairB})
+	})
+	Convey("a pair removed after it changed is only sent as removed, and the other way around", t, func() {
+		sub := newSubscribers().add(SubscriptionOptions{})
+
+		sub.queue(map[string]m.Pair{mockPairA.Id(): mockPairA, mockPairB.Id(): mockPairB}, nil)
+		sub.queue(nil, map[string]m.Pair{mockPairA.Id(): mockPairA, mockPairB.Id(): mockPairB})
+		sub.queue(map[string]m.Pair{mockPairB.Id(): mockPairB}, nil)
+		actual, ok := sub.next(context.Background())
+
+		So(ok, ShouldBeTrue)
+		So(actual.pairs, ShouldResemble, []m.Pair{mockPairB})
+		So(actual.removed, ShouldResemble, []string{"1/2"})
+	})
+	Convey("stops waiting once the context is done", t, func() {
+		sub := newSubscribers().add(SubscriptionOptions{})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, ok := sub.next(ctx)
+
+		So(ok, ShouldBeFalse)
+	})
+	Convey("removed subscribers don't get changes", t, func() {
+		subs := newSubscribers()
+		sub := subs.add(SubscriptionOptions{})
+
+		subs.remove(sub)
+		subs.publish(map[string]m.Pair{mockPairA.Id(): mockPairA}, map[string]m.Pair{mockPairB.Id(): mockPairB})
+
+		So(sub.pending, ShouldBeEmpty)
+		So(sub.removed, ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,51 @@
+package streaming
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// time allowed to write an update before the subscriber is dropped
+const writeTimeout = 10 * time.Second
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// streams updates as JSON text messages over a WebSocket, messages from the subscriber are ignored
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	options, err := subscriptionOptionsFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already responded with an error
+		return
+	}
+	defer conn.Close()
+
+	// reading is needed to notice the subscriber closing the connection
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	_ = s.Subscribe(ctx, options, func(update Update) error {
+		if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+			return err
+		}
+		return conn.WriteJSON(update)
+	})
+}
@@ -0,0 +1,80 @@
+package streaming
+
+import (
+	"context"
+	"github.com/gorilla/websocket"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_WebSocket(t *testing.T) {
+	Convey("subscribers get the rebased market and then its changes", t, func() {
+		server := mockServer()
+		httpServer := httptest.NewServer(server)
+		defer httpServer.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http")+"/ws", nil)
+		So(err, ShouldBeNil)
+		defer conn.Close()
+
+		var initialUpdate Update
+		So(conn.ReadJSON(&initialUpdate), ShouldBeNil)
+		So(initialUpdate.Market, ShouldHaveLength, 2)
+
+		_, err = server.Upsert(context.Background(), []m.Pair{mockRatePair("3", "4", 4)})
+		So(err, ShouldBeNil)
+
+		var update Update
+		So(conn.ReadJSON(&update), ShouldBeNil)
+		So(update.Market, ShouldHaveLength, 1)
+		So(update.Market[0].Symbol(), ShouldEqual, "3/4")
+	})
+	Convey("subscribers get the symbols of removed pairs", t, func() {
+		server := mockServer()
+		httpServer := httptest.NewServer(server)
+		defer httpServer.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http")+"/ws", nil)
+		So(err, ShouldBeNil)
+		defer conn.Close()
+		var initialUpdate Update
+		So(conn.ReadJSON(&initialUpdate), ShouldBeNil)
+
+		mockPairB := mockRatePair("2", "3", 3)
+		_, err = server.Remove(context.Background(), mockPairB.Id())
+		So(err, ShouldBeNil)
+
+		var update Update
+		So(conn.ReadJSON(&update), ShouldBeNil)
+		So(update.Market, ShouldBeEmpty)
+		So(update.Removed, ShouldResemble, []string{"2/3"})
+	})
+	Convey("subscribers that leave are dropped", t, func() {
+		server := mockServer()
+		httpServer := httptest.NewServer(server)
+		defer httpServer.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http")+"/ws", nil)
+		So(err, ShouldBeNil)
+		var initialUpdate Update
+		So(conn.ReadJSON(&initialUpdate), ShouldBeNil)
+		So(conn.Close(), ShouldBeNil)
+
+		// the server notices on its next read
+		for i := 0; i < 100 && subscriberCount(server) > 0; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		So(subscriberCount(server), ShouldEqual, 0)
+	})
+}
+
+func subscriberCount(server *Server) int {
+	server.subscribers.mutex.Lock()
+	defer server.subscribers.mutex.Unlock()
+	return len(server.subscribers.subscribers)
+}
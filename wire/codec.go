@@ -0,0 +1,116 @@
+package wire
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/jochenboesmans/go-rebase/api"
+)
+
+/**
+Encoding of rebase inputs and outputs on the wire. Every codec encodes the same api.Input and api.Output.
+*/
+type Codec interface {
+	ContentType() string
+	MarshalInput(input api.Input) ([]byte, error)
+	UnmarshalInput(data []byte) (api.Input, error)
+	MarshalOutput(output api.Output) ([]byte, error)
+	UnmarshalOutput(data []byte) (api.Output, error)
+}
+
+var (
+	JSON        Codec = jsonCodec{}
+	Protobuf    Codec = protobufCodec{}
+	MessagePack Codec = messagePackCodec{}
+)
+
+var codecs = []Codec{JSON, Protobuf, MessagePack}
+
+// other content types some clients use for the same encodings
+var contentTypeAliases = map[string]Codec{
+	"application/protobuf": Protobuf,
+	"application/msgpack":  MessagePack,
+}
+
+/**
+Codec of a Content-Type header. A missing content type is taken to be JSON.
+*/
+func ForContentType(contentType string) (Codec, error) {
+	if contentType == "" {
+		return JSON, nil
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf(`invalid content type "%s": %w`, contentType, err)
+	}
+	if mediaType == "text/csv" {
+		return csvCodecOf(params)
+	}
+	if codec := forMediaType(mediaType); codec != nil {
+		return codec, nil
+	}
+	return nil, fmt.Errorf(`unsupported content type "%s"`, contentType)
+}
+
+/**
+Codec of the first supported media type in an Accept header, in the order the client listed them.
+Returns the fallback when the header is missing, accepts anything or lists nothing supported.
+*/
+func ForAccept(accept string, fallback Codec) Codec {
+	for _, accepted := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		if mediaType == "*/*" || mediaType == "application/*" {
+			return fallback
+		}
+		if mediaType == "text/csv" {
+			if codec, err := csvCodecOf(params); err == nil {
+				return codec
+			}
+			continue
+		}
+		if codec := forMediaType(mediaType); codec != nil {
+			return codec
+		}
+	}
+	return fallback
+}
+
+func forMediaType(mediaType string) Codec {
+	for _, codec := range codecs {
+		if codec.ContentType() == mediaType {
+			return codec
+		}
+	}
+	return contentTypeAliases[mediaType]
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) MarshalInput(input api.Input) ([]byte, error) {
+	return json.Marshal(input)
+}
+
+func (jsonCodec) UnmarshalInput(data []byte) (api.Input, error) {
+	var input api.Input
+	err := json.Unmarshal(data, &input)
+	return input, err
+}
+
+func (jsonCodec) MarshalOutput(output api.Output) ([]byte, error) {
+	return json.Marshal(output)
+}
+
+func (jsonCodec) UnmarshalOutput(data []byte) (api.Output, error) {
+	var output api.Output
+	err := json.Unmarshal(data, &output)
+	return output, err
+}
@@ -0,0 +1,190 @@
+package wire
+
+import (
+	"context"
+	"github.com/jochenboesmans/go-rebase/api"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	"github.com/jochenboesmans/go-rebase/rpc/rebasepb"
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/protobuf/proto"
+	"strings"
+	"testing"
+)
+
+const mockInputJSON = `{
+	"rebaseAssetId": "USD",
+	"maxPathLength": 3,
+	"autoPathLength": {"tolerance": 0.01, "maxPathLength": 4, "timeBudgetMs": 100},
+	"pathWeighting": "bottleneck",
+	"minCombinedVolume": 1.5,
+	"minExchanges": 1,
+	"maxRelativeSpread": 0.1,
+	"filter": {"excludeAssets": ["USDT"], "includeExchanges": ["a", "b"]},
+	"budget": {"maxPathsPerPair": 10, "maxPaths": 100, "timeBudgetMs": 1000},
+	"assets": {"aliases": {"EUR": ["XEUR", "eur"]}, "equivalent": [["GBP", "XGBP"]]},
+	"referenceRates": {"rates": [{"baseAssetId": "USD", "quoteAssetId": "JPY", "rate": 0.0067}], "fallback": true, "volume": 5},
+	"pegs": [{"assetId": "USDT", "peggedTo": "USD", "ratio": 1, "tolerance": 0.01, "fallback": true, "volume": 10}],
+	"market": [
+		{
+			"baseAssetId": "USD",
+			"quoteAssetId": "EUR",
+			"exchangeMarkets": [
+				{"exchangeId": "a", "currentBid": 1.12, "currentAsk": 1.13, "baseVolume": 100, "quoteVolume": 89},
+				{"exchangeId": "b", "currentBid": 1.11, "currentAsk": 1.14, "baseVolume": 50}
+			]
+		},
+		{
+			"baseAssetId": "EUR",
+			"quoteAssetId": "GBP",
+			"exchangeMarkets": [
+				{"exchangeId": "a", "currentBid": 1.15, "currentAsk": 1.16, "baseVolume": 100}
+			]
+		}
+	]
+}`
+
+// mock input with holdings, of which BTC can't be priced
+var mockPortfolioJSON = strings.Replace(mockInputJSON, "{", `{
+	"holdings": {"EUR": 2, "BTC": 1},`, 1)
+
+func mockInput() api.Input {
+	input, err := JSON.UnmarshalInput([]byte(mockInputJSON))
+	So(err, ShouldBeNil)
+	return input
+}
+
+// mock input that also sets the fields that change what's rebased and how, and those they add to the output
+func mockFullInput() api.Input {
+	input := mockInput()
+	input.Engine = "leastSquares"
+	input.RebaseAssetId = "BASKET"
+	input.Basket = &api.BasketInput{
+		Assets:    []api.BasketAssetInput{{AssetId: "USD", Weight: 0.6}, {AssetId: "EUR", Weight: 0.4}},
+		Geometric: true,
+	}
+	return input
+}
+
+func TestCodecs(t *testing.T) {
+	for _, codec := range []Codec{JSON, Protobuf, MessagePack} {
+		codec := codec
+		Convey(codec.ContentType()+" inputs round-trip to the same input as JSON", t, func() {
+			input := mockFullInput()
+
+			data, err := codec.MarshalInput(input)
+			So(err, ShouldBeNil)
+			actual, err := codec.UnmarshalInput(data)
+
+			So(err, ShouldBeNil)
+			So(actual, ShouldResemble, input)
+		})
+		Convey(codec.ContentType()+" outputs round-trip to the same output as JSON", t, func() {
+			input := mockFullInput()
+			input.Filter.IncludeExchanges = nil
+			output, err := api.Rebase(context.Background(), input)
+			So(err, ShouldBeNil)
+			jsonData, err := JSON.MarshalOutput(output)
+			So(err, ShouldBeNil)
+			expected, err := JSON.UnmarshalOutput(jsonData)
+			So(err, ShouldBeNil)
+
+			data, err := codec.MarshalOutput(expected)
+			So(err, ShouldBeNil)
+			actual, err := codec.UnmarshalOutput(data)
+
+			So(err, ShouldBeNil)
+			So(actual, ShouldResemble, expected)
+		})
+	}
+	Convey("protobuf inputs with path lengths over 255 don't decode", t, func() {
+		for _, request := range []*rebasepb.RebaseRequest{
+			{MaxPathLength: 256},
+			{AutoPathLength: &rebasepb.AutoPathLength{MaxPathLength: 300}},
+		} {
+			data, _ := proto.Marshal(request)
+
+			_, err := Protobuf.UnmarshalInput(data)
+
+			So(err, ShouldNotBeNil)
+		}
+	})
+	Convey("binary encodings are smaller than JSON", t, func() {
+		input := mockInput()
+		jsonData, _ := JSON.MarshalInput(input)
+		protobufData, _ := Protobuf.MarshalInput(input)
+		messagePackData, _ := MessagePack.MarshalInput(input)
+
+		So(len(protobufData), ShouldBeLessThan, len(jsonData))
+		So(len(messagePackData), ShouldBeLessThan, len(jsonData))
+	})
+}
+
+func TestForContentType(t *testing.T) {
+	Convey("supported content types, with parameters and aliases", t, func() {
+		for contentType, expected := range map[string]Codec{
+			"":                                JSON,
+			"application/json":                JSON,
+			"application/json; charset=utf-8": JSON,
+			"application/x-protobuf":          Protobuf,
+			"application/protobuf":            Protobuf,
+			"application/x-msgpack":           MessagePack,
+			"application/msgpack":             MessagePack,
+			"text/csv":                        CSV,
+		} {
+			actual, err := ForContentType(contentType)
+
+			So(err, ShouldBeNil)
+			So(actual.ContentType(), ShouldEqual, expected.ContentType())
+		}
+	})
+	Convey("unsupported content types", t, func() {
+		_, err := ForContentType("text/html")
+		So(err, ShouldNotBeNil)
+
+		_, err = ForContentType("text/csv; delimiter=ab")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestCSVCodec(t *testing.T) {
+	Convey("takes its layout from the content type parameters", t, func() {
+		codec, err := ForContentType("text/csv; header=absent; delimiter=tab")
+		So(err, ShouldBeNil)
+
+		data, err := codec.MarshalInput(mockInput())
+
+		So(err, ShouldBeNil)
+		So(string(data), ShouldStartWith, "EUR\tGBP\ta\t1.15\t1.16\t100\t0\n")
+		So(codec.ContentType(), ShouldEqual, "text/csv; delimiter=tab; header=absent")
+	})
+	Convey("round-trips the market of inputs and outputs", t, func() {
+		input := mockInput()
+		codec, _ := ForContentType(`text/csv; delimiter=";"`)
+
+		data, err := codec.MarshalInput(input)
+		So(err, ShouldBeNil)
+		actual, err := codec.UnmarshalInput(data)
+
+		So(err, ShouldBeNil)
+		So(actual.RebaseAssetId, ShouldBeEmpty)
+		So(actual.Market, ShouldResemble, []m.Pair{input.Market[1], input.Market[0]})
+	})
+}
+
+func TestForAccept(t *testing.T) {
+	Convey("first supported media type in the order listed", t, func() {
+		actual := ForAccept("text/html, application/x-msgpack, application/json", JSON)
+
+		So(actual.ContentType(), ShouldEqual, MessagePack.ContentType())
+	})
+	Convey("CSV with its layout parameters", t, func() {
+		actual := ForAccept("text/csv; header=absent", JSON)
+
+		So(actual.ContentType(), ShouldEqual, "text/csv; header=absent")
+	})
+	Convey("falls back when anything or nothing supported is accepted", t, func() {
+		for _, accept := range []string{"", "*/*", "text/html"} {
+			So(ForAccept(accept, Protobuf).ContentType(), ShouldEqual, Protobuf.ContentType())
+		}
+	})
+}
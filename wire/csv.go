@@ -0,0 +1,105 @@
+package wire
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/jochenboesmans/go-rebase/api"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+)
+
+/**
+Encodes the market of inputs and outputs as flat CSV, one exchange market per row. Settings don't fit in the file, so
+the HTTP entry points take them from query parameters instead. The header and delimiter parameters of the content
+type configure the layout, as in "text/csv; header=absent; delimiter=tab".
+*/
+type csvCodec struct {
+	format m.CSVFormat
+}
+
+var CSV Codec = csvCodec{}
+
+func csvCodecOf(params map[string]string) (Codec, error) {
+	var format m.CSVFormat
+	switch params["header"] {
+	case "", "present":
+	case "absent":
+		format.NoHeader = true
+	default:
+		return nil, fmt.Errorf(`invalid CSV header parameter "%s"`, params["header"])
+	}
+	switch delimiter := params["delimiter"]; delimiter {
+	case "":
+	case "tab":
+		format.Delimiter = '\t'
+	default:
+		if utf8.RuneCountInString(delimiter) != 1 {
+			return nil, fmt.Errorf(`invalid CSV delimiter "%s"`, delimiter)
+		}
+		format.Delimiter, _ = utf8.DecodeRuneInString(delimiter)
+	}
+	return csvCodec{format: format}, nil
+}
+
+func (c csvCodec) ContentType() string {
+	params := map[string]string{}
+	if c.format.NoHeader {
+		params["header"] = "absent"
+	}
+	switch c.format.Delimiter {
+	case 0, ',':
+	case '\t':
+		params["delimiter"] = "tab"
+	default:
+		params["delimiter"] = string(c.format.Delimiter)
+	}
+	return mime.FormatMediaType("text/csv", params)
+}
+
+func (c csvCodec) MarshalInput(input api.Input) ([]byte, error) {
+	return c.marshalPairs(input.Market)
+}
+
+func (c csvCodec) UnmarshalInput(data []byte) (api.Input, error) {
+	pairs, err := c.unmarshalPairs(data)
+	return api.Input{Market: pairs}, err
+}
+
+func (c csvCodec) MarshalOutput(output api.Output) ([]byte, error) {
+	return c.marshalPairs(output.Market)
+}
+
+func (c csvCodec) UnmarshalOutput(data []byte) (api.Output, error) {
+	pairs, err := c.unmarshalPairs(data)
+	return api.Output{Market: pairs}, err
+}
+
+func (c csvCodec) marshalPairs(pairs []m.Pair) ([]byte, error) {
+	market := m.Market{PairsById: map[string]m.Pair{}}
+	for _, pair := range pairs {
+		market.PairsById[pair.Id()] = pair
+	}
+	var buffer bytes.Buffer
+	if err := m.WriteCSV(&buffer, &market, c.format); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (c csvCodec) unmarshalPairs(data []byte) ([]m.Pair, error) {
+	market, err := m.ReadCSV(bytes.NewReader(data), c.format)
+	if err != nil {
+		return nil, err
+	}
+	pairs := []m.Pair{}
+	for _, pair := range market.PairsById {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Symbol() < pairs[j].Symbol()
+	})
+	return pairs, nil
+}
@@ -0,0 +1,160 @@
+package wire
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jochenboesmans/go-rebase/api"
+)
+
+// largest request body the handlers read, in bytes
+const maxBodyBytes = 10 << 20
+
+// response to an encoded rebase request, errors are plain text
+type response struct {
+	status      int
+	contentType string
+	body        []byte
+}
+
+/**
+HTTP handler for rebase requests. The request body is decoded according to its Content-Type, and the response is
+encoded in the first supported type of the Accept header, or else in the request's own encoding.
+The rebaseAssetId, maxPathLength and pathWeighting query parameters override the settings of the body, which is how
+CSV requests, whose body is just the market, are configured. Bodies over 10 MiB are rejected.
+*/
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		response := rebase(r.Context(), r.Header.Get("Content-Type"), r.Header.Get("Accept"), r.URL.Query(), body)
+		w.Header().Set("Content-Type", response.contentType)
+		w.WriteHeader(response.status)
+		_, _ = w.Write(response.body)
+	})
+}
+
+/**
+HTTP handler for portfolio requests, which value holdings at the prices of a rebased market, see api.Portfolio.
+Requests and responses are JSON only. The same query parameters as those of Handler override the settings of the body.
+Bodies over 10 MiB are rejected.
+*/
+func PortfolioHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		response := portfolio(r.Context(), r.Header.Get("Content-Type"), r.URL.Query(), body)
+		w.Header().Set("Content-Type", response.contentType)
+		w.WriteHeader(response.status)
+		_, _ = w.Write(response.body)
+	})
+}
+
+// decodes, rebases and encodes a request
+func rebase(ctx context.Context, contentType string, accept string, query url.Values, body []byte) response {
+	requestCodec, err := ForContentType(contentType)
+	if err != nil {
+		return errorResponse(http.StatusUnsupportedMediaType, err)
+	}
+	input, err := requestCodec.UnmarshalInput(body)
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, err)
+	}
+	if input, err = withQuery(input, query); err != nil {
+		return errorResponse(http.StatusBadRequest, err)
+	}
+	output, err := api.Rebase(ctx, input)
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, err)
+	}
+	responseCodec := ForAccept(accept, requestCodec)
+	responseBody, err := responseCodec.MarshalOutput(output)
+	if err != nil {
+		return errorResponse(http.StatusInternalServerError, err)
+	}
+	return response{
+		status:      http.StatusOK,
+		contentType: responseCodec.ContentType(),
+		body:        responseBody,
+	}
+}
+
+// decodes, values and encodes a portfolio request
+func portfolio(ctx context.Context, contentType string, query url.Values, body []byte) response {
+	if codec, err := ForContentType(contentType); err != nil || codec != JSON {
+		return errorResponse(http.StatusUnsupportedMediaType, fmt.Errorf(`unsupported content type "%s", portfolio requests are JSON`, contentType))
+	}
+	var input api.PortfolioInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return errorResponse(http.StatusBadRequest, err)
+	}
+	var err error
+	if input.Input, err = withQuery(input.Input, query); err != nil {
+		return errorResponse(http.StatusBadRequest, err)
+	}
+	output, err := api.Portfolio(ctx, input)
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, err)
+	}
+	responseBody, err := json.Marshal(output)
+	if err != nil {
+		return errorResponse(http.StatusInternalServerError, err)
+	}
+	return response{
+		status:      http.StatusOK,
+		contentType: JSON.ContentType(),
+		body:        responseBody,
+	}
+}
+
+// settings given as query parameters take precedence over those in the body
+func withQuery(input api.Input, query url.Values) (api.Input, error) {
+	if rebaseAssetId := query.Get("rebaseAssetId"); rebaseAssetId != "" {
+		input.RebaseAssetId = rebaseAssetId
+	}
+	if maxPathLength := query.Get("maxPathLength"); maxPathLength != "" {
+		value, err := strconv.ParseUint(maxPathLength, 10, 8)
+		if err != nil {
+			return input, fmt.Errorf(`invalid maxPathLength "%s"`, maxPathLength)
+		}
+		input.MaxPathLength = uint8(value)
+	}
+	if pathWeighting := query.Get("pathWeighting"); pathWeighting != "" {
+		input.PathWeighting = pathWeighting
+	}
+	if engine := query.Get("engine"); engine != "" {
+		input.Engine = engine
+	}
+	return input, nil
+}
+
+func errorResponse(status int, err error) response {
+	return response{
+		status:      status,
+		contentType: "text/plain; charset=utf-8",
+		body:        []byte(err.Error() + "\n"),
+	}
+}
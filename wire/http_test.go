@@ -0,0 +1,111 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/jochenboesmans/go-rebase/api"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	postTo := func(target string, contentType string, accept string, body []byte) *http.Response {
+		request := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		request.Header.Set("Accept", accept)
+		recorder := httptest.NewRecorder()
+		Handler().ServeHTTP(recorder, request)
+		return recorder.Result()
+	}
+	post := func(contentType string, accept string, body []byte) *http.Response {
+		return postTo("/rebase", contentType, accept, body)
+	}
+
+	Convey("responds in the request's encoding by default", t, func() {
+		body, _ := Protobuf.MarshalInput(mockInput())
+
+		response := post(Protobuf.ContentType(), "", body)
+
+		So(response.StatusCode, ShouldEqual, http.StatusOK)
+		So(response.Header.Get("Content-Type"), ShouldEqual, Protobuf.ContentType())
+		data, _ := ioutil.ReadAll(response.Body)
+		output, err := Protobuf.UnmarshalOutput(data)
+		So(err, ShouldBeNil)
+		So(output.RebaseAssetId, ShouldEqual, "USD")
+	})
+	Convey("responds in the accepted encoding", t, func() {
+		response := post("application/json", MessagePack.ContentType(), []byte(mockInputJSON))
+
+		So(response.StatusCode, ShouldEqual, http.StatusOK)
+		So(response.Header.Get("Content-Type"), ShouldEqual, MessagePack.ContentType())
+		data, _ := ioutil.ReadAll(response.Body)
+		output, err := MessagePack.UnmarshalOutput(data)
+		So(err, ShouldBeNil)
+		So(output.Market, ShouldHaveLength, 2)
+	})
+	Convey("takes the settings of CSV requests from query parameters", t, func() {
+		body := "base;quote;exchange;bid;ask;baseVolume\nUSD;EUR;a;1.12;1.13;100\nEUR;GBP;a;1.15;1.16;100\n"
+
+		response := postTo("/rebase?rebaseAssetId=USD&maxPathLength=2", `text/csv; delimiter=";"`, "", []byte(body))
+
+		So(response.StatusCode, ShouldEqual, http.StatusOK)
+		So(response.Header.Get("Content-Type"), ShouldEqual, `text/csv; delimiter=";"`)
+		data, _ := ioutil.ReadAll(response.Body)
+		So(string(data), ShouldStartWith, "base;quote;exchange;bid;ask;baseVolume\n")
+		So(strings.Count(string(data), "\n"), ShouldEqual, 3)
+	})
+	Convey("invalid requests", t, func() {
+		So(postTo("/rebase?maxPathLength=x", "application/json", "", []byte(mockInputJSON)).StatusCode, ShouldEqual, http.StatusBadRequest)
+		So(postTo("/rebase?engine=foo", "application/json", "", []byte(mockInputJSON)).StatusCode, ShouldEqual, http.StatusBadRequest)
+		So(post("text/html", "", []byte(mockInputJSON)).StatusCode, ShouldEqual, http.StatusUnsupportedMediaType)
+		So(post("application/json", "", []byte("{")).StatusCode, ShouldEqual, http.StatusBadRequest)
+		response := post("application/json", "", []byte(strings.Replace(mockInputJSON, "bottleneck", "foo", 1)))
+		So(response.StatusCode, ShouldEqual, http.StatusBadRequest)
+		So(response.Header.Get("Content-Type"), ShouldStartWith, "text/plain")
+		tooLarge := mockInputJSON + strings.Repeat(" ", maxBodyBytes)
+		So(post("application/json", "", []byte(tooLarge)).StatusCode, ShouldEqual, http.StatusBadRequest)
+	})
+}
+
+func TestPortfolioHandler(t *testing.T) {
+	post := func(target string, contentType string, body []byte) *http.Response {
+		request := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		recorder := httptest.NewRecorder()
+		PortfolioHandler().ServeHTTP(recorder, request)
+		return recorder.Result()
+	}
+
+	Convey("values holdings as JSON", t, func() {
+		response := post("/portfolio", "application/json", []byte(mockPortfolioJSON))
+
+		So(response.StatusCode, ShouldEqual, http.StatusOK)
+		So(response.Header.Get("Content-Type"), ShouldEqual, JSON.ContentType())
+		var output api.PortfolioOutput
+		So(json.NewDecoder(response.Body).Decode(&output), ShouldBeNil)
+		So(output.RebaseAssetId, ShouldEqual, "USD")
+		So(output.Positions, ShouldHaveLength, 2)
+		So(output.Unpriced, ShouldResemble, []string{"BTC"})
+		So(output.Total, ShouldBeGreaterThan, 0)
+	})
+	Convey("takes settings from query parameters", t, func() {
+		response := post("/portfolio?rebaseAssetId=EUR", "application/json", []byte(mockPortfolioJSON))
+
+		So(response.StatusCode, ShouldEqual, http.StatusOK)
+		var output api.PortfolioOutput
+		So(json.NewDecoder(response.Body).Decode(&output), ShouldBeNil)
+		So(output.RebaseAssetId, ShouldEqual, "EUR")
+	})
+	Convey("invalid requests", t, func() {
+		body, _ := Protobuf.MarshalInput(mockInput())
+		So(post("/portfolio", Protobuf.ContentType(), body).StatusCode, ShouldEqual, http.StatusUnsupportedMediaType)
+		So(post("/portfolio", "application/json", []byte("{")).StatusCode, ShouldEqual, http.StatusBadRequest)
+		So(post("/portfolio?maxPathLength=x", "application/json", []byte(mockPortfolioJSON)).StatusCode, ShouldEqual, http.StatusBadRequest)
+		tooLarge := mockPortfolioJSON + strings.Repeat(" ", maxBodyBytes)
+		So(post("/portfolio", "application/json", []byte(tooLarge)).StatusCode, ShouldEqual, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,87 @@
+package wire
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jochenboesmans/go-rebase/api"
+)
+
+/**
+Lambda handler that takes rebase inputs either directly as JSON, like a handler started with lambda.Start(api.Rebase),
+or wrapped in API Gateway proxy requests. Proxy requests are decoded and their responses encoded according to their
+Content-Type and Accept headers. Binary bodies are base64 encoded both ways.
+Portfolio inputs, JSON with holdings or proxy requests to a path ending in /portfolio, are valued with api.Portfolio.
+*/
+type LambdaHandler struct{}
+
+func (LambdaHandler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	var request events.APIGatewayProxyRequest
+	if err := json.Unmarshal(payload, &request); err == nil && request.HTTPMethod != "" {
+		return json.Marshal(proxyResponse(ctx, request))
+	}
+
+	var input api.PortfolioInput
+	if err := json.Unmarshal(payload, &input); err != nil {
+		return nil, err
+	}
+	if input.Holdings != nil {
+		output, err := api.Portfolio(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(output)
+	}
+	output, err := api.Rebase(ctx, input.Input)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(output)
+}
+
+func proxyResponse(ctx context.Context, request events.APIGatewayProxyRequest) events.APIGatewayProxyResponse {
+	body := []byte(request.Body)
+	if request.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(request.Body); err != nil {
+			return toProxyResponse(errorResponse(http.StatusBadRequest, err))
+		}
+	}
+	query := url.Values{}
+	for key, value := range request.QueryStringParameters {
+		query.Set(key, value)
+	}
+	if strings.HasSuffix(strings.TrimSuffix(request.Path, "/"), "/portfolio") {
+		return toProxyResponse(portfolio(ctx, header(request.Headers, "Content-Type"), query, body))
+	}
+	return toProxyResponse(rebase(ctx, header(request.Headers, "Content-Type"), header(request.Headers, "Accept"), query, body))
+}
+
+func toProxyResponse(response response) events.APIGatewayProxyResponse {
+	proxyResponse := events.APIGatewayProxyResponse{
+		StatusCode: response.status,
+		Headers:    map[string]string{"Content-Type": response.contentType},
+	}
+	if response.contentType == JSON.ContentType() || strings.HasPrefix(response.contentType, "text/") {
+		proxyResponse.Body = string(response.body)
+	} else {
+		proxyResponse.Body = base64.StdEncoding.EncodeToString(response.body)
+		proxyResponse.IsBase64Encoded = true
+	}
+	return proxyResponse
+}
+
+// API Gateway passes headers on in the client's casing
+func header(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package wire
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jochenboesmans/go-rebase/api"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestLambdaHandler_Invoke(t *testing.T) {
+	Convey("takes inputs directly as JSON", t, func() {
+		payload, err := LambdaHandler{}.Invoke(context.Background(), []byte(mockInputJSON))
+
+		So(err, ShouldBeNil)
+		var output api.Output
+		So(json.Unmarshal(payload, &output), ShouldBeNil)
+		So(output.RebaseAssetId, ShouldEqual, "USD")
+	})
+	Convey("negotiates the encoding of API Gateway proxy requests", t, func() {
+		body, _ := MessagePack.MarshalInput(mockInput())
+		request, _ := json.Marshal(events.APIGatewayProxyRequest{
+			HTTPMethod:      "POST",
+			Headers:         map[string]string{"content-type": MessagePack.ContentType(), "accept": Protobuf.ContentType()},
+			Body:            base64.StdEncoding.EncodeToString(body),
+			IsBase64Encoded: true,
+		})
+
+		payload, err := LambdaHandler{}.Invoke(context.Background(), request)
+
+		So(err, ShouldBeNil)
+		var response events.APIGatewayProxyResponse
+		So(json.Unmarshal(payload, &response), ShouldBeNil)
+		So(response.StatusCode, ShouldEqual, 200)
+		So(response.Headers["Content-Type"], ShouldEqual, Protobuf.ContentType())
+		So(response.IsBase64Encoded, ShouldBeTrue)
+		data, err := base64.StdEncoding.DecodeString(response.Body)
+		So(err, ShouldBeNil)
+		output, err := Protobuf.UnmarshalOutput(data)
+		So(err, ShouldBeNil)
+		So(output.Market, ShouldHaveLength, 2)
+	})
+	Convey("JSON proxy responses aren't base64 encoded", t, func() {
+		request, _ := json.Marshal(events.APIGatewayProxyRequest{
+			HTTPMethod: "POST",
+			Body:       mockInputJSON,
+		})
+
+		payload, err := LambdaHandler{}.Invoke(context.Background(), request)
+
+		So(err, ShouldBeNil)
+		var response events.APIGatewayProxyResponse
+		So(json.Unmarshal(payload, &response), ShouldBeNil)
+		So(response.IsBase64Encoded, ShouldBeFalse)
+		var output api.Output
+		So(json.Unmarshal([]byte(response.Body), &output), ShouldBeNil)
+		So(output.RebaseAssetId, ShouldEqual, "USD")
+	})
+	Convey("values holdings of direct JSON inputs", t, func() {
+		payload, err := LambdaHandler{}.Invoke(context.Background(), []byte(mockPortfolioJSON))
+
+		So(err, ShouldBeNil)
+		var output api.PortfolioOutput
+		So(json.Unmarshal(payload, &output), ShouldBeNil)
+		So(output.Positions, ShouldHaveLength, 2)
+		So(output.Unpriced, ShouldResemble, []string{"BTC"})
+	})
+	Convey("values holdings of proxy requests to /portfolio", t, func() {
+		request, _ := json.Marshal(events.APIGatewayProxyRequest{
+			HTTPMethod: "POST",
+			Path:       "/prod/portfolio",
+			Body:       mockPortfolioJSON,
+		})
+
+		payload, err := LambdaHandler{}.Invoke(context.Background(), request)
+
+		So(err, ShouldBeNil)
+		var response events.APIGatewayProxyResponse
+		So(json.Unmarshal(payload, &response), ShouldBeNil)
+		So(response.StatusCode, ShouldEqual, 200)
+		var output api.PortfolioOutput
+		So(json.Unmarshal([]byte(response.Body), &output), ShouldBeNil)
+		So(output.Positions, ShouldHaveLength, 2)
+	})
+}
@@ -0,0 +1,53 @@
+package wire
+
+import (
+	"bytes"
+
+	"github.com/jochenboesmans/go-rebase/api"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+/**
+Encodes inputs and outputs as MessagePack maps with the same keys as their JSON.
+*/
+type messagePackCodec struct{}
+
+func (messagePackCodec) ContentType() string {
+	return "application/x-msgpack"
+}
+
+func (messagePackCodec) MarshalInput(input api.Input) ([]byte, error) {
+	return marshalMessagePack(input)
+}
+
+func (messagePackCodec) UnmarshalInput(data []byte) (api.Input, error) {
+	var input api.Input
+	err := unmarshalMessagePack(data, &input)
+	return input, err
+}
+
+func (messagePackCodec) MarshalOutput(output api.Output) ([]byte, error) {
+	return marshalMessagePack(output)
+}
+
+func (messagePackCodec) UnmarshalOutput(data []byte) (api.Output, error) {
+	var output api.Output
+	err := unmarshalMessagePack(data, &output)
+	return output, err
+}
+
+func marshalMessagePack(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := msgpack.NewEncoder(&buffer)
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func unmarshalMessagePack(data []byte, value interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(value)
+}
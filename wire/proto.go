@@ -0,0 +1,347 @@
+package wire
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jochenboesmans/go-rebase/api"
+	m "github.com/jochenboesmans/go-rebase/model/market"
+	"github.com/jochenboesmans/go-rebase/rpc/rebasepb"
+	"github.com/jochenboesmans/go-rebase/streaming"
+	"google.golang.org/protobuf/proto"
+)
+
+// conversions between protobuf messages and the types of the api and model packages
+
+/**
+Input of a request, which fails when path lengths don't fit the input's uint8 fields.
+*/
+func InputFromProto(request *rebasepb.RebaseRequest) (api.Input, error) {
+	maxPathLength, err := pathLengthFromProto("max_path_length", request.GetMaxPathLength())
+	if err != nil {
+		return api.Input{}, err
+	}
+	input := api.Input{
+		RebaseAssetId:     request.GetRebaseAssetId(),
+		MaxPathLength:     maxPathLength,
+		PathWeighting:     request.GetPathWeighting(),
+		Engine:            request.GetEngine(),
+		MinCombinedVolume: request.GetMinCombinedVolume(),
+		MinExchanges:      int(request.GetMinExchanges()),
+		MaxRelativeSpread: request.GetMaxRelativeSpread(),
+		Budget: api.BudgetInput{
+			MaxPathsPerPair: int(request.GetBudget().GetMaxPathsPerPair()),
+			MaxPaths:        int(request.GetBudget().GetMaxPaths()),
+			TimeBudgetMs:    int(request.GetBudget().GetTimeBudgetMs()),
+		},
+		Market: PairsFromProto(request.GetMarket()),
+	}
+	if referenceRates := request.GetReferenceRates(); referenceRates != nil {
+		input.ReferenceRates = &api.ReferenceRatesInput{
+			Fallback: referenceRates.GetFallback(),
+			Volume:   referenceRates.GetVolume(),
+		}
+		for _, rate := range referenceRates.GetRates() {
+			input.ReferenceRates.Rates = append(input.ReferenceRates.Rates, api.ReferenceRateInput{
+				BaseAssetId:  rate.GetBaseAssetId(),
+				QuoteAssetId: rate.GetQuoteAssetId(),
+				Rate:         rate.GetRate(),
+			})
+		}
+	}
+	if basket := request.GetBasket(); basket != nil {
+		input.Basket = &api.BasketInput{Geometric: basket.GetGeometric()}
+		for _, asset := range basket.GetAssets() {
+			input.Basket.Assets = append(input.Basket.Assets, api.BasketAssetInput{
+				AssetId: asset.GetAssetId(),
+				Weight:  asset.GetWeight(),
+			})
+		}
+	}
+	for _, peg := range request.GetPegs() {
+		input.Pegs = append(input.Pegs, api.PegInput{
+			AssetId:   peg.GetAssetId(),
+			PeggedTo:  peg.GetPeggedTo(),
+			Ratio:     peg.GetRatio(),
+			Tolerance: peg.GetTolerance(),
+			Fallback:  peg.GetFallback(),
+			Volume:    peg.GetVolume(),
+		})
+	}
+	if autoPathLength := request.GetAutoPathLength(); autoPathLength != nil {
+		autoMaxPathLength, err := pathLengthFromProto("auto_path_length.max_path_length", autoPathLength.GetMaxPathLength())
+		if err != nil {
+			return api.Input{}, err
+		}
+		input.AutoPathLength = &api.AutoPathLengthInput{
+			Tolerance:     autoPathLength.GetTolerance(),
+			MaxPathLength: autoMaxPathLength,
+			TimeBudgetMs:  int(autoPathLength.GetTimeBudgetMs()),
+		}
+	}
+	if assets := request.GetAssets(); assets != nil {
+		input.Assets = &api.AssetsInput{}
+		for canonicalId, aliases := range assets.GetAliases() {
+			if input.Assets.Aliases == nil {
+				input.Assets.Aliases = map[string][]string{}
+			}
+			input.Assets.Aliases[canonicalId] = aliases.GetAssetIds()
+		}
+		for _, assetIds := range assets.GetEquivalent() {
+			input.Assets.Equivalent = append(input.Assets.Equivalent, assetIds.GetAssetIds())
+		}
+	}
+	if filter := request.GetFilter(); filter != nil {
+		input.Filter = m.Filter{
+			IncludeAssets:    filter.GetIncludeAssets(),
+			ExcludeAssets:    filter.GetExcludeAssets(),
+			IncludePairs:     filter.GetIncludePairs(),
+			ExcludePairs:     filter.GetExcludePairs(),
+			IncludeExchanges: filter.GetIncludeExchanges(),
+			ExcludeExchanges: filter.GetExcludeExchanges(),
+		}
+	}
+	return input, nil
+}
+
+// path lengths are uint32 on the wire, but at most 255 pairs long
+func pathLengthFromProto(field string, length uint32) (uint8, error) {
+	if length > math.MaxUint8 {
+		return 0, fmt.Errorf("%s %d is over %d", field, length, math.MaxUint8)
+	}
+	return uint8(length), nil
+}
+
+func InputToProto(input api.Input) *rebasepb.RebaseRequest {
+	request := rebasepb.RebaseRequest{
+		RebaseAssetId:     input.RebaseAssetId,
+		MaxPathLength:     uint32(input.MaxPathLength),
+		PathWeighting:     input.PathWeighting,
+		Engine:            input.Engine,
+		MinCombinedVolume: input.MinCombinedVolume,
+		MinExchanges:      uint32(input.MinExchanges),
+		MaxRelativeSpread: input.MaxRelativeSpread,
+		Filter: &rebasepb.Filter{
+			IncludeAssets:    input.Filter.IncludeAssets,
+			ExcludeAssets:    input.Filter.ExcludeAssets,
+			IncludePairs:     input.Filter.IncludePairs,
+			ExcludePairs:     input.Filter.ExcludePairs,
+			IncludeExchanges: input.Filter.IncludeExchanges,
+			ExcludeExchanges: input.Filter.ExcludeExchanges,
+		},
+		Budget: &rebasepb.Budget{
+			MaxPathsPerPair: uint32(input.Budget.MaxPathsPerPair),
+			MaxPaths:        uint32(input.Budget.MaxPaths),
+			TimeBudgetMs:    uint32(input.Budget.TimeBudgetMs),
+		},
+		Market: PairsToProto(input.Market),
+	}
+	if input.ReferenceRates != nil {
+		request.ReferenceRates = &rebasepb.ReferenceRates{
+			Fallback: input.ReferenceRates.Fallback,
+			Volume:   input.ReferenceRates.Volume,
+		}
+		for _, rate := range input.ReferenceRates.Rates {
+			request.ReferenceRates.Rates = append(request.ReferenceRates.Rates, &rebasepb.ReferenceRate{
+				BaseAssetId:  rate.BaseAssetId,
+				QuoteAssetId: rate.QuoteAssetId,
+				Rate:         rate.Rate,
+			})
+		}
+	}
+	if input.Basket != nil {
+		request.Basket = &rebasepb.Basket{Geometric: input.Basket.Geometric}
+		for _, asset := range input.Basket.Assets {
+			request.Basket.Assets = append(request.Basket.Assets, &rebasepb.BasketAsset{
+				AssetId: asset.AssetId,
+				Weight:  asset.Weight,
+			})
+		}
+	}
+	for _, peg := range input.Pegs {
+		request.Pegs = append(request.Pegs, &rebasepb.Peg{
+			AssetId:   peg.AssetId,
+			PeggedTo:  peg.PeggedTo,
+			Ratio:     peg.Ratio,
+			Tolerance: peg.Tolerance,
+			Fallback:  peg.Fallback,
+			Volume:    peg.Volume,
+		})
+	}
+	if input.Assets != nil {
+		request.Assets = &rebasepb.Assets{}
+		for canonicalId, aliases := range input.Assets.Aliases {
+			if request.Assets.Aliases == nil {
+				request.Assets.Aliases = map[string]*rebasepb.AssetIds{}
+			}
+			request.Assets.Aliases[canonicalId] = &rebasepb.AssetIds{AssetIds: aliases}
+		}
+		for _, assetIds := range input.Assets.Equivalent {
+			request.Assets.Equivalent = append(request.Assets.Equivalent, &rebasepb.AssetIds{AssetIds: assetIds})
+		}
+	}
+	if input.AutoPathLength != nil {
+		request.AutoPathLength = &rebasepb.AutoPathLength{
+			Tolerance:     input.AutoPathLength.Tolerance,
+			MaxPathLength: uint32(input.AutoPathLength.MaxPathLength),
+			TimeBudgetMs:  uint32(input.AutoPathLength.TimeBudgetMs),
+		}
+	}
+	return &request
+}
+
+func OutputToProto(output api.Output) *rebasepb.RebaseResponse {
+	return &rebasepb.RebaseResponse{
+		RebaseAssetId: output.RebaseAssetId,
+		PathLength:    uint32(output.PathLength),
+		Partial:       output.Partial,
+		PartialReason: output.PartialReason,
+		Referenced:    output.Referenced,
+		Pegged:        output.Pegged,
+		BasketPrices:  output.BasketPrices,
+		Residuals:     output.Residuals,
+		Market:        PairsToProto(output.Market),
+	}
+}
+
+func OutputFromProto(response *rebasepb.RebaseResponse) api.Output {
+	return api.Output{
+		RebaseAssetId: response.GetRebaseAssetId(),
+		PathLength:    uint8(response.GetPathLength()),
+		Partial:       response.GetPartial(),
+		PartialReason: response.GetPartialReason(),
+		Referenced:    response.GetReferenced(),
+		Pegged:        response.GetPegged(),
+		BasketPrices:  response.GetBasketPrices(),
+		Residuals:     response.GetResiduals(),
+		Market:        PairsFromProto(response.GetMarket()),
+	}
+}
+
+func ConvertInputFromProto(request *rebasepb.ConvertRequest) (api.ConvertInput, error) {
+	input, err := InputFromProto(request.GetRebase())
+	if err != nil {
+		return api.ConvertInput{}, err
+	}
+	return api.ConvertInput{
+		Input:       input,
+		FromAssetId: request.GetFromAssetId(),
+		ToAssetId:   request.GetToAssetId(),
+		Amount:      request.GetAmount(),
+	}, nil
+}
+
+func ConvertOutputToProto(output api.ConvertOutput) *rebasepb.ConvertResponse {
+	return &rebasepb.ConvertResponse{
+		FromAssetId:     output.FromAssetId,
+		ToAssetId:       output.ToAssetId,
+		Amount:          output.Amount,
+		ConvertedAmount: output.ConvertedAmount,
+		PathLength:      uint32(output.PathLength),
+		Partial:         output.Partial,
+		PartialReason:   output.PartialReason,
+		Referenced:      output.Referenced,
+		Pegged:          output.Pegged,
+	}
+}
+
+func ReconcileOutputToProto(output api.ReconcileOutput) *rebasepb.ReconcileResponse {
+	response := &rebasepb.ReconcileResponse{
+		RebaseAssetId: output.RebaseAssetId,
+		Partial:       output.Partial,
+		PartialReason: output.PartialReason,
+	}
+	for _, pair := range output.Pairs {
+		response.Pairs = append(response.Pairs, &rebasepb.ReconciledPair{
+			Symbol:       pair.Symbol,
+			DirectMid:    pair.DirectMid,
+			ImpliedMid:   pair.ImpliedMid,
+			DeviationBps: pair.DeviationBps,
+		})
+	}
+	return response
+}
+
+func UpdateToProto(update streaming.Update) *rebasepb.RebaseResponse {
+	return &rebasepb.RebaseResponse{
+		RebaseAssetId: update.RebaseAssetId,
+		PathLength:    uint32(update.PathLength),
+		Market:        PairsToProto(update.Market),
+		Removed:       update.Removed,
+	}
+}
+
+func PairsFromProto(pbPairs []*rebasepb.Pair) []m.Pair {
+	pairs := []m.Pair{}
+	for _, pbPair := range pbPairs {
+		pair := m.Pair{
+			BaseAssetId:  pbPair.GetBaseAssetId(),
+			QuoteAssetId: pbPair.GetQuoteAssetId(),
+		}
+		for _, pbExchangeMarket := range pbPair.GetExchangeMarkets() {
+			pair.ExchangeMarkets = append(pair.ExchangeMarkets, m.ExchangeMarket{
+				ExchangeId:  pbExchangeMarket.GetExchangeId(),
+				CurrentBid:  pbExchangeMarket.GetCurrentBid(),
+				CurrentAsk:  pbExchangeMarket.GetCurrentAsk(),
+				BaseVolume:  pbExchangeMarket.GetBaseVolume(),
+				QuoteVolume: pbExchangeMarket.GetQuoteVolume(),
+			})
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+func PairsToProto(pairs []m.Pair) []*rebasepb.Pair {
+	var pbPairs []*rebasepb.Pair
+	for _, pair := range pairs {
+		pbPair := rebasepb.Pair{
+			BaseAssetId:  pair.BaseAssetId,
+			QuoteAssetId: pair.QuoteAssetId,
+		}
+		for _, emd := range pair.ExchangeMarkets {
+			pbPair.ExchangeMarkets = append(pbPair.ExchangeMarkets, &rebasepb.ExchangeMarket{
+				ExchangeId:  emd.ExchangeId,
+				CurrentBid:  emd.CurrentBid,
+				CurrentAsk:  emd.CurrentAsk,
+				BaseVolume:  emd.BaseVolume,
+				QuoteVolume: emd.QuoteVolume,
+			})
+		}
+		pbPairs = append(pbPairs, &pbPair)
+	}
+	return pbPairs
+}
+
+/**
+Encodes inputs and outputs as the RebaseRequest and RebaseResponse messages of the gRPC service.
+*/
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (protobufCodec) MarshalInput(input api.Input) ([]byte, error) {
+	return proto.Marshal(InputToProto(input))
+}
+
+func (protobufCodec) UnmarshalInput(data []byte) (api.Input, error) {
+	var request rebasepb.RebaseRequest
+	if err := proto.Unmarshal(data, &request); err != nil {
+		return api.Input{}, err
+	}
+	return InputFromProto(&request)
+}
+
+func (protobufCodec) MarshalOutput(output api.Output) ([]byte, error) {
+	return proto.Marshal(OutputToProto(output))
+}
+
+func (protobufCodec) UnmarshalOutput(data []byte) (api.Output, error) {
+	var response rebasepb.RebaseResponse
+	if err := proto.Unmarshal(data, &response); err != nil {
+		return api.Output{}, err
+	}
+	return OutputFromProto(&response), nil
+}